@@ -16,6 +16,7 @@ import (
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/inputs"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/rollout"
 	"github.com/spf13/cobra"
 )
 
@@ -46,6 +47,9 @@ var (
 	agentShowDiff       bool
 	agentForce          bool
 
+	agentWait        bool
+	agentWaitTimeout time.Duration
+
 	agentStackId string
 
 	agentListJSON      bool
@@ -134,6 +138,13 @@ on their create/update commands).`,
 			fmt.Fprintln(out, serverMessage)
 		}
 
+		if agentWait {
+			return waitForRollout(
+				cmd, deployClient, pCtx.orgId, pCtx.projectId, agentWaitTimeout,
+				rollout.Target{Kind: "agent", Name: agentName},
+			)
+		}
+
 		return nil
 	},
 }
@@ -188,6 +199,7 @@ config diff.`,
   iai agents update chat-agent --file agent-config.yaml
   iai agents update chat-agent --file agent-config.yaml --expect-revision 13
   iai agents update chat-agent --file agent-config.yaml --show-diff
+  iai agents update chat-agent --version 0.0.3 --wait
   iai agents update chat-agent --endpoint=false
   iai agents update chat-agent --schedule-uptime "Mon-Fri 07:30-20:30" --schedule-timezone Europe/Berlin
   iai agents update chat-agent --clear-schedule
@@ -291,6 +303,11 @@ config diff.`,
 			return err
 		}
 
+		var revision int
+		if liveErr == nil {
+			revision = live.Revision + 1
+		}
+
 		fmt.Fprintln(out)
 		fmt.Fprintln(out, "Submitting agent update request...")
 
@@ -309,6 +326,13 @@ config diff.`,
 			fmt.Fprintln(out, serverMessage)
 		}
 
+		if agentWait {
+			return waitForRollout(
+				cmd, deployClient, pCtx.orgId, pCtx.projectId, agentWaitTimeout,
+				rollout.Target{Kind: "agent", Name: agentName, Revision: revision},
+			)
+		}
+
 		return nil
 	},
 }
@@ -581,6 +605,7 @@ var (
 	agentLogsAllFields  bool
 	agentLogsTimestamps bool
 	agentLogsLimit      int
+	agentLogsFilter     logFilterFlags
 )
 
 var agentLogsCmd = &cobra.Command{
@@ -595,17 +620,32 @@ Structured (JSON) logs are automatically formatted: the level and message
 fields are extracted and displayed as "LEVEL message". Use --fields or
 --all-fields to include additional top-level fields after the message. Use
 --raw for exact server JSON, or --decode to decode embedded JSON strings into
-nested JSON values.`,
+nested JSON values.
+
+Filter entries with --level, --grep, --exclude, --where, and --replica; they
+apply as entries arrive, so they work with --follow and keep formatting
+intact. --level warn+ shows warnings and anything more severe. --grep and
+--exclude match the message of structured entries and the whole of plain-text
+lines. --where key=value matches a top-level field, like those
+'iai agents log-fields' lists. --replica takes a replica's full name or the
+suffix shown in brackets.`,
 	Example: `  iai agents logs my-agent
   iai agents logs my-agent --follow
   iai agents logs my-agent --since 30m
   iai agents logs my-agent --timestamps
-  iai agents logs my-agent --start-time 2026-01-01T00:00:00Z --end-time 2026-01-01T01:00:00Z`,
+  iai agents logs my-agent --start-time 2026-01-01T00:00:00Z --end-time 2026-01-01T01:00:00Z
+  iai agents logs my-agent --level warn+ --grep timeout
+  iai agents logs my-agent --follow --replica abc12 --exclude "health check"`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		out := cmd.OutOrStdout()
 		agentName := strings.TrimSpace(args[0])
 
+		filter, err := agentLogsFilter.filter()
+		if err != nil {
+			return err
+		}
+
 		ctx := cmd.Context()
 		if agentLogsFollow {
 			var stop func()
@@ -653,6 +693,7 @@ nested JSON values.`,
 			Fields:     agentLogsFields,
 			AllFields:  agentLogsAllFields,
 			Timestamps: agentLogsTimestamps,
+			Filter:     filter,
 		}
 		err = output.PrintLogStream(out, logsResp.Body, true, meta, fmtOpts)
 		if agentLogsFollow && ctx.Err() != nil {
@@ -922,7 +963,8 @@ var agentLogFieldsCmd = &cobra.Command{
 	Short: "List available fields in structured logs",
 	Long: `Scan recent logs and list the extra top-level fields present in structured (JSON) log entries.
 
-Use the reported field names with 'iai agents logs --fields' to include them in output.`,
+Use the reported field names with 'iai agents logs --fields' to include them in output,
+or with 'iai agents logs --where' to filter on them.`,
 	Example: `  iai agents log-fields my-agent
   iai agents log-fields my-agent --since 1h`,
 	Args: cobra.ExactArgs(1),
@@ -1009,6 +1051,7 @@ func init() {
 		StringVar(&agentStackId, "stack-id", "", "Stack ID to assign the agent to")
 	agentCreateCmd.Flags().
 		StringArrayVar(&agentMcpNames, "mcp", nil, "Attach an MCP by name (see 'iai mcps list'); can be repeated")
+	addWaitFlags(agentCreateCmd, &agentWait, &agentWaitTimeout)
 	_ = agentCreateCmd.MarkFlagRequired("id")
 	_ = agentCreateCmd.MarkFlagRequired("version")
 	_ = agentCreateCmd.MarkFlagRequired("file")
@@ -1056,6 +1099,7 @@ func init() {
 		BoolVar(&agentShowDiff, "show-diff", false, "Print a live-vs-incoming agent config diff to stderr before applying; requires --file, --mcp, or --detach-mcp")
 	agentUpdateCmd.Flags().
 		BoolVar(&agentForce, "force", false, "Apply even when the update would downgrade/remove live content pins or drop live env vars or secret refs")
+	addWaitFlags(agentUpdateCmd, &agentWait, &agentWaitTimeout)
 
 	// Flags for "agents list"
 	agentListCmd.Flags().
@@ -1137,6 +1181,7 @@ func init() {
 		BoolVar(&agentLogsTimestamps, "timestamps", false, "Include platform log timestamps")
 	agentLogsCmd.Flags().
 		IntVar(&agentLogsLimit, "limit", 0, "Maximum number of log entries to return (1-5000); defaults to 1000")
+	addLogFilterFlags(agentLogsCmd, &agentLogsFilter, true)
 	agentLogsCmd.MarkFlagsMutuallyExclusive("raw", "fields")
 	agentLogsCmd.MarkFlagsMutuallyExclusive("raw", "all-fields")
 	agentLogsCmd.MarkFlagsMutuallyExclusive("decode", "fields")
@@ -37,12 +37,12 @@ var (
 )
 
 var commentsCmd = &cobra.Command{
-	Use:              "comments",
-	Aliases:          []string{"comment"},
-	Short:            "Annotate traces, observations, and sessions",
-	GroupID:          groupObserve,
-	Long:             `Manage comments on traces, observations, sessions, and prompts.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "comments",
+	Aliases:           []string{"comment"},
+	Short:             "Annotate traces, observations, and sessions",
+	GroupID:           groupObserve,
+	Long:              `Manage comments on traces, observations, sessions, and prompts.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var commentsListCmd = &cobra.Command{
@@ -13,6 +13,7 @@ import (
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/inputs"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/rollout"
 	"github.com/spf13/cobra"
 )
 
@@ -40,6 +41,9 @@ var (
 
 	dbStackId string
 
+	dbWait        bool
+	dbWaitTimeout time.Duration
+
 	dbSourceDatabase string
 	dbTargetTime     string
 
@@ -53,6 +57,7 @@ var (
 	dbLogsAllFields  bool
 	dbLogsTimestamps bool
 	dbLogsLimit      int
+	dbLogsFilter     logFilterFlags
 )
 
 var databasesCmd = &cobra.Command{
@@ -203,6 +208,13 @@ during the upgrade.`,
 			fmt.Fprintln(out, serverMessage)
 		}
 
+		if dbWait {
+			return waitForRollout(
+				cmd, deployClient, pCtx.orgId, pCtx.projectId, dbWaitTimeout,
+				rollout.Target{Kind: "database", Name: databaseName},
+			)
+		}
+
 		return nil
 	},
 }
@@ -221,6 +233,7 @@ Use --clear-stack-id to remove the database from its stack.`,
 	Example: `  iai databases update my-db --instances 3
   iai databases update my-db --cpu 2 --memory 4G
   iai databases update my-db --storage-size 50G
+  iai databases update my-db --instances 3 --wait --timeout 15m
   iai databases update my-db --backup-schedule "0 0 3 * * *" --backup-retention 60d
   iai databases update my-db --clear-backup
   iai databases update my-db --stack-id my-stack
@@ -253,6 +266,17 @@ Use --clear-stack-id to remove the database from its stack.`,
 			return fmt.Errorf("no fields to update; pass at least one flag")
 		}
 
+		// The revision the update creates, so --wait doesn't mistake the
+		// current revision for the finished rollout.
+		var revision int
+		if dbWait {
+			if live, err := deployClient.DescribeDatabase(
+				cmd.Context(), pCtx.orgId, pCtx.projectId, databaseName,
+			); err == nil {
+				revision = live.Revision + 1
+			}
+		}
+
 		fmt.Fprintln(out)
 		fmt.Fprintln(out, "Submitting database update request...")
 
@@ -271,6 +295,13 @@ Use --clear-stack-id to remove the database from its stack.`,
 			fmt.Fprintln(out, serverMessage)
 		}
 
+		if dbWait {
+			return waitForRollout(
+				cmd, deployClient, pCtx.orgId, pCtx.projectId, dbWaitTimeout,
+				rollout.Target{Kind: "database", Name: databaseName, Revision: revision},
+			)
+		}
+
 		return nil
 	},
 }
@@ -401,12 +432,24 @@ extracted and displayed. PostgreSQL-style logs use a "record" envelope — the
 severity and message are extracted from it automatically. Use --fields record
 to see the nested PostgreSQL details; --all-fields includes extra top-level
 fields only. Use --raw for exact server JSON, or --decode to decode embedded
-JSON strings into nested JSON values.`,
+JSON strings into nested JSON values.
+
+Filter entries with --level, --grep, --exclude, --where, and --replica; they
+apply as entries arrive, so they work with --follow and keep formatting
+intact. --level warn+ shows warnings and anything more severe. --grep and
+--exclude match the message of structured entries and the whole of plain-text
+lines. --where key=value matches a top-level field, like those
+'iai databases log-fields' lists. --replica takes a replica's full name or the
+suffix shown in brackets. The PostgreSQL details nested under "record" are not
+top-level fields, so --where can't match them; --level and --grep use the
+record's severity and message.`,
 	Example: `  iai databases logs my-db
   iai databases logs my-db --follow
   iai databases logs my-db --since 30m
   iai databases logs my-db --timestamps
-  iai databases logs my-db --start-time 2026-01-01T00:00:00Z --end-time 2026-01-01T01:00:00Z`,
+  iai databases logs my-db --start-time 2026-01-01T00:00:00Z --end-time 2026-01-01T01:00:00Z
+  iai databases logs my-db --level error+ --grep "authentication failed"
+  iai databases logs my-db --follow --exclude checkpoint`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		out := cmd.OutOrStdout()
@@ -416,6 +459,11 @@ JSON strings into nested JSON values.`,
 			return fmt.Errorf("database name is required")
 		}
 
+		filter, err := dbLogsFilter.filter()
+		if err != nil {
+			return err
+		}
+
 		ctx := cmd.Context()
 		if dbLogsFollow {
 			var stop func()
@@ -470,6 +518,7 @@ JSON strings into nested JSON values.`,
 			AllFields:  dbLogsAllFields,
 			CNPGFormat: true,
 			Timestamps: dbLogsTimestamps,
+			Filter:     filter,
 		}
 		err = output.PrintLogStream(out, logsResp.Body, true, meta, fmtOpts)
 		if dbLogsFollow && ctx.Err() != nil {
@@ -488,7 +537,8 @@ var dbLogFieldsCmd = &cobra.Command{
 
 PostgreSQL-specific details are often nested under the 'record' field, so seeing
 'record' in the results is expected. Use the reported field names with
-'iai databases logs --fields' to include them in output.`,
+'iai databases logs --fields' to include them in output,
+or with 'iai databases logs --where' to filter on them.`,
 	Example: `  iai databases log-fields my-db
   iai databases log-fields my-db --since 1h`,
 	Args: cobra.ExactArgs(1),
@@ -750,6 +800,7 @@ func init() {
 	addDatabaseResourceFlags(dbCreateCmd)
 	dbCreateCmd.Flags().
 		StringVar(&dbStackId, "stack-id", "", "Stack ID to assign the database to")
+	addWaitFlags(dbCreateCmd, &dbWait, &dbWaitTimeout)
 	_ = dbCreateCmd.MarkFlagRequired("instances")
 	_ = dbCreateCmd.MarkFlagRequired("cpu")
 	_ = dbCreateCmd.MarkFlagRequired("memory")
@@ -767,6 +818,7 @@ func init() {
 		StringVar(&dbStackId, "stack-id", "", "Stack ID to assign the database to")
 	dbUpdateCmd.Flags().
 		BoolVar(&dbClearStackId, "clear-stack-id", false, "Remove the database from its stack")
+	addWaitFlags(dbUpdateCmd, &dbWait, &dbWaitTimeout)
 
 	// databases delete
 	dbDeleteCmd.Flags().
@@ -811,6 +863,7 @@ func init() {
 		BoolVar(&dbLogsTimestamps, "timestamps", false, "Include platform log timestamps")
 	dbLogsCmd.Flags().
 		IntVar(&dbLogsLimit, "limit", 0, "Maximum number of log entries to return (1-5000); defaults to 1000")
+	addLogFilterFlags(dbLogsCmd, &dbLogsFilter, true)
 	dbLogsCmd.MarkFlagsMutuallyExclusive("raw", "fields")
 	dbLogsCmd.MarkFlagsMutuallyExclusive("raw", "all-fields")
 	dbLogsCmd.MarkFlagsMutuallyExclusive("decode", "fields")
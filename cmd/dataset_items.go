@@ -44,12 +44,12 @@ var (
 )
 
 var datasetItemsCmd = &cobra.Command{
-	Use:              "dataset-items",
-	Aliases:          []string{"dataset-item"},
-	Short:            "Manage items in evaluation datasets",
-	GroupID:          groupEvaluation,
-	Long:             `Manage individual items within evaluation datasets.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "dataset-items",
+	Aliases:           []string{"dataset-item"},
+	Short:             "Manage items in evaluation datasets",
+	GroupID:           groupEvaluation,
+	Long:              `Manage individual items within evaluation datasets.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var datasetItemsListCmd = &cobra.Command{
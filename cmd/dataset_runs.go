@@ -31,12 +31,12 @@ var (
 )
 
 var datasetRunsCmd = &cobra.Command{
-	Use:              "dataset-runs",
-	Aliases:          []string{"dataset-run"},
-	Short:            "Run evaluations against datasets",
-	GroupID:          groupEvaluation,
-	Long:             `Manage evaluation runs within datasets.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "dataset-runs",
+	Aliases:           []string{"dataset-run"},
+	Short:             "Run evaluations against datasets",
+	GroupID:           groupEvaluation,
+	Long:              `Manage evaluation runs within datasets.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var datasetRunsListCmd = &cobra.Command{
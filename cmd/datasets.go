@@ -32,12 +32,12 @@ var (
 )
 
 var datasetsCmd = &cobra.Command{
-	Use:              "datasets",
-	Aliases:          []string{"dataset"},
-	Short:            "Create and list evaluation datasets",
-	GroupID:          groupEvaluation,
-	Long:             `Manage evaluation datasets. Works with API key or session login.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "datasets",
+	Aliases:           []string{"dataset"},
+	Short:             "Create and list evaluation datasets",
+	GroupID:           groupEvaluation,
+	Long:              `Manage evaluation datasets. Works with API key or session login.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var datasetsListCmd = &cobra.Command{
@@ -348,7 +348,8 @@ open when the existing tags cannot be listed.`,
 		req.Header.Set("Content-Type", "application/x-tar")
 
 		httpClient := &http.Client{
-			Timeout: 5 * time.Minute,
+			Transport: clients.DefaultTransport,
+			Timeout:   5 * time.Minute,
 		}
 
 		fmt.Fprintln(out)
@@ -365,10 +366,9 @@ open when the existing tags cannot be listed.`,
 
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 			body, _ := io.ReadAll(resp.Body)
-			if msg := clients.ExtractServerMessage(body); msg != "" {
-				return fmt.Errorf("%s", msg)
-			}
-			return fmt.Errorf("failed to push image: server returned %s", resp.Status)
+			return clients.NewAPIError(
+				resp, body, fmt.Sprintf("failed to push image: server returned %s", resp.Status),
+			)
 		}
 
 		var result struct {
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/auth"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -150,7 +151,8 @@ func runInteractiveLogin(cmd *cobra.Command) error {
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{
-		Timeout: defaultHTTPTimeout,
+		Transport: clients.DefaultTransport,
+		Timeout:   defaultHTTPTimeout,
 	}
 
 	fmt.Fprintln(out, "Logging in to InteractiveAI...")
@@ -161,7 +163,9 @@ func runInteractiveLogin(cmd *cobra.Command) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("login failed with status %s", resp.Status)
+		return clients.NewAPIError(
+			resp, nil, fmt.Sprintf("login failed with status %s", resp.Status),
+		)
 	}
 
 	cookies := resp.Cookies()
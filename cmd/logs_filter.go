@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// logFilterFlags holds the client-side filters of a logs command.
+type logFilterFlags struct {
+	level    string
+	grep     string
+	exclude  string
+	where    []string
+	replicas []string
+}
+
+// addLogFilterFlags registers --level, --grep, --exclude, --where, and, for
+// commands streaming several replicas, --replica.
+func addLogFilterFlags(c *cobra.Command, f *logFilterFlags, withReplica bool) {
+	c.Flags().
+		StringVar(&f.level, "level", "", "Show only entries at this level (trace, debug, info, warn, error, fatal); append + to include more severe levels (e.g. --level warn+)")
+	c.Flags().
+		StringVar(&f.grep, "grep", "", "Show only entries whose message matches this regular expression")
+	c.Flags().
+		StringVar(&f.exclude, "exclude", "", "Hide entries whose message matches this regular expression")
+	c.Flags().
+		StringArrayVar(&f.where, "where", nil, "Show only structured entries whose top-level field has this value (key=value, as listed by log-fields); repeatable, all must match")
+	if withReplica {
+		c.Flags().
+			StringSliceVar(&f.replicas, "replica", nil, "Show only entries from these replicas, by name or the suffix shown in brackets; repeatable")
+	}
+}
+
+// filter returns the filter the flags describe, or nil when none are set.
+func (f *logFilterFlags) filter() (*output.LogFilter, error) {
+	if f.level == "" && f.grep == "" && f.exclude == "" &&
+		len(f.where) == 0 && len(f.replicas) == 0 {
+		return nil, nil
+	}
+	filter, err := output.NewLogFilter(f.level, f.grep, f.exclude, f.where, f.replicas)
+	if err != nil {
+		return nil, &exitCodeError{code: exitUsage, err: fmt.Errorf("invalid log filter: %w", err)}
+	}
+	return filter, nil
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/inputs"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/rollout"
 	"github.com/spf13/cobra"
 )
 
@@ -49,6 +51,11 @@ var (
 
 var mcpForce bool
 
+var (
+	mcpWait        bool
+	mcpWaitTimeout time.Duration
+)
+
 var (
 	mcpArgsJSON string
 	mcpArgsFile string
@@ -191,6 +198,13 @@ and the create fails if the server is unreachable or rejects the credential.`,
 		if serverMessage != "" {
 			fmt.Fprintln(out, serverMessage)
 		}
+		if mcpWait {
+			return waitForRollout(
+				cmd, deployClient, pCtx.orgId, pCtx.projectId, mcpWaitTimeout,
+				rollout.Target{Kind: "mcp", Name: mcpName},
+			)
+		}
+
 		return nil
 	},
 }
@@ -208,6 +222,7 @@ Changing --credential, or switching --auth-type to "none", rotates the mcp's
 Secret and restarts the mcp (if internal) and every agent currently attached
 to it. Auth routing cannot change while agents are attached — detach them first.`,
 	Example: `  iai mcps update my-tool --image-tag v2
+  iai mcps update my-tool --image-tag v2 --wait
   iai mcps update my-tool --memory 1G --cpu 500m
   iai mcps update acme --credential "$NEW_TOKEN"
   iai mcps update my-tool --clear-headers
@@ -252,6 +267,17 @@ to it. Auth routing cannot change while agents are attached — detach them firs
 			return err
 		}
 
+		// The revision the update creates, so --wait doesn't mistake the
+		// current revision for the finished rollout.
+		var revision int
+		if mcpWait {
+			if live, err := deployClient.DescribeMcp(
+				cmd.Context(), pCtx.orgId, pCtx.projectId, mcpName,
+			); err == nil {
+				revision = live.Revision + 1
+			}
+		}
+
 		fmt.Fprintln(out)
 		fmt.Fprintln(out, "Submitting mcp update request...")
 
@@ -268,6 +294,13 @@ to it. Auth routing cannot change while agents are attached — detach them firs
 		if serverMessage != "" {
 			fmt.Fprintln(out, serverMessage)
 		}
+		if mcpWait {
+			return waitForRollout(
+				cmd, deployClient, pCtx.orgId, pCtx.projectId, mcpWaitTimeout,
+				rollout.Target{Kind: "mcp", Name: mcpName, Revision: revision},
+			)
+		}
+
 		return nil
 	},
 }
@@ -626,6 +659,7 @@ func init() {
 		c.Flags().
 			StringVar(&mcpStackId, "stack-id", "", "Stack ID to assign the mcp to")
 		c.MarkFlagsMutuallyExclusive("credential", "credential-stdin")
+		addWaitFlags(c, &mcpWait, &mcpWaitTimeout)
 	}
 
 	mcpCreateCmd.Flags().
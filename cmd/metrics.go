@@ -29,12 +29,12 @@ var (
 )
 
 var metricsCmd = &cobra.Command{
-	Use:              "metrics",
-	Aliases:          []string{"metric"},
-	Short:            "Query aggregated observability metrics",
-	GroupID:          groupObserve,
-	Long:             `Access observability metrics. Works with API key (--api-key or INTERACTIVE_API_KEY) or session from 'iai login'.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "metrics",
+	Aliases:           []string{"metric"},
+	Short:             "Query aggregated observability metrics",
+	GroupID:           groupObserve,
+	Long:              `Access observability metrics. Works with API key (--api-key or INTERACTIVE_API_KEY) or session from 'iai login'.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var metricsListCmd = &cobra.Command{
@@ -27,11 +27,11 @@ var (
 )
 
 var modelsCmd = &cobra.Command{
-	Use:              "models",
-	Aliases:          []string{"model"},
-	Short:            "List and inspect models",
-	Long:             `List and inspect router models available to a project.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "models",
+	Aliases:           []string{"model"},
+	Short:             "List and inspect models",
+	Long:              `List and inspect router models available to a project.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var modelsListCmd = &cobra.Command{
@@ -39,12 +39,12 @@ var (
 )
 
 var observationsCmd = &cobra.Command{
-	Use:              "observations",
-	Aliases:          []string{"obs", "observation"},
-	Short:            "Inspect spans within traces",
-	GroupID:          groupObserve,
-	Long:             `Manage observations within traces. Works with API key (--api-key or INTERACTIVE_API_KEY) or session from 'iai login'.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "observations",
+	Aliases:           []string{"obs", "observation"},
+	Short:             "Inspect spans within traces",
+	GroupID:           groupObserve,
+	Long:              `Manage observations within traces. Works with API key (--api-key or INTERACTIVE_API_KEY) or session from 'iai login'.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var obsListCmd = &cobra.Command{
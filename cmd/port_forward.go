@@ -112,8 +112,7 @@ func handlePortForwardConn(
 ) {
 	defer tcpConn.Close()
 
-	dialer := websocket.Dialer{}
-	wsConn, resp, err := dialer.DialContext(ctx, wsURL, headers)
+	wsConn, resp, err := clients.NewWebsocketDialer().DialContext(ctx, wsURL, headers)
 	if err != nil && resp != nil {
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
@@ -50,12 +50,12 @@ var (
 )
 
 var queueItemsCmd = &cobra.Command{
-	Use:              "queue-items",
-	Aliases:          []string{"queue-item"},
-	Short:            "Manage items in annotation queues",
-	GroupID:          groupEvaluation,
-	Long:             `Manage items within annotation queues.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "queue-items",
+	Aliases:           []string{"queue-item"},
+	Short:             "Manage items in annotation queues",
+	GroupID:           groupEvaluation,
+	Long:              `Manage items within annotation queues.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var queueItemsListCmd = &cobra.Command{
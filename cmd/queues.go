@@ -45,12 +45,12 @@ var (
 )
 
 var queuesCmd = &cobra.Command{
-	Use:              "queues",
-	Aliases:          []string{"queue"},
-	Short:            "Annotation queues for human review workflows",
-	GroupID:          groupEvaluation,
-	Long:             `Manage annotation queues for review workflows.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "queues",
+	Aliases:           []string{"queue"},
+	Short:             "Annotation queues for human review workflows",
+	GroupID:           groupEvaluation,
+	Long:              `Manage annotation queues for review workflows.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var queuesListCmd = &cobra.Command{
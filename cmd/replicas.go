@@ -139,6 +139,7 @@ var (
 	replicaLogsAllFields  bool
 	replicaLogsTimestamps bool
 	replicaLogsLimit      int
+	replicaLogsFilter     logFilterFlags
 )
 
 var replicasLogsCmd = &cobra.Command{
@@ -153,12 +154,21 @@ Structured (JSON) logs are automatically formatted: the level and message
 fields are extracted and displayed as "LEVEL message". Use --fields or
 --all-fields to include additional top-level fields after the message. Use
 --raw for exact server JSON, or --decode to decode embedded JSON strings into
-nested JSON values.`,
+nested JSON values.
+
+Filter entries with --level, --grep, --exclude, and --where; they apply as
+entries arrive, so they work with --follow and keep formatting intact. --level
+warn+ shows warnings and anything more severe. --grep and --exclude match the
+message of structured entries and the whole of plain-text lines. --where
+key=value matches a top-level field, like those 'iai replicas log-fields'
+lists.`,
 	Example: `  iai replicas logs my-service-abc123
   iai replicas logs my-service-abc123 --follow
   iai replicas logs my-service-abc123 --since 30m --fields logger,pid
   iai replicas logs my-service-abc123 --timestamps
-  iai replicas logs my-service-abc123 --start-time 2026-01-01T00:00:00Z --end-time 2026-01-01T01:00:00Z`,
+  iai replicas logs my-service-abc123 --start-time 2026-01-01T00:00:00Z --end-time 2026-01-01T01:00:00Z
+  iai replicas logs my-service-abc123 --level warn+ --grep timeout
+  iai replicas logs my-service-abc123 --follow --where logger=http`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		out := cmd.OutOrStdout()
@@ -168,6 +178,11 @@ nested JSON values.`,
 			return fmt.Errorf("replica name is required")
 		}
 
+		filter, err := replicaLogsFilter.filter()
+		if err != nil {
+			return err
+		}
+
 		ctx := cmd.Context()
 		if replicaLogsFollow {
 			var stop func()
@@ -256,6 +271,7 @@ nested JSON values.`,
 			Fields:     replicaLogsFields,
 			AllFields:  replicaLogsAllFields,
 			Timestamps: replicaLogsTimestamps,
+			Filter:     filter,
 		}
 		err = output.PrintLogStream(out, logsResp.Body, false, meta, fmtOpts)
 		if replicaLogsFollow && ctx.Err() != nil {
@@ -272,7 +288,8 @@ var replicaLogFieldsCmd = &cobra.Command{
 	Short: "List available fields in structured logs",
 	Long: `Scan recent logs and list the extra top-level fields present in structured (JSON) log entries.
 
-Use the reported field names with 'iai replicas logs --fields' to include them in output.`,
+Use the reported field names with 'iai replicas logs --fields' to include them in output,
+or with 'iai replicas logs --where' to filter on them.`,
 	Example: `  iai replicas log-fields my-service-abc123
   iai replicas log-fields my-service-abc123 --since 1h`,
 	Args: cobra.ExactArgs(1),
@@ -371,6 +388,7 @@ func init() {
 		BoolVar(&replicaLogsTimestamps, "timestamps", false, "Include platform log timestamps")
 	replicasLogsCmd.Flags().
 		IntVar(&replicaLogsLimit, "limit", 0, "Maximum number of log entries to return (1-5000); defaults to 1000")
+	addLogFilterFlags(replicasLogsCmd, &replicaLogsFilter, false)
 	replicasLogsCmd.MarkFlagsMutuallyExclusive("raw", "fields")
 	replicasLogsCmd.MarkFlagsMutuallyExclusive("raw", "all-fields")
 	replicasLogsCmd.MarkFlagsMutuallyExclusive("decode", "fields")
@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/buildinfo"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/versioncheck"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -32,15 +36,51 @@ var (
 	token              string
 	apiKey             string
 	cfgFilePath        string
+	debug              bool
+	debugFile          string
+	debugCurl          bool
+	debugOut           *os.File
+	transportOpts      clients.TransportOptions
+	recordDir          string
+	replayDir          string
 	rootCmd            = &cobra.Command{
 		Use:     "iai",
 		Short:   "InteractiveAI's CLI",
 		Version: version,
 		Long: `InteractiveAI's CLI to interact with its platform.
 
-Use the subcommands below to manage your organizations, projects, agents, services, secrets, prompts, routines, policies, variables, glossaries, macros, and other components.`,
+Use the subcommands below to manage your organizations, projects, agents, services, secrets, prompts, routines, policies, variables, glossaries, macros, and other components.
+
+Requests go through the proxy the HTTPS_PROXY, HTTP_PROXY, and NO_PROXY
+environment variables name. Behind a TLS-inspecting proxy, trust its CA with
+--ca-file; --client-cert and --client-key authenticate with mutual TLS.
+
+--record <dir> saves the API requests a command makes and their responses, and
+--replay <dir> answers the same requests from them without a backend: to
+attach a reproducible session to a bug report, or to demo the CLI offline.
+Replays still need credentials of the kind that was recorded, but not valid
+ones.
+
+Exit codes:
+  0   success
+  1   any other failure
+  2   usage error: unknown command, invalid flags or arguments
+  3   authentication failed or not allowed (401, 403), or no credentials
+  4   not found (404)
+  5   conflict (409, 412)
+  6   validation failed (400, 422)
+  10  stacks drift: local changes pending
+  11  stacks drift: live drift detected`,
 		SilenceUsage: true,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Cobra checks required flags and flag groups only after this
+			// hook; check them first so they fail as usage errors.
+			if err := cmd.ValidateRequiredFlags(); err != nil {
+				return err
+			}
+			if err := cmd.ValidateFlagGroups(); err != nil {
+				return err
+			}
 			if !strings.HasPrefix(hostname, "http://") && !strings.HasPrefix(hostname, "https://") {
 				hostname = "https://" + hostname
 			}
@@ -48,6 +88,14 @@ Use the subcommands below to manage your organizations, projects, agents, servic
 				!strings.HasPrefix(deploymentHostname, "https://") {
 				deploymentHostname = "https://" + deploymentHostname
 			}
+			configureDebug(cmd)
+			if err := configureTransport(); err != nil {
+				return err
+			}
+			if err := configureCassette(); err != nil {
+				return err
+			}
+			commandStarted = true
 
 			// RefreshCache is intentionally gated too: no point keeping the
 			// cache warm when the notice can't be shown anyway.
@@ -55,10 +103,61 @@ Use the subcommands below to manage your organizations, projects, agents, servic
 				notifyUpdate()
 				go versioncheck.RefreshCache(cfgDirName)
 			}
+			return nil
 		},
 	}
 )
 
+// configureDebug sets up the tracing of API requests from --debug,
+// --debug-file, and --debug-curl. The trace goes to stderr, or is appended to
+// --debug-file, which implies --debug.
+func configureDebug(cmd *cobra.Command) {
+	if !debug && !debugCurl && debugFile == "" {
+		return
+	}
+	out := cmd.ErrOrStderr()
+	if debugFile != "" {
+		f, err := os.OpenFile(debugFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			fmt.Fprintf(out, "Warning: tracing to stderr: %v\n", err)
+		} else {
+			debugOut = f
+			out = f
+		}
+	}
+	clients.DefaultDebug = clients.DebugOptions{
+		Out:   out,
+		Trace: debug || debugFile != "",
+		Curl:  debugCurl,
+	}
+}
+
+// configureTransport builds the transport every client uses from --ca-file,
+// --client-cert, and --client-key.
+func configureTransport() error {
+	t, err := clients.NewTransport(transportOpts)
+	if err != nil {
+		return err
+	}
+	clients.DefaultTransport = t
+	return nil
+}
+
+// configureCassette makes the API clients record to --record or replay from
+// --replay.
+func configureCassette() error {
+	var err error
+	switch {
+	case recordDir != "" && replayDir != "":
+		return fmt.Errorf("--record and --replay can't be used together")
+	case recordDir != "":
+		clients.DefaultCassette, err = clients.RecordCassette(recordDir)
+	case replayDir != "":
+		clients.DefaultCassette, err = clients.ReplayCassette(replayDir)
+	}
+	return err
+}
+
 // updateNoticeAllowed reports whether the upgrade nudge may be shown: never
 // in CI, in scripts (stderr not a terminal), or when the user opted out.
 func updateNoticeAllowed() bool {
@@ -81,19 +180,23 @@ func notifyUpdate() {
 	)
 }
 
-// chainRootPersistentPreRun calls the root command's PersistentPreRun manually.
-// Cobra doesn't chain PersistentPreRun hooks, so subcommands that define their
+// chainRootPersistentPreRun calls the root command's PersistentPreRunE manually.
+// Cobra doesn't chain PersistentPreRunE hooks, so subcommands that define their
 // own must call this to preserve URL normalization.
-var chainRootPersistentPreRun = func(cmd *cobra.Command, args []string) {
-	if root := cmd.Root(); root != nil && root.PersistentPreRun != nil {
-		root.PersistentPreRun(cmd, args)
+var chainRootPersistentPreRun = func(cmd *cobra.Command, args []string) error {
+	if root := cmd.Root(); root != nil && root.PersistentPreRunE != nil {
+		return root.PersistentPreRunE(cmd, args)
 	}
+	return nil
 }
 
 var updateMessage = make(chan string, 1)
 
 func Execute() {
 	err := rootCmd.Execute()
+	if debugOut != nil {
+		debugOut.Close()
+	}
 
 	select {
 	case msg := <-updateMessage:
@@ -102,8 +205,72 @@ func Execute() {
 	}
 
 	if err != nil {
-		os.Exit(1)
+		var apiErr *clients.APIError
+		if errors.As(err, &apiErr) && apiErr.RequestID != "" {
+			fmt.Fprintf(os.Stderr, "Request ID: %s\n", apiErr.RequestID)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// Exit codes scripts can tell failures apart by; documented in rootCmd's Long.
+const (
+	exitUsage      = 2
+	exitAuth       = 3
+	exitNotFound   = 4
+	exitConflict   = 5
+	exitValidation = 6
+)
+
+// commandStarted is set once the root command's PersistentPreRunE has
+// checked the flags and set up the clients. Cobra parses flags and validates
+// arguments before that, so an error returned earlier is a usage error.
+var commandStarted bool
+
+// exitCode returns the code Execute exits with after err.
+func exitCode(err error) int {
+	var exitErr *exitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	if !commandStarted {
+		return exitUsage
+	}
+	if errors.Is(err, clients.ErrNoCredentials) {
+		return exitAuth
+	}
+
+	var apiErr *clients.APIError
+	if !errors.As(err, &apiErr) {
+		return 1
+	}
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return exitAuth
+	case http.StatusNotFound:
+		return exitNotFound
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return exitConflict
+	}
+	if apiErr.Validation() {
+		return exitValidation
 	}
+	return 1
+}
+
+// exitCodeError makes Execute exit with code instead of 1. It is reported
+// like any other error.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
 }
 
 func init() {
@@ -127,6 +294,32 @@ func init() {
 		apiKey = envApiKey
 	}
 
+	transportOpts.CAFile = os.Getenv("IAI_CA_BUNDLE")
+	transportOpts.CertFile = os.Getenv("IAI_CLIENT_CERT")
+	transportOpts.KeyFile = os.Getenv("IAI_CLIENT_KEY")
+
+	envDebug := os.Getenv("IAI_DEBUG")
+	if envDebug != "" {
+		if on, err := strconv.ParseBool(envDebug); err == nil {
+			debug = on
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring IAI_DEBUG=%q: not a boolean\n", envDebug)
+		}
+	}
+
+	envMaxRetries := os.Getenv("INTERACTIVE_MAX_RETRIES")
+	if envMaxRetries != "" {
+		if n, err := strconv.Atoi(envMaxRetries); err == nil {
+			clients.DefaultRetryPolicy.MaxRetries = n
+		} else {
+			fmt.Fprintf(
+				os.Stderr,
+				"Warning: ignoring INTERACTIVE_MAX_RETRIES=%q: not a number\n",
+				envMaxRetries,
+			)
+		}
+	}
+
 	rootCmd.AddGroup(
 		&cobra.Group{ID: groupAuth, Title: "Auth:"},
 		&cobra.Group{ID: groupInfra, Title: "Infrastructure:"},
@@ -140,6 +333,24 @@ func init() {
 	rootCmd.PersistentFlags().
 		StringVar(&deploymentHostname, "deployment-hostname", deploymentHostname, "Hostname for the deployment API")
 	rootCmd.PersistentFlags().StringVar(&apiKey, "api-key", apiKey, "API key for authentication")
+	rootCmd.PersistentFlags().
+		StringVar(&transportOpts.CAFile, "ca-file", transportOpts.CAFile, "PEM bundle of CAs to trust besides the system's, e.g. a TLS-inspecting proxy's (env IAI_CA_BUNDLE)")
+	rootCmd.PersistentFlags().
+		StringVar(&transportOpts.CertFile, "client-cert", transportOpts.CertFile, "PEM client certificate for mutual TLS; needs --client-key (env IAI_CLIENT_CERT)")
+	rootCmd.PersistentFlags().
+		StringVar(&transportOpts.KeyFile, "client-key", transportOpts.KeyFile, "PEM key of --client-cert (env IAI_CLIENT_KEY)")
+	rootCmd.PersistentFlags().
+		StringVar(&recordDir, "record", "", "Record every API request and response to this directory, with credentials and secret values scrubbed")
+	rootCmd.PersistentFlags().
+		StringVar(&replayDir, "replay", "", "Answer API requests from the responses --record wrote to this directory instead of the network")
+	rootCmd.PersistentFlags().
+		BoolVar(&debug, "debug", debug, "Trace API requests and responses to stderr, with credentials and secret values redacted (env IAI_DEBUG)")
+	rootCmd.PersistentFlags().
+		StringVar(&debugFile, "debug-file", "", "Append the --debug trace to this file instead of stderr")
+	rootCmd.PersistentFlags().
+		BoolVar(&debugCurl, "debug-curl", false, "Print an equivalent curl command for each API request")
+	rootCmd.PersistentFlags().
+		IntVar(&clients.DefaultRetryPolicy.MaxRetries, "max-retries", clients.DefaultRetryPolicy.MaxRetries, "Retries of idempotent API requests after connection errors and 429/502/503/504 responses (0 disables; env INTERACTIVE_MAX_RETRIES)")
 	rootCmd.PersistentFlags().
 		StringVar(&cfgFilePath, "cfg-file", "", "Path to YAML config file with organization, project, and optional service definitions")
 }
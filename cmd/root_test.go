@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients"
+	"github.com/spf13/pflag"
+)
+
+func TestExitCode(t *testing.T) {
+	apiErr := func(status int) error {
+		resp := &http.Response{StatusCode: status, Status: http.StatusText(status)}
+		return fmt.Errorf("request failed: %w", clients.NewAPIError(resp, nil, ""))
+	}
+
+	tests := []struct {
+		name    string
+		started bool
+		err     error
+		want    int
+	}{
+		{name: "usage", err: errors.New(`unknown flag: --nope`), want: exitUsage},
+		{name: "unauthorized", started: true, err: apiErr(401), want: exitAuth},
+		{name: "forbidden", started: true, err: apiErr(403), want: exitAuth},
+		{name: "no credentials", started: true, err: clients.ErrNoCredentials, want: exitAuth},
+		{name: "not found", started: true, err: apiErr(404), want: exitNotFound},
+		{name: "conflict", started: true, err: apiErr(409), want: exitConflict},
+		{name: "bad request", started: true, err: apiErr(400), want: exitValidation},
+		{name: "unprocessable", started: true, err: apiErr(422), want: exitValidation},
+		{name: "server error", started: true, err: apiErr(500), want: 1},
+		{name: "other", started: true, err: errors.New("boom"), want: 1},
+		{
+			name:    "explicit code",
+			started: true,
+			err:     &exitCodeError{code: exitDriftDetected, err: errors.New("drift")},
+			want:    exitDriftDetected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commandStarted = tt.started
+			t.Cleanup(func() { commandStarted = false })
+
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagErrorsAreUsageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "missing required flag",
+			args:    []string{"stacks", "plan", "--file", "stack.yaml"},
+			wantErr: `required flag(s) "out" not set`,
+		},
+		{
+			name:    "mutually exclusive flags",
+			args:    []string{"services", "logs", "api", "--raw", "--fields", "pid"},
+			wantErr: "[fields raw] were all set",
+		},
+		{
+			name:    "invalid transport flags",
+			args:    []string{"--client-cert", "cert.pem", "stacks", "plan", "--out", "plan.json"},
+			wantErr: "needs both a certificate and a key file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				commandStarted = false
+				transportOpts = clients.TransportOptions{}
+				// Required flags and flag groups are checked by whether
+				// flags were set, which sticks across Execute calls.
+				if executed, _, err := rootCmd.Find(tt.args); err == nil {
+					executed.Flags().VisitAll(func(f *pflag.Flag) { f.Changed = false })
+				}
+				rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) { f.Changed = false })
+			})
+
+			rootCmd.SetArgs(tt.args)
+			rootCmd.SetOut(io.Discard)
+			rootCmd.SetErr(io.Discard)
+			err := rootCmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Execute() error = %v, want one containing %q", err, tt.wantErr)
+			}
+			if got := exitCode(err); got != exitUsage {
+				t.Errorf("exitCode(%v) = %d, want %d", err, got, exitUsage)
+			}
+		})
+	}
+}
@@ -31,12 +31,12 @@ var (
 )
 
 var runItemsCmd = &cobra.Command{
-	Use:              "run-items",
-	Aliases:          []string{"run-item"},
-	Short:            "Inspect results of evaluation runs",
-	GroupID:          groupEvaluation,
-	Long:             `Manage items within dataset runs.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "run-items",
+	Aliases:           []string{"run-item"},
+	Short:             "Inspect results of evaluation runs",
+	GroupID:           groupEvaluation,
+	Long:              `Manage items within dataset runs.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var runItemsListCmd = &cobra.Command{
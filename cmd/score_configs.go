@@ -47,12 +47,12 @@ var (
 )
 
 var scoreConfigsCmd = &cobra.Command{
-	Use:              "score-configs",
-	Aliases:          []string{"score-config"},
-	Short:            "Define scoring schemas for evaluation",
-	GroupID:          groupObserve,
-	Long:             `Manage scoring configuration schemas for annotation workflows.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "score-configs",
+	Aliases:           []string{"score-config"},
+	Short:             "Define scoring schemas for evaluation",
+	GroupID:           groupObserve,
+	Long:              `Manage scoring configuration schemas for annotation workflows.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var scoreConfigsListCmd = &cobra.Command{
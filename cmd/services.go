@@ -15,6 +15,7 @@ import (
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/inputs"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/rollout"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/session"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/sync"
 	"github.com/spf13/cobra"
@@ -67,6 +68,9 @@ var (
 
 	serviceExpectRevision int
 	serviceForce          bool
+
+	serviceWait        bool
+	serviceWaitTimeout time.Duration
 )
 
 var servicesCmd = &cobra.Command{
@@ -83,6 +87,7 @@ var servCCmd = &cobra.Command{
 	Long:  `Create a service in a specific project using the deployment service.`,
 	Example: `  iai services create my-svc --image-type external --image-repository docker.io --image-name nginx --image-tag latest --port 80 --memory 512M --cpu 0.5
   iai services create my-svc --image-name my-app --image-tag v1 --port 8080 --memory 1G --cpu 1 --replicas 3 --endpoint
+  iai services create my-svc --image-name my-app --image-tag v1 --port 8080 --memory 1G --cpu 1 --wait
   iai services create my-svc --image-name my-app --image-tag v1 --memory 512M --cpu 0.5 --env LOG_LEVEL=debug --secret DB_PASSWORD --healthcheck-path /health
   iai services create my-svc --image-name my-app --image-tag v1 --memory 512M --cpu 0.5 --schedule-uptime "Mon-Fri 08:00-18:00" --schedule-timezone Europe/Berlin`,
 	Args: cobra.ExactArgs(1),
@@ -144,6 +149,13 @@ var servCCmd = &cobra.Command{
 			fmt.Fprintln(out, serverMessage)
 		}
 
+		if serviceWait {
+			return waitForRollout(
+				cmd, deployClient, pCtx.orgId, pCtx.projectId, serviceWaitTimeout,
+				rollout.Target{Kind: "service", Name: serviceName},
+			)
+		}
+
 		return nil
 	},
 }
@@ -179,6 +191,7 @@ checks fail open when live state cannot be fetched; use --expect-revision
 to fail instead when the live revision differs from what you expect.`,
 	Example: `  iai services update my-svc --image-tag v2
   iai services update my-svc --image-tag v2 --expect-revision 47
+  iai services update my-svc --image-tag v2 --wait --timeout 10m
   iai services update my-svc --memory 1G --cpu 0.5
   iai services update my-svc --replicas 3
   iai services update my-svc --autoscaling-max-replicas 8
@@ -257,6 +270,11 @@ to fail instead when the live revision differs from what you expect.`,
 			return err
 		}
 
+		var revision int
+		if liveErr == nil {
+			revision = live.Revision + 1
+		}
+
 		fmt.Fprintln(out)
 		fmt.Fprintln(out, "Submitting service update request...")
 
@@ -275,6 +293,13 @@ to fail instead when the live revision differs from what you expect.`,
 			fmt.Fprintln(out, serverMessage)
 		}
 
+		if serviceWait {
+			return waitForRollout(
+				cmd, deployClient, pCtx.orgId, pCtx.projectId, serviceWaitTimeout,
+				rollout.Target{Kind: "service", Name: serviceName, Revision: revision},
+			)
+		}
+
 		return nil
 	},
 }
@@ -589,6 +614,7 @@ var (
 	servLogsAllFields  bool
 	servLogsTimestamps bool
 	servLogsLimit      int
+	servLogsFilter     logFilterFlags
 )
 
 var servLogsCmd = &cobra.Command{
@@ -603,12 +629,22 @@ Structured (JSON) logs are automatically formatted: the level and message
 fields are extracted and displayed as "LEVEL message". Use --fields or
 --all-fields to include additional top-level fields after the message. Use
 --raw for exact server JSON, or --decode to decode embedded JSON strings into
-nested JSON values.`,
+nested JSON values.
+
+Filter entries with --level, --grep, --exclude, --where, and --replica; they
+apply as entries arrive, so they work with --follow and keep formatting
+intact. --level warn+ shows warnings and anything more severe. --grep and
+--exclude match the message of structured entries and the whole of plain-text
+lines. --where key=value matches a top-level field, like those
+'iai services log-fields' lists. --replica takes a replica's full name or the
+suffix shown in brackets.`,
 	Example: `  iai services logs my-svc
   iai services logs my-svc --follow
   iai services logs my-svc --since 3h
   iai services logs my-svc --timestamps
-  iai services logs my-svc --fields logger,pid`,
+  iai services logs my-svc --fields logger,pid
+  iai services logs my-svc --level warn+ --grep timeout
+  iai services logs my-svc --follow --where logger=http`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		out := cmd.OutOrStdout()
@@ -618,6 +654,11 @@ nested JSON values.`,
 			return fmt.Errorf("service name is required")
 		}
 
+		filter, err := servLogsFilter.filter()
+		if err != nil {
+			return err
+		}
+
 		ctx := cmd.Context()
 		if servLogsFollow {
 			var stop func()
@@ -671,6 +712,7 @@ nested JSON values.`,
 			Fields:     servLogsFields,
 			AllFields:  servLogsAllFields,
 			Timestamps: servLogsTimestamps,
+			Filter:     filter,
 		}
 		err = output.PrintLogStream(out, logsResp.Body, true, meta, fmtOpts)
 		if servLogsFollow && ctx.Err() != nil {
@@ -687,7 +729,8 @@ var servLogFieldsCmd = &cobra.Command{
 	Short: "List available fields in structured logs",
 	Long: `Scan recent logs and list the extra top-level fields present in structured (JSON) log entries.
 
-Use the reported field names with 'iai services logs --fields' to include them in output.`,
+Use the reported field names with 'iai services logs --fields' to include them in output,
+or with 'iai services logs --where' to filter on them.`,
 	Example: `  iai services log-fields my-service
   iai services log-fields my-service --since 1h`,
 	Args: cobra.ExactArgs(1),
@@ -1044,6 +1087,7 @@ func init() {
 		StringVar(&serviceScheduleTimezone, "schedule-timezone", "", "IANA timezone for the schedule (e.g. Europe/Berlin, US/Eastern, UTC); required with --schedule-uptime or --schedule-downtime")
 	servCCmd.Flags().
 		StringVar(&serviceStackId, "stack-id", "", "Stack ID to assign the service to")
+	addWaitFlags(servCCmd, &serviceWait, &serviceWaitTimeout)
 
 	// Flags for "services update"
 	servUCmd.Flags().
@@ -1108,6 +1152,7 @@ func init() {
 		IntVar(&serviceExpectRevision, "expect-revision", 0, "Fail without applying unless the live revision equals this value; 0 is valid and matches a never-updated service (opt-in staleness guard)")
 	servUCmd.Flags().
 		BoolVar(&serviceForce, "force", false, "Apply even when the update would drop live env vars or secret refs")
+	addWaitFlags(servUCmd, &serviceWait, &serviceWaitTimeout)
 
 	// Flags for "services list"
 	servListCmd.Flags().
@@ -1189,6 +1234,7 @@ func init() {
 		BoolVar(&servLogsTimestamps, "timestamps", false, "Include platform log timestamps")
 	servLogsCmd.Flags().
 		IntVar(&servLogsLimit, "limit", 0, "Maximum number of log entries to return (1-5000); defaults to 1000")
+	addLogFilterFlags(servLogsCmd, &servLogsFilter, true)
 	servLogsCmd.MarkFlagsMutuallyExclusive("raw", "fields")
 	servLogsCmd.MarkFlagsMutuallyExclusive("raw", "all-fields")
 	servLogsCmd.MarkFlagsMutuallyExclusive("decode", "fields")
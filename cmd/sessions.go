@@ -41,12 +41,12 @@ const (
 )
 
 var sessionsCmd = &cobra.Command{
-	Use:              "sessions",
-	Aliases:          []string{"session"},
-	Short:            "Browse trace-derived conversation sessions",
-	GroupID:          groupObserve,
-	Long:             `Manage trace-derived sessions. Works with API key (--api-key or INTERACTIVE_API_KEY) or session from 'iai login'.`,
-	PersistentPreRun: chainRootPersistentPreRun,
+	Use:               "sessions",
+	Aliases:           []string{"session"},
+	Short:             "Browse trace-derived conversation sessions",
+	GroupID:           groupObserve,
+	Long:              `Manage trace-derived sessions. Works with API key (--api-key or INTERACTIVE_API_KEY) or session from 'iai login'.`,
+	PersistentPreRunE: chainRootPersistentPreRun,
 }
 
 var sessionsListCmd = &cobra.Command{
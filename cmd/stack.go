@@ -2,12 +2,18 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/platform"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/rollout"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/session"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/sync"
 	"github.com/spf13/cobra"
@@ -19,6 +25,12 @@ var (
 	stackSyncOrganization string
 	stackSyncAllowDelete  []string
 	stackSyncDryRun       bool
+	stackSyncOverlay      []string
+	stackSyncParallelism  int
+	stackSyncAtomic       bool
+	stackSyncWait         bool
+	stackSyncWaitTimeout  time.Duration
+	stackSyncTarget       []string
 
 	stackGetStackID string
 	stackGetFile    string
@@ -26,16 +38,21 @@ var (
 	stackGetProject string
 	stackGetJSON    bool
 	stackGetYAML    bool
+	stackGetOverlay []string
+	stackGetOutDir  string
 
 	stackDiffFile    string
 	stackDiffStackID string
 	stackDiffOrg     string
 	stackDiffProject string
 	stackDiffJSON    bool
+	stackDiffFormat  string
+	stackDiffOverlay []string
 
-	stackListJSON bool
-	stackListOrg  string
-	stackListProj string
+	stackListJSON      bool
+	stackListOrg       string
+	stackListProj      string
+	stackListUnmanaged bool
 )
 
 var stackCmd = &cobra.Command{
@@ -43,38 +60,129 @@ var stackCmd = &cobra.Command{
 	Aliases: []string{"stack", "st"},
 	Short:   "Declarative resource sync from config files",
 	GroupID: groupInfra,
-	Long:    `Manage stacks and their resources (services, agents, databases, mcps) from stack configuration files.`,
+	Long: `Manage stacks and their resources (services, agents, databases, mcps) and the
+context items agents use (routines, policies, variables, glossaries, macros,
+prompts) from stack configuration files.`,
 }
 
 var stackSyncCmd = &cobra.Command{
 	Use:   "sync",
-	Short: "Sync services, agents, databases, and mcps from a stack config file",
-	Long: `Sync services, agents, databases, and mcps in a project from a stack configuration file.
+	Short: "Sync secrets, context items, services, agents, databases, and mcps from a stack config file",
+	Long: `Sync secrets, context items, services, agents, databases, and mcps in a
+project from a stack configuration file.
 
 Services, agents, databases, and mcps are created and updated to match the config
 file. Resources the config file no longer mentions are NOT deleted by
 default: a config that omits a resource looks identical to a stale one, so
 the sync refuses each deletion, reports it on stderr, and continues with the
 creates and updates. Pass --allow-delete with the resource types you intend
-to decommission (services, agents, databases, mcps, or all) to delete them; within
-each resource type, deletes run after that type's creates and updates.
+to decommission (services, agents, databases, mcps, or all) to delete them.
+Deletes run after every create and update: services and agents first, then
+mcps, then databases.
+
+Services, agents, databases, and mcps are planned together and applied in
+dependency order: a database or mcp is created or updated before the
+services, agents, and mcps that reference it, either in an env value that
+names it as a host (e.g. postgres://orders-db:5432/app) or in an agentConfig
+mcps entry. Independent resources are applied concurrently, up to
+--parallelism at a time; --parallelism 1 applies them one by one. Results are
+reported per resource type in a fixed order however the work was scheduled.
+After a failure no further changes start, and the resources left unsynced are
+listed.
+
+Each existing resource is compared against its live spec first, and resources
+that already match the config are reported as unchanged and left alone, so
+re-syncing an unchanged file creates no new revisions and restarts nothing.
+MCP credentials are write-only, so whether one changed can't be told: mcps
+whose config sets auth.credential are always updated, which also rotates the
+credential.
 
 Updates replace the whole live spec of each resource. For every service, agent,
 or mcp updated, the live revision being replaced is printed to stderr so a
 sync from a stale config file is visible before it lands.
 
-Use --dry-run to print the full plan — creates, updates, deletes, and
-refused deletions — without applying anything.
+Secrets are synced first, so services and agents can reference them through
+secretRefs. Their values never appear in the stack file: each secret reads
+them from environment variables ("keys", or "env" to map a key to a different
+variable), a KEY=VALUE file ("envFile", relative to the stack file), or the
+output of a shell command ("command"). All values are resolved before anything
+is changed. A secret is created if missing and replaced when any key or value
+differs; secrets the config omits are never deleted.
+
+Context items (routines, policies, variables, glossaries, macros, prompts) are
+synced next, before the agents that reference them. Each item's content is
+given inline ("content") or read from a path relative to the stack file
+("file"). A new version is created only when the content differs from the
+item's latest version or a declared label, tag, or setting is missing from it;
+labels are applied to the new version. Context items carry no stack ID, so
+items the config omits are never deleted.
+
+--file may also name a stack directory, which keeps each entry in a file of
+its own under a directory named after its section, e.g. agents/support.yaml
+for the agent "support" and routines/greeting.yaml for a routine, and the
+top-level fields (organization, project, stack-id) in stack.yaml. Relative
+paths are then relative to the directory. In a stack file or directory, any
+value can be read from another file with "!include path" or "{$ref: path}",
+relative to the file that includes it, e.g. "agentConfig: !include
+support/config.yaml" in agents/support.yaml or "content: !include
+greeting.md"; YAML and JSON files are parsed, other files are included as
+text. Every YAML or JSON file directly in a section directory is an entry, so
+keep included ones in a subdirectory or elsewhere.
+
+Pass --overlay to layer environment-specific files over the base file, e.g.
+--file stack.yaml --overlay prod.yaml. Overlays are deep-merged in order:
+resource maps merge key by key, env lists merge by variable name, other values
+replace the base value, and a null value (~) removes the key. Every file may
+reference environment variables as ${VAR} or ${VAR:-default}; write $$ for a
+literal dollar sign. Unset variables without a default are reported with
+their file and line, and nothing is synced.
+
+Pass --target to sync only some resources, e.g. to ship a hotfix to one
+service without touching the rest: --target service/api --target
+agent/support. Each target is type/name (service, agent, database, mcp,
+secret, or a context item type such as routine), and the name may use the
+globs * and ?, e.g. --target 'agent/support-*'. Every other resource is left
+alone and listed as skipped in the plan; in particular, leaving a resource
+out of the targets never deletes it. A targeted resource the config omits is
+deleted only with --allow-delete, as in a full sync. Dependencies are not
+followed: target a database or mcp too if the change needs it.
+
+Pass --atomic to roll the whole sync back if any step fails. Before each
+update, the spec being replaced is recorded: the live revision for services,
+agents, and mcps, and the live spec for databases, secrets, and context items.
+On failure, every change already applied is reverted, newest first: created
+resources are deleted, updated ones are restored to their recorded spec
+(context items get a new version with the previous content), and each revert
+is reported. Deleted resources can't be restored, so a rollback is reported
+as incomplete when the sync had already deleted something. MCP credentials
+are write-only, so a restored mcp keeps the credential the sync set.
+
+Pass --wait to wait, after everything is applied, until every service,
+agent, database, and mcp the sync created or updated has rolled out its new
+revision. Status changes are streamed as they happen; if a rollout fails or
+--timeout expires, the replicas that aren't ready are described (last
+termination state and events) and the command exits non-zero. With --atomic,
+a failed rollout rolls the sync back too.
+
+Use --dry-run to print the full plan — creates, updates, unchanged resources,
+deletes, and refused deletions — without applying anything. To review a plan
+before it lands, write it with 'iai stacks plan --out' and apply exactly that
+plan with 'iai stacks apply'.
 
 The organization and project are read from the config file, flags, or resolved via 'iai organizations select' / 'iai projects select'.`,
 	Example: `  iai stacks sync --file stack.yaml
   iai stacks sync --file stack.yaml --project my-project --organization my-org
   iai stacks sync --file stack.yaml --dry-run
+  iai stacks sync --file stack.yaml --overlay prod.yaml
+  iai stacks sync --file stack/ --overlay prod.yaml
+  iai stacks sync --file stack.yaml --parallelism 8
+  iai stacks sync --file stack.yaml --target service/api
+  iai stacks sync --file stack.yaml --target 'agent/support-*' --target mcp/search
+  iai stacks sync --file stack.yaml --atomic
+  iai stacks sync --file stack.yaml --wait --timeout 10m
   iai stacks sync --file stack.yaml --allow-delete services,agents`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		out := cmd.OutOrStdout()
-
 		filePath := stackSyncFile
 		if filePath == "" {
 			filePath = cfgFilePath
@@ -83,189 +191,306 @@ The organization and project are read from the config file, flags, or resolved v
 			return fmt.Errorf("config file is required; please provide --file or --cfg-file")
 		}
 
-		cfg, err := files.LoadStackConfig(filePath)
-		if err != nil {
-			return fmt.Errorf("failed to load stack config: %w", err)
-		}
-
-		if cfg.StackId == "" {
-			return fmt.Errorf("stack-id is required for sync command")
-		}
-
-		cookies, err := files.LoadSessionCookies(cfgDirName, sessionFileName)
-		if err != nil {
-			return fmt.Errorf("failed to load session: %w", err)
-		}
-
-		apiClient, err := platform.NewAPIClient(
-			hostname,
-			defaultHTTPTimeout,
-			token,
-			apiKey,
-			cookies,
-		)
+		targets, err := sync.NewTargets(stackSyncTarget)
 		if err != nil {
 			return err
 		}
 
-		deployClient, err := deployment.NewDeploymentClient(
-			deploymentHostname,
-			defaultHTTPTimeout,
-			token,
-			apiKey,
-			cookies,
-		)
+		cfg, err := files.LoadStackConfig(filePath, stackSyncOverlay...)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to load stack config: %w", err)
 		}
 
-		sess := session.NewSession(cfgDirName)
+		_, err = runStackSync(cmd, stackRun{
+			cfg:         cfg,
+			baseDir:     files.StackBaseDir(filePath),
+			org:         stackSyncOrganization,
+			project:     stackSyncProject,
+			allowDelete: stackSyncAllowDelete,
+			dryRun:      stackSyncDryRun,
+			parallelism: stackSyncParallelism,
+			atomic:      stackSyncAtomic,
+			wait:        stackSyncWait,
+			waitTimeout: stackSyncWaitTimeout,
+			targets:     targets,
+		})
+		return err
+	},
+}
 
-		orgName, err := sess.ResolveOrganization(cfg.Organization, stackSyncOrganization)
-		if err != nil {
-			return err
-		}
+// stackRun configures runStackSync.
+type stackRun struct {
+	cfg          *files.StackConfig
+	baseDir      string // secret envFile paths resolve against it
+	org, project string // --organization and --project; override the config
+	allowDelete  []string
+	dryRun       bool
+	parallelism  int
+	atomic       bool
+	wait         bool
+	waitTimeout  time.Duration
+	// planned, when set, restricts the sync to the changes of a reviewed
+	// plan file: steps that don't match are refused (see sync.Restrict).
+	planned []sync.Change
+	// targets, when set, restricts the sync to the resources it selects.
+	targets *sync.Targets
+}
 
-		projectName, err := sess.ResolveProject(cfg.Project, stackSyncProject)
-		if err != nil {
-			return err
+// runStackSync plans and applies a stack config, or only prints the plan on
+// a dry run, and returns the plans it made. The config's organization and
+// project are set to the names it resolved.
+func runStackSync(cmd *cobra.Command, run stackRun) ([]*sync.Plan, error) {
+	out := cmd.OutOrStdout()
+	cfg := run.cfg
+
+	if cfg.StackId == "" {
+		return nil, fmt.Errorf("stack-id is required for sync command")
+	}
+
+	if run.parallelism < 1 {
+		return nil, fmt.Errorf("--parallelism must be at least 1")
+	}
+
+	// Resolve secret values before changing anything, so a missing
+	// environment variable or failing command aborts the whole sync.
+	// Secrets that aren't targeted are planned as skipped, unresolved.
+	resolveCfg := cfg
+	if run.targets != nil {
+		targeted := *cfg
+		targeted.Secrets = make(map[string]files.SecretConfig)
+		for name, secret := range cfg.Secrets {
+			if run.targets.Match("secret", name) {
+				targeted.Secrets[name] = secret
+			}
 		}
-
-		orgId, projectId, err := apiClient.GetProjectId(cmd.Context(), orgName, projectName)
-		if err != nil {
+		resolveCfg = &targeted
+	}
+	secretData, err := files.ResolveSecrets(cmd.Context(), resolveCfg, run.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+	for name := range cfg.Secrets {
+		if _, ok := secretData[name]; !ok {
+			secretData[name] = nil
+		}
+	}
+
+	cookies, err := files.LoadSessionCookies(cfgDirName, sessionFileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	apiClient, err := platform.NewAPIClient(
+		hostname,
+		defaultHTTPTimeout,
+		token,
+		apiKey,
+		cookies,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deployClient, err := deployment.NewDeploymentClient(
+		deploymentHostname,
+		defaultHTTPTimeout,
+		token,
+		apiKey,
+		cookies,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := session.NewSession(cfgDirName)
+
+	orgName, err := sess.ResolveOrganization(cfg.Organization, run.org)
+	if err != nil {
+		return nil, err
+	}
+
+	projectName, err := sess.ResolveProject(cfg.Project, run.project)
+	if err != nil {
+		return nil, err
+	}
+
+	orgId, projectId, err := apiClient.GetProjectId(cmd.Context(), orgName, projectName)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Organization, cfg.Project = orgName, projectName
+
+	fmt.Fprintln(out)
+	switch {
+	case run.dryRun:
+		fmt.Fprintf(
+			out,
+			"Dry run: planning stack %q — no changes will be applied.\n",
+			cfg.StackId,
+		)
+	case run.planned != nil:
+		fmt.Fprintf(out, "Applying plan for stack %q...\n", cfg.StackId)
+	default:
+		fmt.Fprintf(out, "Syncing stack %q...\n", cfg.StackId)
+	}
+	if run.targets != nil {
+		fmt.Fprintf(out, "Targeting only %s.\n", strings.Join(run.targets.Patterns(), ", "))
+	}
+	ranSync := false
+	refused := 0
+	var made, applied []*sync.Plan
+
+	var journal *sync.Journal
+	if run.atomic && !run.dryRun {
+		journal = &sync.Journal{}
+	}
+	// rollback reverts what the sync applied before err, when --atomic
+	// is set, and returns err.
+	rollback := func(err error) error {
+		if journal.Len() == 0 {
 			return err
 		}
-
-		fmt.Fprintln(out)
-		verb := "Syncing"
-		if stackSyncDryRun {
-			verb = "Planning"
-			fmt.Fprintf(
-				out,
-				"Dry run: planning stack %q — no changes will be applied.\n",
-				cfg.StackId,
-			)
-		} else {
-			fmt.Fprintf(out, "Syncing stack %q...\n", cfg.StackId)
+		fmt.Fprintf(out, "\nSync failed; rolling back %d changes...\n", journal.Len())
+		if rbErr := sync.PrintRollback(out, journal.Rollback()); rbErr != nil {
+			return fmt.Errorf("%w; %w", err, rbErr)
 		}
-		ranSync := false
-
-		runPhase := func(label string, run func(sync.Options) (*sync.Result, error)) error {
-			ranSync = true
-			fmt.Fprint(out, verb+" "+label)
+		return err
+	}
+
+	type phase struct {
+		label string
+		plan  func(sync.Options) (*sync.Plan, error)
+	}
+
+	// runStage plans every phase first, then applies all of their steps
+	// together, up to --parallelism at a time, and prints one result per
+	// phase in phase order.
+	runStage := func(phases []phase, deps map[string][]string) error {
+		var plans []*sync.Plan
+		for _, ph := range phases {
+			fmt.Fprint(out, "Planning "+ph.label)
 			done := output.PrintLoadingDots(out)
-			result, err := run(sync.Options{
-				AllowDelete: sync.AllowDeleteResource(stackSyncAllowDelete, label),
-				DryRun:      stackSyncDryRun,
+			plan, err := ph.plan(sync.Options{
+				AllowDelete: sync.AllowDeleteResource(run.allowDelete, ph.label),
+				DryRun:      run.dryRun,
+				Parallelism: run.parallelism,
+				Journal:     journal,
+				Targets:     run.targets,
 			})
 			close(done)
 			fmt.Fprintln(out)
-			if stackSyncDryRun {
-				if err != nil {
-					return err
-				}
-				sync.PrintPlan(out, label, result)
-				return nil
+			if err != nil {
+				return err
+			}
+			if !plan.Empty() {
+				plans = append(plans, plan)
 			}
-			return sync.PrintResult(out, label, result, err)
 		}
-
-		svcBodies := make(map[string]deployment.CreateServiceBody)
-		for name, svcCfg := range cfg.Services {
-			svcBodies[name] = svcCfg.ToCreateRequest(cfg.StackId)
+		if len(plans) == 0 {
+			return nil
 		}
+		ranSync = true
+		made = append(made, plans...)
 
-		hasServices := false
-		if len(svcBodies) == 0 {
-			hasServices, err = sync.HasServices(
-				cmd.Context(),
-				deployClient,
-				orgId,
-				projectId,
-				cfg.StackId,
-			)
-			if err != nil {
-				return err
+		if run.dryRun {
+			for _, plan := range plans {
+				sync.PrintPlan(out, plan.Label, plan.Result)
 			}
+			return nil
 		}
 
-		if len(svcBodies) > 0 || hasServices {
-			err := runPhase("services", func(opts sync.Options) (*sync.Result, error) {
-				return sync.Services(
-					cmd.Context(),
-					cmd.ErrOrStderr(),
-					deployClient,
-					orgId,
-					projectId,
-					cfg.StackId,
-					svcBodies,
-					opts,
-				)
-			})
-			if err != nil {
-				return err
-			}
+		if run.planned != nil {
+			refusals := sync.Restrict(plans, run.planned, deps)
+			sync.PrintRefusals(out, refusals)
+			refused += len(refusals)
 		}
 
-		agentBodies := make(map[string]deployment.CreateAgentBody)
-		for name, agentCfg := range cfg.Agents {
-			agentBodies[name] = agentCfg.ToCreateRequest(cfg.StackId)
+		labels := make([]string, len(plans))
+		for i, plan := range plans {
+			labels[i] = plan.Label
 		}
+		fmt.Fprint(out, "Syncing "+strings.Join(labels, ", "))
+		done := output.PrintLoadingDots(out)
+		results, errs := sync.ApplyPlans(plans, deps, run.parallelism)
+		close(done)
+		fmt.Fprintln(out)
 
-		hasAgents := false
-		if len(agentBodies) == 0 {
-			hasAgents, err = sync.HasAgents(
-				cmd.Context(),
-				deployClient,
-				orgId,
-				projectId,
-				cfg.StackId,
-			)
-			if err != nil {
-				return err
+		var firstErr error
+		for i, plan := range plans {
+			err := sync.PrintResult(out, plan.Label, results[i], errs[i])
+			if err != nil && firstErr == nil {
+				firstErr = err
 			}
 		}
-
-		if len(agentBodies) > 0 || hasAgents {
-			err := runPhase("agents", func(opts sync.Options) (*sync.Result, error) {
-				return sync.Agents(
+		applied = append(applied, plans...)
+		return firstErr
+	}
+
+	// Secrets and context items don't depend on each other and run first,
+	// so the resources below can reference them.
+	var prereqs []phase
+	if len(secretData) > 0 {
+		prereqs = append(prereqs, phase{
+			label: "secrets",
+			plan: func(opts sync.Options) (*sync.Plan, error) {
+				return sync.PlanSecrets(
 					cmd.Context(),
 					cmd.ErrOrStderr(),
 					deployClient,
 					orgId,
 					projectId,
-					cfg.StackId,
-					agentBodies,
+					secretData,
 					opts,
 				)
-			})
-			if err != nil {
-				return err
-			}
+			},
+		})
+	}
+	for _, kind := range files.ContextKinds {
+		items := cfg.ContextItems(kind.Section)
+		if len(items) == 0 {
+			continue
 		}
-
-		dbBodies := make(map[string]deployment.CreateDatabaseBody)
-		for name, dbCfg := range cfg.Databases {
-			dbBodies[name] = dbCfg.ToCreateRequest(cfg.StackId)
-		}
-
-		hasDatabases := false
-		if len(dbBodies) == 0 {
-			hasDatabases, err = sync.HasDatabases(
-				cmd.Context(),
-				deployClient,
-				orgId,
-				projectId,
-				cfg.StackId,
-			)
-			if err != nil {
-				return err
-			}
-		}
-
-		if len(dbBodies) > 0 || hasDatabases {
-			err := runPhase("databases", func(opts sync.Options) (*sync.Result, error) {
-				return sync.Databases(
+		prereqs = append(prereqs, phase{
+			label: kind.Section,
+			plan: func(opts sync.Options) (*sync.Plan, error) {
+				return sync.PlanContextItems(
+					cmd.Context(),
+					cmd.ErrOrStderr(),
+					apiClient,
+					projectId,
+					kind,
+					items,
+					opts,
+				)
+			},
+		})
+	}
+	if err := runStage(prereqs, nil); err != nil {
+		return made, rollback(err)
+	}
+
+	svcBodies := make(map[string]deployment.CreateServiceBody)
+	for name, svcCfg := range cfg.Services {
+		svcBodies[name] = svcCfg.ToCreateRequest(cfg.StackId)
+	}
+	agentBodies := make(map[string]deployment.CreateAgentBody)
+	for name, agentCfg := range cfg.Agents {
+		agentBodies[name] = agentCfg.ToCreateRequest(cfg.StackId)
+	}
+	dbBodies := make(map[string]deployment.CreateDatabaseBody)
+	for name, dbCfg := range cfg.Databases {
+		dbBodies[name] = dbCfg.ToCreateRequest(cfg.StackId)
+	}
+	mcpBodies := make(map[string]deployment.CreateMcpBody)
+	for name, mcpCfg := range cfg.Mcps {
+		mcpBodies[name] = mcpCfg.ToCreateRequest(cfg.StackId)
+	}
+
+	err = runStage([]phase{
+		{
+			label: "databases",
+			plan: func(opts sync.Options) (*sync.Plan, error) {
+				return sync.PlanDatabases(
 					cmd.Context(),
 					cmd.ErrOrStderr(),
 					deployClient,
@@ -275,34 +500,12 @@ The organization and project are read from the config file, flags, or resolved v
 					dbBodies,
 					opts,
 				)
-			})
-			if err != nil {
-				return err
-			}
-		}
-
-		mcpBodies := make(map[string]deployment.CreateMcpBody)
-		for name, mcpCfg := range cfg.Mcps {
-			mcpBodies[name] = mcpCfg.ToCreateRequest(cfg.StackId)
-		}
-
-		hasMcps := false
-		if len(mcpBodies) == 0 {
-			hasMcps, err = sync.HasMcps(
-				cmd.Context(),
-				deployClient,
-				orgId,
-				projectId,
-				cfg.StackId,
-			)
-			if err != nil {
-				return err
-			}
-		}
-
-		if len(mcpBodies) > 0 || hasMcps {
-			err := runPhase("mcps", func(opts sync.Options) (*sync.Result, error) {
-				return sync.Mcps(
+			},
+		},
+		{
+			label: "mcps",
+			plan: func(opts sync.Options) (*sync.Plan, error) {
+				return sync.PlanMcps(
 					cmd.Context(),
 					cmd.ErrOrStderr(),
 					deployClient,
@@ -312,18 +515,87 @@ The organization and project are read from the config file, flags, or resolved v
 					mcpBodies,
 					opts,
 				)
-			})
-			if err != nil {
-				return err
+			},
+		},
+		{
+			label: "services",
+			plan: func(opts sync.Options) (*sync.Plan, error) {
+				return sync.PlanServices(
+					cmd.Context(),
+					cmd.ErrOrStderr(),
+					deployClient,
+					orgId,
+					projectId,
+					cfg.StackId,
+					svcBodies,
+					opts,
+				)
+			},
+		},
+		{
+			label: "agents",
+			plan: func(opts sync.Options) (*sync.Plan, error) {
+				return sync.PlanAgents(
+					cmd.Context(),
+					cmd.ErrOrStderr(),
+					deployClient,
+					orgId,
+					projectId,
+					cfg.StackId,
+					agentBodies,
+					opts,
+				)
+			},
+		},
+	}, files.StackDependencies(cfg))
+	if err != nil {
+		return made, rollback(err)
+	}
+
+	if run.wait && !run.dryRun {
+		var targets []rollout.Target
+		for _, plan := range applied {
+			for _, step := range plan.Steps {
+				if step.Action == "delete" {
+					continue
+				}
+				switch step.Resource {
+				case "service", "agent", "database", "mcp":
+					targets = append(targets, rollout.Target{
+						Kind:     step.Resource,
+						Name:     step.Name,
+						Revision: step.Revision,
+					})
+				}
 			}
 		}
-
-		if !ranSync {
-			fmt.Fprintf(out, "No resources to sync for stack %q.\n", cfg.StackId)
+		err := waitForRollout(
+			cmd, deployClient, orgId, projectId, run.waitTimeout, targets...,
+		)
+		if err != nil {
+			return made, rollback(err)
 		}
+	}
+
+	if !ranSync {
+		fmt.Fprintf(out, "No resources to sync for stack %q.\n", cfg.StackId)
+	}
+	for _, p := range run.targets.Unmatched() {
+		fmt.Fprintf(
+			cmd.ErrOrStderr(),
+			"Warning: --target %s matched no resource in the config or the live stack\n",
+			p,
+		)
+	}
 
-		return nil
-	},
+	if refused > 0 {
+		return made, fmt.Errorf(
+			"refused %d planned changes that no longer match the live state; "+
+				"run 'iai stacks plan' again to review them",
+			refused,
+		)
+	}
+	return made, nil
 }
 
 var stackGetCmd = &cobra.Command{
@@ -336,11 +608,28 @@ Use this to rebase your local stack config on the live state before making
 changes. MCP credentials are never exported; include auth.credential before
 syncing credentialed MCPs.
 
+Secrets referenced by the stack's secretRefs, or declared in the --cfg-file
+stack file, are exported by name and keys only; values are never exported.
+On sync, listed keys are read from environment variables of the same name.
+
+Context items are not tagged with a stack ID. Pass the stack file with
+--cfg-file to also export the latest version of each routine, policy,
+variable, glossary, macro, and prompt it declares, with content inline.
+Overlays given with --overlay are merged onto that file first.
+
+Pass --out-dir to write a stack directory, as 'iai stacks sync --file'
+reads it, instead of a single file: the top-level fields go to stack.yaml
+and each entry to a file of its own, e.g. agents/support.yaml. Entry files
+of resources the stack no longer has are removed, so exporting over an
+earlier export leaves the directory matching the live stack.
+
 The organization and project are read from flags or resolved via 'iai
 organizations select' / 'iai projects select'.`,
 	Example: `  iai stacks get --stack-id my-stack
   iai stacks get --stack-id my-stack -f live-stack.yaml
-  iai stacks get --stack-id my-stack -o my-org -p my-project`,
+  iai stacks get --stack-id my-stack -o my-org -p my-project
+  iai stacks get --stack-id my-stack --cfg-file stack.yaml
+  iai stacks get --stack-id my-stack --out-dir stack/`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		out := cmd.OutOrStdout()
@@ -351,7 +640,20 @@ organizations select' / 'iai projects select'.`,
 
 		fmt.Fprintf(cmd.ErrOrStderr(), "Exporting stack %q...\n", stackGetStackID)
 
-		pCtx, _, deployClient, err := resolveProject(
+		// Context items and secrets carry no stack ID; export the ones the
+		// stack file passed with --cfg-file (and its overlays) declares.
+		declared, err := files.LoadStackConfig(cfgFilePath, stackGetOverlay...)
+		if err != nil {
+			return fmt.Errorf("failed to load config file: %w", err)
+		}
+		if stackGetOrg == "" {
+			stackGetOrg = declared.Organization
+		}
+		if stackGetProject == "" {
+			stackGetProject = declared.Project
+		}
+
+		pCtx, apiClient, deployClient, err := resolveProject(
 			cmd.Context(),
 			stackGetOrg,
 			stackGetProject,
@@ -371,9 +673,42 @@ organizations select' / 'iai projects select'.`,
 			return err
 		}
 
+		err = files.FetchLiveContext(cmd.Context(), apiClient, pCtx.projectId, declared, liveCfg)
+		if err != nil {
+			return err
+		}
+
+		// Secrets are exported by name and keys only, never values.
+		secretNames := liveCfg.ReferencedSecrets()
+		for name := range declared.Secrets {
+			if !slices.Contains(secretNames, name) {
+				secretNames = append(secretNames, name)
+			}
+		}
+		slices.Sort(secretNames)
+		err = files.FetchLiveSecrets(
+			cmd.Context(),
+			deployClient,
+			pCtx.orgId,
+			pCtx.projectId,
+			secretNames,
+			liveCfg,
+		)
+		if err != nil {
+			return err
+		}
+
 		liveCfg.Organization = pCtx.orgName
 		liveCfg.Project = pCtx.projectName
 
+		if stackGetOutDir != "" {
+			if err := files.WriteStackDir(liveCfg, stackGetOutDir); err != nil {
+				return fmt.Errorf("failed to write stack directory: %w", err)
+			}
+			fmt.Fprintf(out, "Stack configuration written to %s\n", stackGetOutDir)
+			return nil
+		}
+
 		yamlData, err := files.MarshalStackConfig(liveCfg)
 		if err != nil {
 			return err
@@ -406,11 +741,32 @@ var stackDiffCmd = &cobra.Command{
 stack and show creates, updates, deletes, and field-level changes.
 
 The local file is read from --file or --cfg-file. The live state is fetched
-from the deployment API using --stack-id.
-
-Use --json for machine-readable output in CI pipelines.`,
+from the deployment API using --stack-id. Context items the file declares are
+compared against their latest version; content changes are shown as digests.
+Overlays given with --overlay are merged onto the file first, as in 'iai
+stacks sync'. Secrets the file declares are resolved from their sources and
+compared key by key using value hashes; added, removed, and changed keys are
+listed, but values are never printed.
+
+--format picks how changes are shown:
+
+  detailed    created, updated, and deleted resources with their changed
+              fields (the default)
+  unified     a unified YAML diff of each changed resource, colored on a
+              terminal
+  json-patch  an RFC 6902 JSON Patch per changed resource, from the live
+              document to the local one, for tooling
+
+env entries are matched by name and secretRefs by secretName, so reordering
+them is not a change.
+
+Use --json for machine-readable output in CI pipelines. The command exits 0
+whether or not there are differences; use 'iai stacks drift' for exit codes
+that tell drift apart from pending local changes.`,
 	Example: `  iai stacks diff --file stack.yaml --stack-id my-stack
   iai stacks diff --file stack.yaml --stack-id my-stack --json
+  iai stacks diff --file stack.yaml --stack-id my-stack --format unified
+  iai stacks diff --file stack.yaml --overlay prod.yaml --stack-id my-stack
   iai stacks diff --file stack.yaml --stack-id my-stack -o my-org -p my-project`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -423,55 +779,129 @@ Use --json for machine-readable output in CI pipelines.`,
 		if filePath == "" {
 			return fmt.Errorf("config file is required; please provide --file or --cfg-file")
 		}
-
-		localCfg, err := files.LoadStackConfig(filePath)
-		if err != nil {
-			return fmt.Errorf("failed to load local config: %w", err)
-		}
-
-		if stackDiffOrg == "" {
-			stackDiffOrg = localCfg.Organization
-		}
-		if stackDiffProject == "" {
-			stackDiffProject = localCfg.Project
+		if !slices.Contains(stackDiffFormats, stackDiffFormat) {
+			return fmt.Errorf(
+				"invalid format %q: must be one of %s",
+				stackDiffFormat,
+				strings.Join(stackDiffFormats, ", "),
+			)
 		}
 
-		pCtx, _, deployClient, err := resolveProject(
-			cmd.Context(),
-			stackDiffOrg,
-			stackDiffProject,
+		c, err := compareStack(
+			cmd, filePath, stackDiffOverlay, stackDiffStackID, stackDiffOrg, stackDiffProject,
 		)
 		if err != nil {
 			return err
 		}
 
-		stackID := stackDiffStackID
-		if stackID == "" {
-			stackID = localCfg.StackId
+		d := files.DiffStackConfigs(c.local, c.live)
+
+		if stackDiffJSON {
+			return output.PrintStructuredJSON(out, d)
 		}
-		if stackID == "" {
-			return fmt.Errorf("--stack-id is required")
+
+		switch stackDiffFormat {
+		case "unified", "json-patch":
+			docs, err := files.StackDiffDocuments(c.local, c.live, d)
+			if err != nil {
+				return fmt.Errorf("failed to render diff: %w", err)
+			}
+			if stackDiffFormat == "unified" {
+				return output.PrintStackDiffUnified(out, d.StackID, docs)
+			}
+			return output.PrintStructuredJSON(out, map[string]any{
+				"stackId":   d.StackID,
+				"resources": files.StackDiffPatches(docs),
+			})
 		}
+		return files.PrintStackDiffDetailed(out, c.local, c.live, d)
+	},
+}
 
-		liveCfg, err := files.FetchLiveStack(
+var stackDiffFormats = []string{"detailed", "unified", "json-patch"}
+
+// stackComparison is a local stack file and the live state of its stack.
+type stackComparison struct {
+	local, live  *files.StackConfig
+	pCtx         *projectContext
+	deployClient *deployment.DeploymentClient
+}
+
+// compareStack loads the stack file at filePath with its overlays and fetches
+// the live state of stackID, or of the file's stack-id when stackID is empty:
+// the stack's services, agents, databases, and mcps, and the context items
+// and secrets the file declares. Secrets are resolved so their values can be
+// compared by hash.
+func compareStack(
+	cmd *cobra.Command,
+	filePath string,
+	overlays []string,
+	stackID, org, project string,
+) (*stackComparison, error) {
+	localCfg, err := files.LoadStackConfig(filePath, overlays...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load local config: %w", err)
+	}
+
+	if org == "" {
+		org = localCfg.Organization
+	}
+	if project == "" {
+		project = localCfg.Project
+	}
+
+	pCtx, apiClient, deployClient, err := resolveProject(cmd.Context(), org, project)
+	if err != nil {
+		return nil, err
+	}
+
+	if stackID == "" {
+		stackID = localCfg.StackId
+	}
+	if stackID == "" {
+		return nil, fmt.Errorf("--stack-id is required")
+	}
+
+	liveCfg, err := files.FetchLiveStack(
+		cmd.Context(),
+		deployClient,
+		pCtx.orgId,
+		pCtx.projectId,
+		stackID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = files.FetchLiveContext(cmd.Context(), apiClient, pCtx.projectId, localCfg, liveCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(localCfg.Secrets) > 0 {
+		_, err = files.ResolveSecrets(cmd.Context(), localCfg, files.StackBaseDir(filePath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+		}
+		err = files.FetchLiveSecrets(
 			cmd.Context(),
 			deployClient,
 			pCtx.orgId,
 			pCtx.projectId,
-			stackID,
+			slices.Sorted(maps.Keys(localCfg.Secrets)),
+			liveCfg,
 		)
 		if err != nil {
-			return err
+			return nil, err
 		}
-
-		d := files.DiffStackConfigs(localCfg, liveCfg)
-
-		if stackDiffJSON {
-			return output.PrintStructuredJSON(out, d)
-		}
-
-		return files.PrintStackDiffDetailed(out, localCfg, liveCfg, d)
-	},
+	}
+
+	return &stackComparison{
+		local:        localCfg,
+		live:         liveCfg,
+		pCtx:         pCtx,
+		deployClient: deployClient,
+	}, nil
 }
 
 var stackListCmd = &cobra.Command{
@@ -482,10 +912,15 @@ var stackListCmd = &cobra.Command{
 in a project. Stacks are discovered from the live resources that belong
 to them.
 
+Resources that belong to no stack (e.g. created without --stack-id) are
+listed after the stacks; bring them under a stack with 'iai stacks adopt'.
+Pass --unmanaged to list only them.
+
 The organization and project are read from flags or resolved via
 'iai organizations select' / 'iai projects select'.`,
 	Example: `  iai stacks list
   iai stacks list --json
+  iai stacks list --unmanaged
   iai stacks list -o my-org -p my-project`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -500,7 +935,7 @@ The organization and project are read from flags or resolved via
 			return err
 		}
 
-		stacks, err := files.ListStacks(
+		resources, err := files.ListStackResources(
 			cmd.Context(),
 			deployClient,
 			pCtx.orgId,
@@ -509,6 +944,22 @@ The organization and project are read from flags or resolved via
 		if err != nil {
 			return err
 		}
+		stacks := files.SummarizeStacks(resources)
+		unmanaged := files.UnmanagedResources(resources)
+
+		if stackListUnmanaged {
+			if stackListJSON {
+				if unmanaged == nil {
+					unmanaged = []files.StackResource{}
+				}
+				return output.PrintStructuredJSON(out, unmanaged)
+			}
+			if len(unmanaged) == 0 {
+				fmt.Fprintln(out, "No unmanaged resources found.")
+				return nil
+			}
+			return printUnmanagedResources(out, unmanaged)
+		}
 
 		if stackListJSON {
 			return output.PrintStructuredJSON(out, stacks)
@@ -516,27 +967,42 @@ The organization and project are read from flags or resolved via
 
 		if len(stacks) == 0 {
 			fmt.Fprintln(out, "No stacks found.")
-			return nil
+		} else {
+			headers := []string{"STACK ID", "SERVICES", "AGENTS", "DATABASES", "MCPS"}
+			rows := make([][]string, len(stacks))
+			for i, s := range stacks {
+				rows[i] = []string{
+					s.StackID,
+					fmt.Sprintf("%d", s.ServiceCount),
+					fmt.Sprintf("%d", s.AgentCount),
+					fmt.Sprintf("%d", s.DatabaseCount),
+					fmt.Sprintf("%d", s.McpCount),
+				}
+			}
+			if err := output.PrintTable(out, headers, rows); err != nil {
+				return err
+			}
 		}
 
-		headers := []string{"STACK ID", "SERVICES", "AGENTS", "DATABASES", "MCPS"}
-		rows := make([][]string, len(stacks))
-		for i, s := range stacks {
-			rows[i] = []string{
-				s.StackID,
-				fmt.Sprintf("%d", s.ServiceCount),
-				fmt.Sprintf("%d", s.AgentCount),
-				fmt.Sprintf("%d", s.DatabaseCount),
-				fmt.Sprintf("%d", s.McpCount),
-			}
+		if len(unmanaged) > 0 {
+			fmt.Fprintf(out, "\nUnmanaged resources (in no stack; see 'iai stacks adopt'):\n")
+			return printUnmanagedResources(out, unmanaged)
 		}
-		return output.PrintTable(out, headers, rows)
+		return nil
 	},
 }
 
+func printUnmanagedResources(out io.Writer, resources []files.StackResource) error {
+	rows := make([][]string, len(resources))
+	for i, r := range resources {
+		rows[i] = []string{r.Type, r.Name}
+	}
+	return output.PrintTable(out, []string{"TYPE", "NAME"}, rows)
+}
+
 func init() {
 	stackSyncCmd.Flags().
-		StringVarP(&stackSyncFile, "file", "f", "", "Path to stack configuration file")
+		StringVarP(&stackSyncFile, "file", "f", "", "Path to stack configuration file or directory")
 	stackSyncCmd.Flags().
 		StringVarP(&stackSyncProject, "project", "p", "", "Project name to sync resources in")
 	stackSyncCmd.Flags().
@@ -545,6 +1011,15 @@ func init() {
 		StringSliceVar(&stackSyncAllowDelete, "allow-delete", nil, "Resource types the sync may delete when the config omits them (services, agents, databases, mcps, or all); deletions are refused otherwise")
 	stackSyncCmd.Flags().
 		BoolVar(&stackSyncDryRun, "dry-run", false, "Print the full plan (creates, updates, deletes, refused deletions) without applying anything")
+	stackSyncCmd.Flags().
+		StringSliceVar(&stackSyncOverlay, "overlay", nil, "Overlay stack file to deep-merge onto --file (repeatable; applied in order)")
+	stackSyncCmd.Flags().
+		IntVar(&stackSyncParallelism, "parallelism", 4, "Maximum number of resources to check or change at once")
+	stackSyncCmd.Flags().
+		BoolVar(&stackSyncAtomic, "atomic", false, "Revert every change the sync applied if any step fails")
+	stackSyncCmd.Flags().
+		StringSliceVar(&stackSyncTarget, "target", nil, "Sync only this resource, as type/name with * globs, e.g. service/api or agent/support-* (repeatable)")
+	addWaitFlags(stackSyncCmd, &stackSyncWait, &stackSyncWaitTimeout)
 
 	stackGetCmd.Flags().
 		StringVar(&stackGetStackID, "stack-id", "", "Stack ID to export")
@@ -558,10 +1033,14 @@ func init() {
 		BoolVar(&stackGetJSON, "json", false, "Output as JSON")
 	stackGetCmd.Flags().
 		BoolVar(&stackGetYAML, "yaml", false, "Output as YAML")
-	stackGetCmd.MarkFlagsMutuallyExclusive("json", "yaml")
+	stackGetCmd.Flags().
+		StringSliceVar(&stackGetOverlay, "overlay", nil, "Overlay stack file to deep-merge onto --cfg-file (repeatable; applied in order)")
+	stackGetCmd.Flags().
+		StringVar(&stackGetOutDir, "out-dir", "", "Write a stack directory, with a file per resource, instead of a single file")
+	stackGetCmd.MarkFlagsMutuallyExclusive("json", "yaml", "file", "out-dir")
 
 	stackDiffCmd.Flags().
-		StringVarP(&stackDiffFile, "file", "f", "", "Path to local stack configuration file")
+		StringVarP(&stackDiffFile, "file", "f", "", "Path to local stack configuration file or directory")
 	stackDiffCmd.Flags().
 		StringVar(&stackDiffStackID, "stack-id", "", "Stack ID to compare against live")
 	stackDiffCmd.Flags().
@@ -570,6 +1049,11 @@ func init() {
 		StringVarP(&stackDiffProject, "project", "p", "", "Project name")
 	stackDiffCmd.Flags().
 		BoolVar(&stackDiffJSON, "json", false, "Output diff as JSON")
+	stackDiffCmd.Flags().
+		StringVar(&stackDiffFormat, "format", "detailed", "Diff format: detailed, unified, or json-patch")
+	stackDiffCmd.Flags().
+		StringSliceVar(&stackDiffOverlay, "overlay", nil, "Overlay stack file to deep-merge onto --file (repeatable; applied in order)")
+	stackDiffCmd.MarkFlagsMutuallyExclusive("json", "format")
 
 	stackListCmd.Flags().
 		BoolVar(&stackListJSON, "json", false, "Output as JSON")
@@ -577,6 +1061,8 @@ func init() {
 		StringVarP(&stackListOrg, "organization", "o", "", "Organization name")
 	stackListCmd.Flags().
 		StringVarP(&stackListProj, "project", "p", "", "Project name")
+	stackListCmd.Flags().
+		BoolVar(&stackListUnmanaged, "unmanaged", false, "List only the resources that belong to no stack")
 
 	stackCmd.AddCommand(stackSyncCmd)
 	stackCmd.AddCommand(stackListCmd)
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stackAdoptStackID      string
+	stackAdoptOrg          string
+	stackAdoptProject      string
+	stackAdoptAllUnmanaged bool
+	stackAdoptForce        bool
+	stackAdoptDryRun       bool
+)
+
+var stackAdoptCmd = &cobra.Command{
+	Use:   "adopt [type/name...]",
+	Short: "Bring existing resources under a stack",
+	Long: `Tag existing services, agents, databases, and mcps with a stack ID, so
+'iai stacks sync' manages them from then on.
+
+Resources created without --stack-id (e.g. with 'iai services create') belong
+to no stack, and a stack sync neither sees nor changes them. Name the
+resources to adopt as type/name, e.g. service/api or agent/support, or pass
+--all-unmanaged to adopt every resource that belongs to no stack; 'iai stacks
+list' shows them. Only the stack ID of each resource is changed; its spec is
+left as it is.
+
+A resource that already belongs to another stack is refused unless --force
+is given, since that stack's next sync would no longer see it.
+
+Once adopted, export the resources with 'iai stacks get --stack-id' and add
+them to the stack file: a sync from a file that omits them plans their
+deletion (refused unless --allow-delete is given).`,
+	Example: `  iai stacks adopt --stack-id shop service/api agent/support
+  iai stacks adopt --stack-id shop --all-unmanaged --dry-run
+  iai stacks adopt --stack-id shop database/orders --force`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		errOut := cmd.ErrOrStderr()
+
+		if stackAdoptStackID == "" {
+			return fmt.Errorf("--stack-id is required")
+		}
+		if stackAdoptAllUnmanaged == (len(args) > 0) {
+			return fmt.Errorf("name the resources to adopt, or pass --all-unmanaged")
+		}
+
+		var resources []files.StackResource
+		for _, arg := range args {
+			r, err := files.ParseStackResource(arg)
+			if err != nil {
+				return err
+			}
+			resources = append(resources, r)
+		}
+
+		pCtx, _, deployClient, err := resolveProject(
+			cmd.Context(),
+			stackAdoptOrg,
+			stackAdoptProject,
+		)
+		if err != nil {
+			return err
+		}
+		ctx, orgId, projectId := cmd.Context(), pCtx.orgId, pCtx.projectId
+
+		if stackAdoptAllUnmanaged {
+			all, err := files.ListStackResources(ctx, deployClient, orgId, projectId)
+			if err != nil {
+				return err
+			}
+			resources = files.UnmanagedResources(all)
+			if len(resources) == 0 {
+				fmt.Fprintf(out, "No unmanaged resources in project %q.\n", pCtx.projectName)
+				return nil
+			}
+		} else {
+			for i, r := range resources {
+				stackID, err := files.ResourceStackID(ctx, deployClient, orgId, projectId, r)
+				if err != nil {
+					return err
+				}
+				resources[i].StackID = stackID
+			}
+		}
+
+		var adopt []files.StackResource
+		for _, r := range resources {
+			switch r.StackID {
+			case "":
+				adopt = append(adopt, r)
+			case stackAdoptStackID:
+				fmt.Fprintf(out, "%s already belongs to stack %q.\n", r, stackAdoptStackID)
+			default:
+				if !stackAdoptForce {
+					return fmt.Errorf(
+						"%s belongs to stack %q; pass --force to move it to stack %q",
+						r, r.StackID, stackAdoptStackID,
+					)
+				}
+				fmt.Fprintf(errOut, "Warning: moving %s out of stack %q\n", r, r.StackID)
+				adopt = append(adopt, r)
+			}
+		}
+		if len(adopt) == 0 {
+			return nil
+		}
+
+		if stackAdoptDryRun {
+			fmt.Fprintf(
+				out,
+				"Dry run: %d resources would be adopted into stack %q:\n",
+				len(adopt),
+				stackAdoptStackID,
+			)
+			for _, r := range adopt {
+				fmt.Fprintf(out, "  %s\n", r)
+			}
+			return nil
+		}
+
+		for _, r := range adopt {
+			err := files.AdoptResource(ctx, deployClient, orgId, projectId, r, stackAdoptStackID)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%s adopted into stack %q.\n", r, stackAdoptStackID)
+		}
+		fmt.Fprintf(
+			out,
+			"\nExport them with 'iai stacks get --stack-id %s' and add them to the stack file.\n",
+			stackAdoptStackID,
+		)
+		return nil
+	},
+}
+
+func init() {
+	stackAdoptCmd.Flags().
+		StringVar(&stackAdoptStackID, "stack-id", "", "Stack ID to adopt the resources into")
+	stackAdoptCmd.Flags().
+		StringVarP(&stackAdoptOrg, "organization", "o", "", "Organization name")
+	stackAdoptCmd.Flags().
+		StringVarP(&stackAdoptProject, "project", "p", "", "Project name")
+	stackAdoptCmd.Flags().
+		BoolVar(&stackAdoptAllUnmanaged, "all-unmanaged", false, "Adopt every service, agent, database, and mcp that belongs to no stack")
+	stackAdoptCmd.Flags().
+		BoolVar(&stackAdoptForce, "force", false, "Also adopt resources that belong to another stack")
+	stackAdoptCmd.Flags().
+		BoolVar(&stackAdoptDryRun, "dry-run", false, "List the resources that would be adopted without changing them")
+
+	stackCmd.AddCommand(stackAdoptCmd)
+}
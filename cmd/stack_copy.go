@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stackCopyStackID     string
+	stackCopyFromOrg     string
+	stackCopyFromProject string
+	stackCopyToOrg       string
+	stackCopyToProject   string
+	stackCopyToStackID   string
+	stackCopyMap         string
+	stackCopyOut         string
+	stackCopyYes         bool
+	stackCopyParallelism int
+)
+
+var stackCopyCmd = &cobra.Command{
+	Use:   "copy",
+	Short: "Copy a live stack into another project or stack ID",
+	Long: `Copy the live services, agents, databases, and mcps of a stack into another
+project, another stack ID, or both, e.g. to promote a stack from staging to
+production.
+
+The stack is exported from --from-project as with 'iai stacks get' and synced
+into --to-project as with 'iai stacks sync'. The changes are planned and shown
+first, and applied after you confirm (or straight away with --yes); only the
+changes shown are made. Without --to-stack-id the stack keeps its ID, and
+without --to-project it is copied within the source project.
+
+References that differ between the projects are rewritten with a mapping
+file given with --map:
+
+  images:      # image repositories
+    registry.example.com/staging: registry.example.com/prod
+  secrets:     # secret names in secretRefs
+    staging-db: prod-db
+  mcps:        # mcp names, and the agentConfig mcps entries naming them
+    search-staging: search
+
+Some things can't be copied, and are reported before anything is planned:
+
+  - Secret values are never read. Secrets the target project doesn't have
+    yet must be created there before the resources using them can start.
+  - Internal images live in the source project's registry; push any the
+    target project doesn't have.
+  - MCP credentials are write-only. A stack with credentialed mcps can't be
+    applied directly: use --out to write the copied stack file, add each
+    auth.credential, and sync it.
+
+With --out, the copied stack file is written instead of being applied, with
+secrets exported by name and keys as in 'iai stacks get'. Context items carry
+no stack ID and are not copied.`,
+	Example: `  iai stacks copy --stack-id shop --from-project staging --to-project prod
+  iai stacks copy --stack-id shop --from-project staging --to-project prod --map prod-map.yaml
+  iai stacks copy --stack-id shop --to-stack-id shop-canary
+  iai stacks copy --stack-id shop --from-project staging --to-project prod --out prod-stack.yaml`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		errOut := cmd.ErrOrStderr()
+
+		if stackCopyStackID == "" {
+			return fmt.Errorf("--stack-id is required")
+		}
+		toStackID := stackCopyToStackID
+		if toStackID == "" {
+			toStackID = stackCopyStackID
+		}
+
+		var mapping *files.CopyMapping
+		if stackCopyMap != "" {
+			var err error
+			if mapping, err = files.LoadCopyMapping(stackCopyMap); err != nil {
+				return err
+			}
+		}
+
+		srcCtx, _, srcClient, err := resolveProject(
+			cmd.Context(),
+			stackCopyFromOrg,
+			stackCopyFromProject,
+		)
+		if err != nil {
+			return err
+		}
+
+		toOrg, toProject := stackCopyToOrg, stackCopyToProject
+		if toOrg == "" {
+			toOrg = srcCtx.orgName
+		}
+		if toProject == "" {
+			toProject = srcCtx.projectName
+		}
+		tgtCtx, _, tgtClient, err := resolveProject(cmd.Context(), toOrg, toProject)
+		if err != nil {
+			return err
+		}
+		if tgtCtx.projectId == srcCtx.projectId && toStackID == stackCopyStackID {
+			return fmt.Errorf(
+				"source and target are the same stack; pass --to-project or --to-stack-id",
+			)
+		}
+
+		fmt.Fprintf(
+			errOut,
+			"Exporting stack %q from project %q...\n",
+			stackCopyStackID,
+			srcCtx.projectName,
+		)
+		cfg, err := files.FetchLiveStack(
+			cmd.Context(),
+			srcClient,
+			srcCtx.orgId,
+			srcCtx.projectId,
+			stackCopyStackID,
+		)
+		if err != nil {
+			return err
+		}
+		if len(cfg.Services)+len(cfg.Agents)+len(cfg.Databases)+len(cfg.Mcps) == 0 {
+			return fmt.Errorf(
+				"stack %q has no resources in project %q",
+				stackCopyStackID,
+				srcCtx.projectName,
+			)
+		}
+
+		// The copied stack file exports secrets by name and keys, as 'iai
+		// stacks get' does; the mapping renames them with their references.
+		if stackCopyOut != "" {
+			err = files.FetchLiveSecrets(
+				cmd.Context(),
+				srcClient,
+				srcCtx.orgId,
+				srcCtx.projectId,
+				cfg.ReferencedSecrets(),
+				cfg,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		warnings, err := mapping.Apply(cfg)
+		if err != nil {
+			return err
+		}
+		cfg.StackId = toStackID
+		cfg.Organization, cfg.Project = tgtCtx.orgName, tgtCtx.projectName
+
+		target, err := fetchCopyTarget(cmd, tgtClient, tgtCtx)
+		if err != nil {
+			return err
+		}
+		warnings = append(warnings, files.CopyWarnings(cfg, target)...)
+		for _, w := range warnings {
+			fmt.Fprintf(errOut, "Warning: %s\n", w)
+		}
+
+		if stackCopyOut != "" {
+			return writeCopiedStack(out, cfg)
+		}
+
+		if mcps := files.CredentialedMcps(cfg); len(mcps) > 0 {
+			return fmt.Errorf(
+				"mcps %s need an auth.credential, which is never copied; "+
+					"use --out to write the copied stack file, add the credentials, and sync it",
+				strings.Join(mcps, ", "),
+			)
+		}
+
+		run := stackRun{
+			cfg:         cfg,
+			baseDir:     ".",
+			org:         tgtCtx.orgName,
+			project:     tgtCtx.projectName,
+			dryRun:      true,
+			parallelism: stackCopyParallelism,
+		}
+		plans, err := runStackSync(cmd, run)
+		if err != nil {
+			return err
+		}
+		changes := sync.Changes(plans)
+		if len(changes) == 0 {
+			fmt.Fprintf(
+				out,
+				"\nStack %q in project %q already matches; nothing to copy.\n",
+				toStackID,
+				tgtCtx.projectName,
+			)
+			return nil
+		}
+
+		if !stackCopyYes {
+			fmt.Fprintf(
+				out,
+				"\nApply %d changes to stack %q in project %q? [y/N] ",
+				len(changes),
+				toStackID,
+				tgtCtx.projectName,
+			)
+			line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("failed to read confirmation: %w", err)
+			}
+			if ans := strings.ToLower(strings.TrimSpace(line)); ans != "y" && ans != "yes" {
+				fmt.Fprintln(out, "Aborted.")
+				return nil
+			}
+		}
+
+		run.dryRun = false
+		run.planned = changes
+		_, err = runStackSync(cmd, run)
+		return err
+	},
+}
+
+// fetchCopyTarget lists the secrets and internal images the target project
+// of a copy already has.
+func fetchCopyTarget(
+	cmd *cobra.Command,
+	client *deployment.DeploymentClient,
+	pCtx *projectContext,
+) (files.CopyTarget, error) {
+	target := files.CopyTarget{
+		Secrets: make(map[string]bool),
+		Images:  make(map[string][]string),
+	}
+
+	secrets, err := client.ListSecrets(cmd.Context(), pCtx.orgId, pCtx.projectId)
+	if err != nil {
+		return target, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	for _, s := range secrets {
+		target.Secrets[s.Name] = true
+	}
+
+	images, err := client.ListImages(cmd.Context(), pCtx.orgId, pCtx.projectId)
+	if err != nil {
+		return target, fmt.Errorf("failed to list images: %w", err)
+	}
+	for _, img := range images {
+		target.Images[img.Name] = img.Tags
+	}
+	return target, nil
+}
+
+// writeCopiedStack writes the copied stack to --out.
+func writeCopiedStack(out io.Writer, cfg *files.StackConfig) error {
+	data, err := files.MarshalStackConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(stackCopyOut, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Copied stack configuration written to %s\n", stackCopyOut)
+	fmt.Fprintf(out, "Review it, then apply it with 'iai stacks sync --file %s'.\n", stackCopyOut)
+	return nil
+}
+
+func init() {
+	stackCopyCmd.Flags().
+		StringVar(&stackCopyStackID, "stack-id", "", "Stack ID to copy")
+	stackCopyCmd.Flags().
+		StringVar(&stackCopyFromOrg, "from-organization", "", "Organization that owns the source project")
+	stackCopyCmd.Flags().
+		StringVar(&stackCopyFromProject, "from-project", "", "Project to copy the stack from")
+	stackCopyCmd.Flags().
+		StringVar(&stackCopyToOrg, "to-organization", "", "Organization that owns the target project (defaults to the source organization)")
+	stackCopyCmd.Flags().
+		StringVar(&stackCopyToProject, "to-project", "", "Project to copy the stack into (defaults to the source project)")
+	stackCopyCmd.Flags().
+		StringVar(&stackCopyToStackID, "to-stack-id", "", "Stack ID of the copy (defaults to --stack-id)")
+	stackCopyCmd.Flags().
+		StringVar(&stackCopyMap, "map", "", "Mapping file that rewrites image repositories, secret names, and mcp names")
+	stackCopyCmd.Flags().
+		StringVar(&stackCopyOut, "out", "", "Write the copied stack file to this path instead of applying it")
+	stackCopyCmd.Flags().
+		BoolVar(&stackCopyYes, "yes", false, "Apply the planned changes without asking for confirmation")
+	stackCopyCmd.Flags().
+		IntVar(&stackCopyParallelism, "parallelism", 4, "Maximum number of resources to check or change at once")
+
+	stackCmd.AddCommand(stackCopyCmd)
+}
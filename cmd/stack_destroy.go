@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/rollout"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stackDestroyStackID       string
+	stackDestroyOrg           string
+	stackDestroyProject       string
+	stackDestroyDryRun        bool
+	stackDestroyKeepDatabases bool
+	stackDestroyBackup        bool
+	stackDestroyBackupTimeout time.Duration
+	stackDestroyConfirm       string
+	stackDestroyParallelism   int
+)
+
+var stackDestroyCmd = &cobra.Command{
+	Use:   "destroy",
+	Short: "Delete every resource of a stack",
+	Long: `Delete every service, agent, database, and mcp tagged with a stack ID.
+
+The resources are listed first, and nothing is deleted until you confirm by
+typing the stack ID (or pass it with --confirm for non-interactive use).
+They are deleted in dependency order: services and agents first, then the
+mcps agents reference, and databases last. Independent resources are
+deleted concurrently, up to --parallelism at a time; after a failure no
+further deletes start.
+
+Pass --keep-databases to leave the stack's databases in place, or --backup
+to take a final backup of each database and wait for every backup to
+complete before anything is deleted; if a backup fails, nothing is deleted.
+
+Secrets and context items carry no stack ID and are never deleted.
+
+Use --dry-run to list what would be deleted without asking or deleting.`,
+	Example: `  iai stacks destroy --stack-id my-stack --dry-run
+  iai stacks destroy --stack-id my-stack
+  iai stacks destroy --stack-id my-stack --keep-databases
+  iai stacks destroy --stack-id my-stack --backup --confirm my-stack`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+
+		if stackDestroyStackID == "" {
+			return fmt.Errorf("--stack-id is required")
+		}
+		if stackDestroyParallelism < 1 {
+			return fmt.Errorf("--parallelism must be at least 1")
+		}
+
+		pCtx, _, deployClient, err := resolveProject(
+			cmd.Context(),
+			stackDestroyOrg,
+			stackDestroyProject,
+		)
+		if err != nil {
+			return err
+		}
+
+		plans, err := planStackDestroy(cmd, deployClient, pCtx)
+		if err != nil {
+			return err
+		}
+		if len(plans) == 0 {
+			fmt.Fprintf(
+				out,
+				"Stack %q has no resources in project %q.\n",
+				stackDestroyStackID,
+				pCtx.projectName,
+			)
+			return nil
+		}
+
+		if stackDestroyDryRun {
+			fmt.Fprintf(
+				out,
+				"Dry run: destroying stack %q — nothing will be deleted.\n",
+				stackDestroyStackID,
+			)
+			for _, plan := range plans {
+				sync.PrintPlan(out, plan.Label, plan.Result)
+			}
+			return nil
+		}
+
+		fmt.Fprintf(
+			out,
+			"Stack %q in project %q will be destroyed:\n",
+			stackDestroyStackID,
+			pCtx.projectName,
+		)
+		for _, plan := range plans {
+			fmt.Fprintf(out, "  %s: %s\n", plan.Label, strings.Join(plan.Result.Deleted, ", "))
+		}
+		if stackDestroyKeepDatabases {
+			fmt.Fprintln(out, "Databases are kept.")
+		}
+
+		confirmed, err := confirmStackID(
+			cmd.InOrStdin(), out, stackDestroyStackID, stackDestroyConfirm,
+		)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("the stack ID doesn't match; nothing was deleted")
+		}
+
+		if stackDestroyBackup {
+			err := backupStackDatabases(cmd, deployClient, pCtx, plans)
+			if err != nil {
+				return fmt.Errorf("%w; nothing was deleted", err)
+			}
+		}
+
+		fmt.Fprintf(out, "\nDestroying stack %q", stackDestroyStackID)
+		done := output.PrintLoadingDots(out)
+		results, errs := sync.ApplyPlans(plans, nil, stackDestroyParallelism)
+		close(done)
+		fmt.Fprintln(out)
+
+		var firstErr error
+		for i, plan := range plans {
+			err := sync.PrintResult(out, plan.Label, results[i], errs[i])
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr != nil {
+			return firstErr
+		}
+		fmt.Fprintf(out, "\nStack %q destroyed.\n", stackDestroyStackID)
+		return nil
+	},
+}
+
+// planStackDestroy plans the deletion of every resource of the stack, as a
+// sync of a stack file that declares none of them would. Plans with nothing
+// to delete are dropped.
+func planStackDestroy(
+	cmd *cobra.Command,
+	client *deployment.DeploymentClient,
+	pCtx *projectContext,
+) ([]*sync.Plan, error) {
+	ctx := cmd.Context()
+	opts := sync.Options{
+		AllowDelete: true,
+		DryRun:      stackDestroyDryRun,
+		Parallelism: stackDestroyParallelism,
+	}
+	orgId, projectId, stackID := pCtx.orgId, pCtx.projectId, stackDestroyStackID
+	// The sync deletion warnings would only repeat the list printed below.
+	warnW := io.Discard
+
+	planners := []func() (*sync.Plan, error){
+		func() (*sync.Plan, error) {
+			return sync.PlanServices(ctx, warnW, client, orgId, projectId, stackID, nil, opts)
+		},
+		func() (*sync.Plan, error) {
+			return sync.PlanAgents(ctx, warnW, client, orgId, projectId, stackID, nil, opts)
+		},
+		func() (*sync.Plan, error) {
+			return sync.PlanMcps(ctx, warnW, client, orgId, projectId, stackID, nil, opts)
+		},
+	}
+	if !stackDestroyKeepDatabases {
+		planners = append(planners, func() (*sync.Plan, error) {
+			return sync.PlanDatabases(ctx, warnW, client, orgId, projectId, stackID, nil, opts)
+		})
+	}
+
+	var plans []*sync.Plan
+	for _, plan := range planners {
+		p, err := plan()
+		if err != nil {
+			return nil, err
+		}
+		if !p.Empty() {
+			plans = append(plans, p)
+		}
+	}
+	return plans, nil
+}
+
+// confirmStackID asks for the stack ID to be typed, unless it was given
+// with --confirm.
+func confirmStackID(in io.Reader, out io.Writer, stackID, given string) (bool, error) {
+	answer := given
+	if answer == "" {
+		fmt.Fprintf(out, "\nThis cannot be undone. Type the stack ID to confirm: ")
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		answer = line
+	}
+	return strings.TrimSpace(answer) == stackID, nil
+}
+
+// backupStackDatabases triggers a backup of each database the plans delete
+// and waits for all of them to complete.
+func backupStackDatabases(
+	cmd *cobra.Command,
+	deployClient *deployment.DeploymentClient,
+	pCtx *projectContext,
+	plans []*sync.Plan,
+) error {
+	out := cmd.OutOrStdout()
+	backups := make(map[string]string)
+	for _, plan := range plans {
+		if plan.Label != "databases" {
+			continue
+		}
+		for _, name := range plan.Result.Deleted {
+			fmt.Fprintf(out, "Triggering a final backup of database %q...\n", name)
+			backup, err := deployClient.TriggerDatabaseBackup(
+				cmd.Context(),
+				pCtx.orgId,
+				pCtx.projectId,
+				name,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to back up database %q: %w", name, err)
+			}
+			backups[name] = backup.Name
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return rollout.WaitForBackups(
+		ctx,
+		out,
+		deployClient,
+		pCtx.orgId,
+		pCtx.projectId,
+		backups,
+		rollout.Options{Timeout: stackDestroyBackupTimeout},
+	)
+}
+
+func init() {
+	stackDestroyCmd.Flags().
+		StringVar(&stackDestroyStackID, "stack-id", "", "Stack ID to destroy")
+	stackDestroyCmd.Flags().
+		StringVarP(&stackDestroyOrg, "organization", "o", "", "Organization name")
+	stackDestroyCmd.Flags().
+		StringVarP(&stackDestroyProject, "project", "p", "", "Project name")
+	stackDestroyCmd.Flags().
+		BoolVar(&stackDestroyDryRun, "dry-run", false, "List what would be deleted without deleting anything")
+	stackDestroyCmd.Flags().
+		BoolVar(&stackDestroyKeepDatabases, "keep-databases", false, "Leave the stack's databases in place")
+	stackDestroyCmd.Flags().
+		BoolVar(&stackDestroyBackup, "backup", false, "Take a final backup of each database and wait for it before deleting anything")
+	stackDestroyCmd.Flags().
+		DurationVar(&stackDestroyBackupTimeout, "backup-timeout", rollout.DefaultBackupTimeout, "How long --backup waits for the backups to complete")
+	stackDestroyCmd.Flags().
+		StringVar(&stackDestroyConfirm, "confirm", "", "Stack ID to confirm the destroy with, instead of typing it")
+	stackDestroyCmd.Flags().
+		IntVar(&stackDestroyParallelism, "parallelism", 4, "Maximum number of resources to delete at once")
+	stackDestroyCmd.MarkFlagsMutuallyExclusive("keep-databases", "backup")
+
+	stackCmd.AddCommand(stackDestroyCmd)
+}
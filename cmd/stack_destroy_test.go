@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfirmStackID(t *testing.T) {
+	tests := []struct {
+		name       string
+		stdin      string
+		given      string
+		wantOK     bool
+		wantPrompt bool
+	}{
+		{name: "typed", stdin: "my-stack\n", wantOK: true, wantPrompt: true},
+		{name: "typed without newline", stdin: "my-stack", wantOK: true, wantPrompt: true},
+		{name: "typed wrong", stdin: "my\n", wantOK: false, wantPrompt: true},
+		{name: "yes is not enough", stdin: "y\n", wantOK: false, wantPrompt: true},
+		{name: "given", given: "my-stack", wantOK: true},
+		{name: "given wrong", stdin: "my-stack\n", given: "other", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			ok, err := confirmStackID(strings.NewReader(tt.stdin), &out, "my-stack", tt.given)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if prompted := out.Len() > 0; prompted != tt.wantPrompt {
+				t.Errorf("prompted = %v, want %v (output %q)",
+					prompted, tt.wantPrompt, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/drift"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of 'iai stacks drift'; 0 means in sync and 1 is any error.
+const (
+	exitDriftPending  = 10
+	exitDriftDetected = 11
+)
+
+var (
+	stackDriftFile    string
+	stackDriftStackID string
+	stackDriftOrg     string
+	stackDriftProject string
+	stackDriftJSON    bool
+	stackDriftJUnit   bool
+	stackDriftOverlay []string
+)
+
+var stackDriftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Detect live changes made outside the CLI",
+	Long: `Compare a stack configuration file against the live state of its stack,
+across secrets, context items, services, agents, databases, and mcps, and
+report every resource that differs as either drift or a pending local change.
+
+A resource has drifted when its latest live revision was made outside the CLI
+(e.g. in the console or through the API), according to the revision's source.
+Any other difference is a local change pending: the file changed and hasn't
+been synced yet. Databases, secrets, and context items keep no revision
+attribution, so their differences always count as pending. MCP credentials
+are never read back from the live stack, so they aren't compared.
+
+The exit code tells the outcomes apart, so the command can run on a schedule:
+
+  0   in sync
+  10  local changes pending, no drift
+  11  live drift detected
+
+If the comparison itself fails, the command exits with one of the codes
+listed in 'iai --help'.
+
+Use --json for a machine-readable report, or --junit for a JUnit XML report
+with one test case per resource, failed when the resource isn't in sync.
+
+The local file, --stack-id, and --overlay work as in 'iai stacks diff'.`,
+	Example: `  iai stacks drift --file stack.yaml
+  iai stacks drift --file stack.yaml --json
+  iai stacks drift --file stack.yaml --overlay prod.yaml --junit > drift.xml`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+
+		filePath := stackDriftFile
+		if filePath == "" {
+			filePath = cfgFilePath
+		}
+		if filePath == "" {
+			return fmt.Errorf("config file is required; please provide --file or --cfg-file")
+		}
+
+		c, err := compareStack(
+			cmd, filePath, stackDriftOverlay, stackDriftStackID, stackDriftOrg, stackDriftProject,
+		)
+		if err != nil {
+			return err
+		}
+
+		report, err := drift.Detect(
+			cmd.Context(),
+			c.deployClient,
+			c.pCtx.orgId,
+			c.pCtx.projectId,
+			c.local,
+			c.live,
+		)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case stackDriftJSON:
+			err = output.PrintStructuredJSON(out, report)
+		case stackDriftJUnit:
+			err = drift.WriteJUnit(out, report)
+		default:
+			drift.PrintReport(out, report)
+		}
+		if err != nil {
+			return err
+		}
+
+		counts := report.Counts()
+		switch report.Status {
+		case drift.Drift:
+			return &exitCodeError{
+				code: exitDriftDetected,
+				err:  fmt.Errorf("live drift detected in %d resources", counts[drift.Drift]),
+			}
+		case drift.Pending:
+			return &exitCodeError{
+				code: exitDriftPending,
+				err: fmt.Errorf(
+					"%d resources have local changes pending", counts[drift.Pending],
+				),
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	stackDriftCmd.Flags().
+		StringVarP(&stackDriftFile, "file", "f", "", "Path to local stack configuration file or directory")
+	stackDriftCmd.Flags().
+		StringVar(&stackDriftStackID, "stack-id", "", "Stack ID to compare against live")
+	stackDriftCmd.Flags().
+		StringVarP(&stackDriftOrg, "organization", "o", "", "Organization name")
+	stackDriftCmd.Flags().
+		StringVarP(&stackDriftProject, "project", "p", "", "Project name")
+	stackDriftCmd.Flags().
+		BoolVar(&stackDriftJSON, "json", false, "Output the report as JSON")
+	stackDriftCmd.Flags().
+		BoolVar(&stackDriftJUnit, "junit", false, "Output the report as JUnit XML")
+	stackDriftCmd.Flags().
+		StringSliceVar(&stackDriftOverlay, "overlay", nil, "Overlay stack file to deep-merge onto --file (repeatable; applied in order)")
+	stackDriftCmd.MarkFlagsMutuallyExclusive("json", "junit")
+
+	stackCmd.AddCommand(stackDriftCmd)
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stackExportFile           string
+	stackExportOverlay        []string
+	stackExportFormat         string
+	stackExportOutDir         string
+	stackExportResolveSecrets bool
+)
+
+var stackExportFormats = []string{"compose"}
+
+var stackExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Convert a stack file for running the stack locally",
+	Long: `Convert a stack file into a docker-compose project, to run the stack's services
+on your machine with 'docker compose up'.
+
+--out-dir receives compose.yaml and a secrets/ directory with an env file per
+secret the services reference:
+
+  - each service keeps its image, env, and replicas (autoscaling's minimum),
+    and publishes its servicePort on the same host port, or the next free
+    one when another service took it; a healthcheck path is probed with
+    curl, which the image must have
+  - internal images are referred to by name and tag, as 'iai images build'
+    tags them locally
+  - each database becomes a postgres container of its postgresVersion, and
+    services whose env names it as a host start after it; its <database>-app
+    secret points at the container
+  - other secrets get placeholder env files with their keys, to fill in by
+    hand, or, with --resolve-secrets, their values read from the stack
+    file's sources (keys, env, envFile, command) as in a sync
+
+Existing env files are never overwritten, so values filled in by hand
+survive a re-export; delete a file to generate it again. Agents and mcps
+only run on the platform and are left out.`,
+	Example: `  iai stacks export --format compose --file stack.yaml --out-dir local
+  iai stacks export --format compose --file stack.yaml --out-dir local --resolve-secrets
+  cd local && docker compose up`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		errOut := cmd.ErrOrStderr()
+
+		if !slices.Contains(stackExportFormats, stackExportFormat) {
+			return fmt.Errorf(
+				"invalid format %q: must be one of %s",
+				stackExportFormat,
+				strings.Join(stackExportFormats, ", "),
+			)
+		}
+		filePath := stackExportFile
+		if filePath == "" {
+			filePath = cfgFilePath
+		}
+		if filePath == "" {
+			return fmt.Errorf("config file is required; please provide --file or --cfg-file")
+		}
+
+		cfg, err := files.LoadStackConfig(filePath, stackExportOverlay...)
+		if err != nil {
+			return fmt.Errorf("failed to load config file: %w", err)
+		}
+		if len(cfg.Services)+len(cfg.Databases) == 0 {
+			return fmt.Errorf("%s has no services or databases to export", filePath)
+		}
+		if n := len(cfg.Agents) + len(cfg.Mcps); n > 0 {
+			fmt.Fprintf(
+				errOut,
+				"Warning: %d agents and mcps only run on the platform and are left out\n",
+				n,
+			)
+		}
+
+		notes, err := files.WriteCompose(
+			cmd.Context(),
+			cfg,
+			files.StackBaseDir(filePath),
+			stackExportOutDir,
+			stackExportResolveSecrets,
+		)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(
+			out,
+			"Wrote %s with %d services and %d databases.\n",
+			filepath.Join(stackExportOutDir, files.ComposeFileName),
+			len(cfg.Services),
+			len(cfg.Databases),
+		)
+		for _, note := range notes {
+			fmt.Fprintf(out, "  %s\n", note)
+		}
+		return nil
+	},
+}
+
+func init() {
+	stackExportCmd.Flags().
+		StringVarP(&stackExportFile, "file", "f", "", "Path to stack configuration file or directory")
+	stackExportCmd.Flags().
+		StringSliceVar(&stackExportOverlay, "overlay", nil, "Overlay stack file to deep-merge onto --file (repeatable; applied in order)")
+	stackExportCmd.Flags().
+		StringVar(&stackExportFormat, "format", "compose", "Export format: compose")
+	stackExportCmd.Flags().
+		StringVar(&stackExportOutDir, "out-dir", ".", "Directory to write the exported files to")
+	stackExportCmd.Flags().
+		BoolVar(&stackExportResolveSecrets, "resolve-secrets", false, "Fill the secrets' env files with their values from the stack file's sources instead of placeholders")
+
+	stackCmd.AddCommand(stackExportCmd)
+}
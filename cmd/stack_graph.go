@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stackGraphFile    string
+	stackGraphOverlay []string
+	stackGraphStackID string
+	stackGraphOrg     string
+	stackGraphProject string
+	stackGraphFormat  string
+)
+
+var stackGraphFormats = []string{"tree", "dot", "mermaid"}
+
+var stackGraphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Show how the resources of a stack reference each other",
+	Long: `Print the dependency graph of a stack: which services, agents, and mcps use
+which other resources, secrets, and context items.
+
+The graph is built from a stack file or directory (--file), offline, or from
+the live resources of a stack (--stack-id). A reference is found in:
+
+  - agentConfig mcps entries, naming an mcp
+  - agentConfig context routines, policies, glossaries, variables, and
+    macros entries, and the prompts of context.description.prompt_id and
+    knowledge_base.prompt.id, naming a context item
+  - secretRefs, naming a secret
+  - env values mentioning a service, database, or mcp of the stack as a
+    host name, e.g. postgres://orders-db:5432/app or http://api:8080
+
+A reference is dangling when its target is missing: for a stack file, when
+the file doesn't declare it; for a live stack, when it doesn't exist in the
+project (secrets and context items) or in the stack (services, databases,
+and mcps).
+Dangling references are marked in the graph and reported on stderr.
+
+Formats:
+  tree      an indented tree per resource nothing else references (default)
+  dot       Graphviz DOT, e.g. for 'dot -Tsvg'
+  mermaid   a Mermaid flowchart, e.g. for Markdown documentation`,
+	Example: `  iai stacks graph --file stack.yaml
+  iai stacks graph --file stack/ --format dot | dot -Tsvg > stack.svg
+  iai stacks graph --stack-id shop --format mermaid`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		errOut := cmd.ErrOrStderr()
+
+		if !slices.Contains(stackGraphFormats, stackGraphFormat) {
+			return fmt.Errorf(
+				"invalid format %q: must be one of %s",
+				stackGraphFormat,
+				strings.Join(stackGraphFormats, ", "),
+			)
+		}
+
+		filePath := stackGraphFile
+		if filePath == "" && stackGraphStackID == "" {
+			filePath = cfgFilePath
+		}
+		if filePath == "" && stackGraphStackID == "" {
+			return fmt.Errorf("please provide --file or --stack-id")
+		}
+
+		var cfg *files.StackConfig
+		var err error
+		missing := "which doesn't exist"
+		if filePath != "" {
+			missing = "which the stack file doesn't declare"
+			cfg, err = files.LoadStackConfig(filePath, stackGraphOverlay...)
+			if err != nil {
+				return fmt.Errorf("failed to load config file: %w", err)
+			}
+		} else if cfg, err = fetchGraphStack(cmd); err != nil {
+			return err
+		}
+
+		graph := files.BuildStackGraph(cfg)
+		switch stackGraphFormat {
+		case "dot":
+			if err := graph.WriteDOT(out, cfg.StackId); err != nil {
+				return err
+			}
+		case "mermaid":
+			if err := graph.WriteMermaid(out); err != nil {
+				return err
+			}
+		default:
+			if err := graph.WriteTree(out); err != nil {
+				return err
+			}
+		}
+
+		for _, e := range graph.Dangling() {
+			fmt.Fprintf(
+				errOut,
+				"Warning: %s references %s (%s), %s\n",
+				e.From,
+				e.To,
+				strings.Join(e.Via, ", "),
+				missing,
+			)
+		}
+		return nil
+	},
+}
+
+// fetchGraphStack returns the live stack --stack-id names, with the project's
+// secrets and context items it references added by name, so references to
+// them only dangle when they don't exist.
+func fetchGraphStack(cmd *cobra.Command) (*files.StackConfig, error) {
+	pCtx, apiClient, deployClient, err := resolveProject(
+		cmd.Context(),
+		stackGraphOrg,
+		stackGraphProject,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := files.FetchLiveStack(
+		cmd.Context(),
+		deployClient,
+		pCtx.orgId,
+		pCtx.projectId,
+		stackGraphStackID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := deployClient.ListSecrets(cmd.Context(), pCtx.orgId, pCtx.projectId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	cfg.Secrets = make(map[string]files.SecretConfig)
+	for _, ref := range files.StackReferences(cfg) {
+		name, ok := strings.CutPrefix(ref.To, "secret/")
+		if !ok {
+			continue
+		}
+		for _, s := range secrets {
+			if s.Name == name {
+				cfg.Secrets[name] = files.SecretConfig{}
+			}
+		}
+	}
+
+	err = files.FetchReferencedContext(cmd.Context(), apiClient, pCtx.projectId, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func init() {
+	stackGraphCmd.Flags().
+		StringVarP(&stackGraphFile, "file", "f", "", "Path to stack configuration file or directory")
+	stackGraphCmd.Flags().
+		StringSliceVar(&stackGraphOverlay, "overlay", nil, "Overlay stack file to deep-merge onto --file (repeatable; applied in order)")
+	stackGraphCmd.Flags().
+		StringVar(&stackGraphStackID, "stack-id", "", "Stack ID to graph from its live resources instead of a file")
+	stackGraphCmd.Flags().
+		StringVarP(&stackGraphOrg, "organization", "o", "", "Organization name (with --stack-id)")
+	stackGraphCmd.Flags().
+		StringVarP(&stackGraphProject, "project", "p", "", "Project name (with --stack-id)")
+	stackGraphCmd.Flags().
+		StringVar(&stackGraphFormat, "format", "tree", "Graph format: tree, dot, or mermaid")
+	stackGraphCmd.MarkFlagsMutuallyExclusive("file", "stack-id")
+
+	stackCmd.AddCommand(stackGraphCmd)
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stackPlanFile         string
+	stackPlanOverlay      []string
+	stackPlanProject      string
+	stackPlanOrganization string
+	stackPlanAllowDelete  []string
+	stackPlanParallelism  int
+	stackPlanOut          string
+	stackPlanTarget       []string
+
+	stackApplyParallelism int
+	stackApplyAtomic      bool
+	stackApplyWait        bool
+	stackApplyWaitTimeout time.Duration
+)
+
+var stackPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Write a reviewable sync plan to a file",
+	Long: `Plan a stack sync without applying it and write the plan to a file for
+'iai stacks apply'.
+
+The plan is printed as with 'iai stacks sync --dry-run' and written to --out
+as JSON: the paths of the stack file and overlays, a digest of the config
+they load to, and every create, update, and delete together with the live
+revision of the resource it was planned against. Commit it next to the stack
+file so the plan can be reviewed before it is applied.
+
+With --target, only the targeted resources are planned, as in 'iai stacks
+sync --target', and the targets are recorded in the plan for the apply.
+
+The config itself is not written to the plan, so neither are secret values,
+MCP credentials, or the values of expanded variables; 'iai stacks apply'
+loads the stack file again and resolves them from their sources.
+
+The organization and project are read from the config file, flags, or resolved via 'iai organizations select' / 'iai projects select'.
+They are recorded in the plan, and 'iai stacks apply' acts on the same ones.`,
+	Example: `  iai stacks plan --file stack.yaml --out plan.json
+  iai stacks plan --file stack.yaml --overlay prod.yaml --out plan.json
+  iai stacks plan --file stack.yaml --allow-delete services --out plan.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+
+		filePath := stackPlanFile
+		if filePath == "" {
+			filePath = cfgFilePath
+		}
+		if filePath == "" {
+			return fmt.Errorf("config file is required; please provide --file or --cfg-file")
+		}
+
+		targets, err := sync.NewTargets(stackPlanTarget)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := files.LoadStackConfig(filePath, stackPlanOverlay...)
+		if err != nil {
+			return fmt.Errorf("failed to load stack config: %w", err)
+		}
+		baseDir, err := filepath.Abs(files.StackBaseDir(filePath))
+		if err != nil {
+			return err
+		}
+		// runStackSync fills in the organization and project, which the
+		// apply digests the config without.
+		digest, err := sync.ConfigDigest(cfg)
+		if err != nil {
+			return err
+		}
+
+		plans, err := runStackSync(cmd, stackRun{
+			cfg:         cfg,
+			baseDir:     baseDir,
+			org:         stackPlanOrganization,
+			project:     stackPlanProject,
+			allowDelete: stackPlanAllowDelete,
+			dryRun:      true,
+			parallelism: stackPlanParallelism,
+			targets:     targets,
+		})
+		if err != nil {
+			return err
+		}
+
+		changes := sync.Changes(plans)
+		err = sync.WritePlanFile(stackPlanOut, &sync.PlanFile{
+			Version:      sync.PlanFileVersion,
+			CreatedAt:    time.Now().UTC(),
+			File:         filePath,
+			Overlays:     stackPlanOverlay,
+			ConfigDigest: digest,
+			Organization: cfg.Organization,
+			Project:      cfg.Project,
+			AllowDelete:  stackPlanAllowDelete,
+			Targets:      stackPlanTarget,
+			Changes:      changes,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "\nPlan with %d changes written to %s.\n", len(changes), stackPlanOut)
+		fmt.Fprintf(out, "Apply it with 'iai stacks apply %s'.\n", stackPlanOut)
+		return nil
+	},
+}
+
+var stackApplyCmd = &cobra.Command{
+	Use:   "apply <plan-file>",
+	Short: "Apply a plan written by 'iai stacks plan'",
+	Long: `Apply a plan file written by 'iai stacks plan'.
+
+The stack file and overlays the plan was made from are loaded again, with
+variables expanded from the current environment, and synced as with 'iai
+stacks sync', in the organization and project the plan was made in, but
+only the changes the plan lists are made. The apply stops before changing
+anything if the config no longer matches the planned one; MCP credentials
+and secret values are left out of that check. Each resource is checked
+against the plan first, and a change is refused when its resource moved on
+since planning: its live revision differs from the one recorded, it now
+needs a different change (e.g. an update instead of a create), or it needs
+a change the plan doesn't list. Resources that depend on a refused one are
+refused too. Every other planned change is applied; the refusals are
+reported and the command exits non-zero, so run 'iai stacks plan' again to
+review the current state.
+
+Secrets and context items carry no revision; they are only checked for the
+planned change.
+
+--atomic, --wait, and --timeout work as in 'iai stacks sync'.`,
+	Example: `  iai stacks apply plan.json
+  iai stacks apply plan.json --atomic --wait`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plan, err := sync.ReadPlanFile(args[0])
+		if err != nil {
+			return err
+		}
+		targets, err := sync.NewTargets(plan.Targets)
+		if err != nil {
+			return err
+		}
+		cfg, err := plan.LoadConfig()
+		if err != nil {
+			return err
+		}
+		baseDir, err := filepath.Abs(files.StackBaseDir(plan.File))
+		if err != nil {
+			return err
+		}
+
+		_, err = runStackSync(cmd, stackRun{
+			cfg:         cfg,
+			baseDir:     baseDir,
+			org:         plan.Organization,
+			project:     plan.Project,
+			allowDelete: plan.AllowDelete,
+			parallelism: stackApplyParallelism,
+			atomic:      stackApplyAtomic,
+			wait:        stackApplyWait,
+			waitTimeout: stackApplyWaitTimeout,
+			planned:     plan.Changes,
+			targets:     targets,
+		})
+		return err
+	},
+}
+
+func init() {
+	stackPlanCmd.Flags().
+		StringVarP(&stackPlanFile, "file", "f", "", "Path to stack configuration file or directory")
+	stackPlanCmd.Flags().
+		StringSliceVar(&stackPlanOverlay, "overlay", nil, "Overlay stack file to deep-merge onto --file (repeatable; applied in order)")
+	stackPlanCmd.Flags().
+		StringVarP(&stackPlanProject, "project", "p", "", "Project name to plan resources in")
+	stackPlanCmd.Flags().
+		StringVarP(&stackPlanOrganization, "organization", "o", "", "Organization name that owns the project")
+	stackPlanCmd.Flags().
+		StringSliceVar(&stackPlanAllowDelete, "allow-delete", nil, "Resource types the plan may delete when the config omits them (services, agents, databases, mcps, or all)")
+	stackPlanCmd.Flags().
+		IntVar(&stackPlanParallelism, "parallelism", 4, "Maximum number of resources to check at once")
+	stackPlanCmd.Flags().
+		StringVar(&stackPlanOut, "out", "", "Path to write the plan file to")
+	stackPlanCmd.Flags().
+		StringSliceVar(&stackPlanTarget, "target", nil, "Plan only this resource, as type/name with * globs, e.g. service/api (repeatable)")
+	_ = stackPlanCmd.MarkFlagRequired("out")
+
+	stackApplyCmd.Flags().
+		IntVar(&stackApplyParallelism, "parallelism", 4, "Maximum number of resources to check or change at once")
+	stackApplyCmd.Flags().
+		BoolVar(&stackApplyAtomic, "atomic", false, "Revert every change the apply made if any step fails")
+	addWaitFlags(stackApplyCmd, &stackApplyWait, &stackApplyWaitTimeout)
+
+	stackCmd.AddCommand(stackPlanCmd)
+	stackCmd.AddCommand(stackApplyCmd)
+}
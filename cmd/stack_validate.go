@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stackValidateFile    string
+	stackValidateOverlay []string
+	stackValidateJSON    bool
+)
+
+var stackValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a stack file for mistakes without contacting the server",
+	Long: `Validate a stack configuration file offline, before 'iai stacks sync' sends
+it to the server.
+
+Each file, the base file and every --overlay, is decoded strictly: keys the
+stack file format doesn't know (usually typos, which a sync silently ignores)
+and values of the wrong type are reported with their file and line. For a
+stack directory, or a file with includes, issues are reported against the
+entry or included file they are in. The
+merged config is then checked for:
+
+  - replicas together with autoscaling, and autoscaling bounds
+  - malformed CPU, memory, and storage quantities (e.g. 500m, 512M, 10G)
+  - images without a name or tag, and ports out of range
+  - mcp types, auth types, and credentials that don't go together
+  - agentConfig mcps entries naming an mcp the file doesn't declare
+  - secretRefs to secrets the file doesn't declare (a warning: the secret
+    may already exist)
+
+Environment variables are expanded as in a sync, but secret values are not
+resolved and nothing is sent to the server. The command exits non-zero when
+any error is found; warnings alone don't fail it.
+
+For editor completion and validation as you type, use the JSON Schema printed
+by 'iai stacks schema'.`,
+	Example: `  iai stacks validate --file stack.yaml
+  iai stacks validate --file stack.yaml --overlay prod.yaml
+  iai stacks validate --file stack/
+  iai stacks validate --file stack.yaml --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+
+		filePath := stackValidateFile
+		if filePath == "" {
+			filePath = cfgFilePath
+		}
+		if filePath == "" {
+			return fmt.Errorf("config file is required; please provide --file or --cfg-file")
+		}
+
+		issues, err := files.ValidateStackFile(filePath, stackValidateOverlay...)
+		if err != nil {
+			return err
+		}
+
+		errCount := 0
+		for _, issue := range issues {
+			if !issue.Warning {
+				errCount++
+			}
+		}
+
+		if stackValidateJSON {
+			if issues == nil {
+				issues = []files.ValidationIssue{}
+			}
+			if err := output.PrintStructuredJSON(out, issues); err != nil {
+				return err
+			}
+		} else {
+			for _, issue := range issues {
+				fmt.Fprintln(out, issue)
+			}
+			if errCount == 0 {
+				fmt.Fprintf(out, "%s is valid.\n", filePath)
+			}
+		}
+
+		if errCount > 0 {
+			return fmt.Errorf(
+				"found %d errors and %d warnings", errCount, len(issues)-errCount,
+			)
+		}
+		return nil
+	},
+}
+
+var stackSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of stack files",
+	Long: `Print a JSON Schema (draft 2020-12) for stack configuration files, generated
+from the same types 'iai stacks sync' decodes them into.
+
+Point your editor's YAML language server at it for completion and validation
+as you type, e.g. with a modeline at the top of stack.yaml:
+
+  # yaml-language-server: $schema=./stack.schema.json
+
+The schema rejects unknown keys and checks value types, quantities, and
+enums; run 'iai stacks validate' for the cross-field and reference checks it
+can't express.`,
+	Example: `  iai stacks schema > stack.schema.json`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return output.PrintStructuredJSON(cmd.OutOrStdout(), files.StackSchema())
+	},
+}
+
+func init() {
+	stackValidateCmd.Flags().
+		StringVarP(&stackValidateFile, "file", "f", "", "Path to stack configuration file or directory")
+	stackValidateCmd.Flags().
+		StringSliceVar(&stackValidateOverlay, "overlay", nil, "Overlay stack file to deep-merge onto --file (repeatable; applied in order)")
+	stackValidateCmd.Flags().
+		BoolVar(&stackValidateJSON, "json", false, "Output the issues as JSON")
+
+	stackCmd.AddCommand(stackValidateCmd)
+	stackCmd.AddCommand(stackSchemaCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/rollout"
+	"github.com/spf13/cobra"
+)
+
+// waitForRollout polls targets until they are ready, streaming status
+// changes to stdout. Ctrl-C stops waiting; the rollout itself continues.
+func waitForRollout(
+	cmd *cobra.Command,
+	deployClient *deployment.DeploymentClient,
+	orgId, projectId string,
+	timeout time.Duration,
+	targets ...rollout.Target,
+) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := rollout.Wait(
+		ctx,
+		cmd.OutOrStdout(),
+		deployClient,
+		orgId,
+		projectId,
+		targets,
+		rollout.Options{Timeout: timeout},
+	)
+	if err != nil && ctx.Err() != nil && cmd.Context().Err() == nil {
+		return fmt.Errorf("stopped waiting; the rollout continues in the background")
+	}
+	return err
+}
+
+// addWaitFlags registers --wait and --timeout on a command that creates or
+// updates a resource.
+func addWaitFlags(c *cobra.Command, wait *bool, timeout *time.Duration) {
+	c.Flags().
+		BoolVar(wait, "wait", false, "Wait until the rollout is ready; exit non-zero if it fails or times out")
+	c.Flags().
+		DurationVar(timeout, "timeout", rollout.DefaultTimeout, "How long --wait waits for the rollout before failing")
+}
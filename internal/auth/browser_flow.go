@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients"
 )
 
 // BrowserFlowResult holds the session cookies after a successful browser login.
@@ -73,7 +75,7 @@ func RunBrowserFlow(
 		return nil, fmt.Errorf("failed to encode authorize request: %w", err)
 	}
 
-	httpClient := &http.Client{Timeout: 15 * time.Second}
+	httpClient := &http.Client{Transport: clients.DefaultTransport, Timeout: 15 * time.Second}
 	authorizeReq, err := http.NewRequestWithContext(
 		ctx, http.MethodPost,
 		hostname+"/api/v1/auth/cli/authorize",
@@ -143,7 +145,8 @@ func exchangeCode(
 
 	// Use a raw http.Client that does NOT follow redirects, so we can capture Set-Cookie headers
 	noRedirectClient := &http.Client{
-		Timeout: 15 * time.Second,
+		Transport: clients.DefaultTransport,
+		Timeout:   15 * time.Second,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients"
 	"github.com/mdp/qrterminal/v3"
 )
 
@@ -44,7 +45,7 @@ func RunDeviceFlow(
 	timeout time.Duration,
 	out io.Writer,
 ) (*DeviceFlowResult, error) {
-	httpClient := &http.Client{Timeout: 15 * time.Second}
+	httpClient := &http.Client{Transport: clients.DefaultTransport, Timeout: 15 * time.Second}
 
 	// 1. Request device code
 	deviceReq, err := http.NewRequestWithContext(
@@ -144,7 +145,8 @@ func pollDeviceToken(
 
 	// Use a client that does NOT follow redirects to capture Set-Cookie headers
 	noRedirectClient := &http.Client{
-		Timeout: 15 * time.Second,
+		Transport: clients.DefaultTransport,
+		Timeout:   15 * time.Second,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
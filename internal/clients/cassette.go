@@ -0,0 +1,306 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	gosync "sync"
+)
+
+// Cassette is a directory of recorded requests and their responses, one JSON
+// file each, numbered in the order the requests were sent; requests that got
+// no response leave a gap. Credentials and secret values are scrubbed as in
+// --debug traces, so cassettes can be attached to bug reports.
+type Cassette struct {
+	dir    string
+	replay bool
+
+	mu gosync.Mutex
+	// next is the number of the next request sent.
+	next int
+	// interactions are the recorded interactions being replayed, and used
+	// whether each has been replayed.
+	interactions []Interaction
+	used         []bool
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// DefaultCassette is the cassette the clients NewHTTPClient makes record to
+// or replay from; nil means they use the network as is. The CLI sets it from
+// --record and --replay.
+var DefaultCassette *Cassette
+
+// RecordCassette returns a cassette that records to dir, after the
+// interactions already there.
+func RecordCassette(dir string) (*Cassette, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	names, err := cassetteFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cassette{dir: dir, next: 1}
+	for _, name := range names {
+		if n, err := strconv.Atoi(strings.SplitN(name, "-", 2)[0]); err == nil && n >= c.next {
+			c.next = n + 1
+		}
+	}
+	return c, nil
+}
+
+// ReplayCassette returns a cassette that replays the interactions recorded
+// in dir.
+func ReplayCassette(dir string) (*Cassette, error) {
+	names, err := cassetteFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no recorded interactions in %s", dir)
+	}
+	c := &Cassette{dir: dir, replay: true}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		var in Interaction
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		c.interactions = append(c.interactions, in)
+	}
+	c.used = make([]bool, len(c.interactions))
+	return c, nil
+}
+
+// cassetteFiles returns the names of the interaction files in dir, in the
+// order they were recorded.
+func cassetteFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette directory: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == ".json" {
+			names = append(names, e.Name())
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// Transport returns a transport that sends requests with base and records
+// them, or, for a replayed cassette, answers them without any network.
+func (c *Cassette) Transport(base http.RoundTripper) http.RoundTripper {
+	return &cassetteTransport{cassette: c, base: base}
+}
+
+type cassetteTransport struct {
+	cassette *Cassette
+	base     http.RoundTripper
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: scrubHeader(req.Header),
+		Body:   string(RedactBody(req.URL.Path, req.Header.Get("Content-Type"), body)),
+	}
+
+	if t.cassette.replay {
+		return t.cassette.replayResponse(req, recorded)
+	}
+
+	seq := t.cassette.nextNumber()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		done: func(respBody []byte) {
+			contentType := resp.Header.Get("Content-Type")
+			t.cassette.record(seq, Interaction{
+				Request: recorded,
+				Response: RecordedResponse{
+					StatusCode: resp.StatusCode,
+					Header:     scrubHeader(resp.Header),
+					Body:       string(RedactBody(req.URL.Path, contentType, respBody)),
+				},
+			})
+		},
+	}
+	return resp, nil
+}
+
+var slugUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// nextNumber returns the number of a request about to be sent.
+func (c *Cassette) nextNumber() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.next++
+	return c.next - 1
+}
+
+// record writes in to a file named after seq, the number of its request, and
+// its method and path, e.g. 0003-get-projects-p-services.json. Responses are
+// recorded once their body is closed, which for concurrent or streamed
+// requests may not be the order they were sent in.
+func (c *Cassette) record(seq int, in Interaction) {
+	data, err := json.MarshalIndent(in, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record %s %s: %v\n",
+			in.Request.Method, in.Request.URL, err)
+		return
+	}
+
+	path, _, _ := strings.Cut(requestURI(in.Request.URL), "?")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	slug := strings.Join(segments[max(len(segments)-3, 0):], "-")
+	slug = strings.Trim(slugUnsafe.ReplaceAllString(strings.ToLower(slug), "-"), "-")
+	name := fmt.Sprintf("%04d-%s-%s.json", seq, strings.ToLower(in.Request.Method), slug)
+
+	if err := os.WriteFile(filepath.Join(c.dir, name), append(data, '\n'), 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record %s %s: %v\n",
+			in.Request.Method, in.Request.URL, err)
+	}
+}
+
+// replayResponse answers req with the first interaction not replayed yet
+// that recorded the same method, path, query, and body. Once all such
+// interactions have been replayed, the last one is replayed again, so polls
+// that run longer than when they were recorded see the final state.
+func (c *Cassette) replayResponse(
+	req *http.Request,
+	recorded RecordedRequest,
+) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last := -1
+	for i, in := range c.interactions {
+		if !sameRequest(in.Request, recorded) {
+			continue
+		}
+		last = i
+		if !c.used[i] {
+			break
+		}
+	}
+	if last < 0 {
+		return nil, fmt.Errorf(
+			"no recorded response in %s for %s %s", c.dir, req.Method, req.URL.RequestURI(),
+		)
+	}
+	c.used[last] = true
+
+	in := c.interactions[last].Response
+	return &http.Response{
+		StatusCode:    in.StatusCode,
+		Status:        fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        in.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(in.Body)),
+		ContentLength: int64(len(in.Body)),
+		Request:       req,
+	}, nil
+}
+
+// sameRequest reports whether a and b have the same method, path, query, and
+// body; hosts are ignored so cassettes replay against any --hostname.
+func sameRequest(a, b RecordedRequest) bool {
+	return a.Method == b.Method &&
+		requestURI(a.URL) == requestURI(b.URL) &&
+		a.Body == b.Body
+}
+
+// requestURI returns the path and query of rawURL.
+func requestURI(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.RequestURI()
+}
+
+// scrubHeader returns h with the values of redactedHeaders replaced.
+func scrubHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	scrubbed := h.Clone()
+	for name, values := range scrubbed {
+		if isRedactedHeader(name) {
+			for i := range values {
+				values[i] = redacted
+			}
+		}
+	}
+	return scrubbed
+}
+
+// recordingBody keeps what is read from a response body, and hands it to
+// done once the body is closed, so streamed responses are recorded as far as
+// they were read.
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	done func([]byte)
+	once gosync.Once
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.buf.Bytes()) })
+	return err
+}
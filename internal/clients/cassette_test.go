@@ -0,0 +1,162 @@
+package clients
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// doCassette sends a request with client and returns the response's status
+// and body.
+func doCassette(t *testing.T, client *http.Client, method, url, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer tok")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do(%s %s) error = %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	return resp.StatusCode, string(respBody)
+}
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/projects/p/secrets/api-keys":
+			_, _ = w.Write([]byte(`{"name":"api-keys","data":{"API_KEY":"s3cret"}}`))
+		case "/v1/projects/p/services":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"not found"}`))
+		}
+	}))
+	dir := t.TempDir()
+
+	recording, err := RecordCassette(dir)
+	if err != nil {
+		t.Fatalf("RecordCassette() error = %v", err)
+	}
+	client := &http.Client{Transport: recording.Transport(http.DefaultTransport)}
+	doCassette(t, client, http.MethodPut, server.URL+"/v1/projects/p/secrets/api-keys",
+		`{"data":{"API_KEY":"s3cret"}}`)
+	doCassette(t, client, http.MethodGet, server.URL+"/v1/projects/p/services?stackId=s", "")
+	server.Close()
+
+	names, err := cassetteFiles(dir)
+	if err != nil {
+		t.Fatalf("cassetteFiles() error = %v", err)
+	}
+	wantNames := []string{
+		"0001-put-p-secrets-api-keys.json",
+		"0002-get-projects-p-services.json",
+	}
+	if diff := cmp.Diff(wantNames, names); diff != "" {
+		t.Fatalf("cassette files mismatch (-want +got):\n%s", diff)
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		for _, leak := range []string{"s3cret", "Bearer tok"} {
+			if strings.Contains(string(data), leak) {
+				t.Errorf("%s leaks %q:\n%s", name, leak, data)
+			}
+		}
+	}
+
+	replaying, err := ReplayCassette(dir)
+	if err != nil {
+		t.Fatalf("ReplayCassette() error = %v", err)
+	}
+	client = &http.Client{Transport: replaying.Transport(http.DefaultTransport)}
+	// Hosts are ignored, and scrubbed bodies still match.
+	status, body := doCassette(t, client, http.MethodPut,
+		"https://elsewhere/v1/projects/p/secrets/api-keys", `{"data":{"API_KEY":"other"}}`)
+	if want := `{"data":{"API_KEY":"[REDACTED]"},"name":"api-keys"}`; status != 200 || body != want {
+		t.Errorf("replayed PUT = %d %s, want 200 %s", status, body, want)
+	}
+	for range 2 {
+		status, body = doCassette(t, client, http.MethodGet,
+			"https://elsewhere/v1/projects/p/services?stackId=s", "")
+		if status != http.StatusNotFound || body != `{"message":"not found"}` {
+			t.Errorf("replayed GET = %d %s, want 404 not found", status, body)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("server got %d requests, want 2 from the recording only", calls)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://elsewhere/v1/projects/p/agents", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	if _, err := client.Do(req); err == nil ||
+		!strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("Do() of an unrecorded request error = %v, want no recorded response", err)
+	}
+}
+
+func TestRecordCassetteContinuesNumbering(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "0007-get-x.json"), []byte("{}"), 0o600); err != nil {
+		t.Fatalf("failed to write cassette: %v", err)
+	}
+	c, err := RecordCassette(dir)
+	if err != nil {
+		t.Fatalf("RecordCassette() error = %v", err)
+	}
+	if c.next != 8 {
+		t.Errorf("next = %d, want 8", c.next)
+	}
+}
+
+func TestCassetteNumbersRequestsInSendOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	recording, err := RecordCassette(dir)
+	if err != nil {
+		t.Fatalf("RecordCassette() error = %v", err)
+	}
+	client := &http.Client{Transport: recording.Transport(http.DefaultTransport)}
+	var bodies []io.ReadCloser
+	for _, path := range []string{"/v1/first", "/v1/second"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", path, err)
+		}
+		bodies = append(bodies, resp.Body)
+	}
+	bodies[1].Close()
+	bodies[0].Close()
+
+	names, err := cassetteFiles(dir)
+	if err != nil {
+		t.Fatalf("cassetteFiles() error = %v", err)
+	}
+	want := []string{"0001-get-v1-first.json", "0002-get-v1-second.json"}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("cassette files mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,318 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	gosync "sync"
+	"time"
+)
+
+// DebugOptions configures the tracing of API requests.
+type DebugOptions struct {
+	Out io.Writer
+	// Trace logs each request and response: method, URL, status, timing,
+	// headers, and bodies.
+	Trace bool
+	// Curl prints an equivalent curl command for each request.
+	Curl bool
+}
+
+// DefaultDebug is the tracing of the clients NewHTTPClient makes; tracing is
+// off unless Trace or Curl is set. The CLI sets it from --debug, --debug-file,
+// and --debug-curl.
+var DefaultDebug DebugOptions
+
+// maxDebugBody is how much of a body is logged.
+const maxDebugBody = 16 << 10
+
+// maxCapturedBody is the largest response body that is logged. Bodies are
+// redacted whole, so larger ones are left out rather than cut short.
+const maxCapturedBody = 1 << 20
+
+const redacted = "[REDACTED]"
+
+// redactedHeaders are the headers whose values are never logged.
+var redactedHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key",
+}
+
+// redactedFields are the JSON fields whose values are never logged, wherever
+// they appear: MCP auth credentials, API keys, and tokens.
+var redactedFields = []string{
+	"credential", "password", "secretKey", "token",
+	"accessToken", "access_token", "refreshToken", "refresh_token", "apiKey", "api_key",
+}
+
+// DebugTransport is an http.RoundTripper that logs the requests it sends,
+// with credentials and secret values redacted.
+type DebugTransport struct {
+	Base    http.RoundTripper
+	Options DebugOptions
+
+	mu gosync.Mutex
+}
+
+func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	var b strings.Builder
+	if t.Options.Curl {
+		writeCurl(&b, req, reqBody)
+	}
+	if t.Options.Trace {
+		fmt.Fprintf(&b, "--> %s %s\n", req.Method, req.URL)
+		writeDebugHeaders(&b, req.Header)
+		writeDebugBody(&b, req.URL.Path, req.Header, reqBody)
+	}
+	t.write(b.String())
+	if !t.Options.Trace {
+		return t.Base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.Base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	b.Reset()
+	if err != nil {
+		fmt.Fprintf(
+			&b, "<-- %s %s failed after %s: %v\n\n", req.Method, req.URL, elapsed, err,
+		)
+		t.write(b.String())
+		return nil, err
+	}
+
+	fmt.Fprintf(&b, "<-- %s %s %s (%s)\n", resp.Status, req.Method, req.URL, elapsed)
+	writeDebugHeaders(&b, resp.Header)
+	switch {
+	case strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"):
+		b.WriteString("(streamed body not logged)\n\n")
+	case resp.ContentLength < 0 || resp.ContentLength > maxCapturedBody:
+		// Chunked bodies may never end (e.g. logs --follow), so they are
+		// passed on as they are read and logged once they have been.
+		b.WriteString("(body logged once read)\n\n")
+		resp.Body = &debugBody{ReadCloser: resp.Body, t: t, req: req, header: resp.Header}
+	default:
+		respBody, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+		writeDebugBody(&b, req.URL.Path, resp.Header, respBody)
+		if readErr != nil {
+			fmt.Fprintf(&b, "(failed to read the body: %v)\n\n", readErr)
+			rest := io.MultiReader(bytes.NewReader(respBody), errReader{readErr})
+			resp.Body = io.NopCloser(rest)
+		}
+	}
+	t.write(b.String())
+	return resp, nil
+}
+
+// debugBody passes a response body on as it is read, keeping a copy of up to
+// maxCapturedBody bytes, and logs the copy when the body ends or is closed.
+type debugBody struct {
+	io.ReadCloser
+	t      *DebugTransport
+	req    *http.Request
+	header http.Header
+
+	buf    bytes.Buffer
+	n      int
+	logged gosync.Once
+}
+
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += n
+	if room := maxCapturedBody - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	if err != nil {
+		b.log(err)
+	}
+	return n, err
+}
+
+func (b *debugBody) Close() error {
+	b.log(nil)
+	return b.ReadCloser.Close()
+}
+
+// log logs the body once; err is the error that ended it, nil when it was
+// closed before the end.
+func (b *debugBody) log(err error) {
+	b.logged.Do(func() {
+		var s strings.Builder
+		fmt.Fprintf(&s, "<-- body of %s %s (%d bytes)\n", b.req.Method, b.req.URL, b.n)
+		if b.n > b.buf.Len() {
+			fmt.Fprintf(&s, "\n(body over %d bytes not logged)\n\n", maxCapturedBody)
+		} else {
+			writeDebugBody(&s, b.req.URL.Path, b.header, b.buf.Bytes())
+		}
+		switch {
+		case err == nil:
+			s.WriteString("(closed before the end of the body)\n\n")
+		case err != io.EOF:
+			fmt.Fprintf(&s, "(failed to read the body: %v)\n\n", err)
+		}
+		b.t.write(s.String())
+	})
+}
+
+// write writes s in one piece, so the logs of concurrent requests don't
+// interleave.
+func (t *DebugTransport) write(s string) {
+	if s == "" || t.Options.Out == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = io.WriteString(t.Options.Out, s)
+}
+
+func writeDebugHeaders(b *strings.Builder, h http.Header) {
+	for _, name := range slices.Sorted(maps.Keys(h)) {
+		for _, value := range h[name] {
+			if isRedactedHeader(name) {
+				value = redacted
+			}
+			fmt.Fprintf(b, "%s: %s\n", name, value)
+		}
+	}
+}
+
+func writeDebugBody(b *strings.Builder, path string, h http.Header, body []byte) {
+	b.WriteString("\n")
+	if len(body) > 0 {
+		body = RedactBody(path, h.Get("Content-Type"), body)
+		if len(body) > maxDebugBody {
+			more := len(body) - maxDebugBody
+			fmt.Fprintf(b, "%s\n... (%d more bytes)\n", body[:maxDebugBody], more)
+		} else {
+			fmt.Fprintf(b, "%s\n", bytes.TrimRight(body, "\n"))
+		}
+		b.WriteString("\n")
+	}
+}
+
+func isRedactedHeader(name string) bool {
+	for _, h := range redactedHeaders {
+		if strings.EqualFold(name, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactBody returns body, sent to or received from path, with credentials
+// and secret values replaced by [REDACTED]. Bodies of secret routes have the
+// values of their data and value fields redacted too, and bodies of router key
+// routes their key fields. Bodies that aren't JSON are returned as they are.
+func RedactBody(path, contentType string, body []byte) []byte {
+	if contentType != "" && !strings.Contains(contentType, "json") {
+		return body
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	route := redactRoute{
+		secret:    strings.Contains(path, "/secrets"),
+		routerKey: strings.Contains(path, "/router-keys"),
+	}
+	redacted, err := json.Marshal(redactValue(v, route))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactRoute records which route-specific fields a body has redacted.
+type redactRoute struct {
+	// secret routes carry secret values in data and value fields.
+	secret bool
+	// routerKey routes return a created router key in a key field.
+	routerKey bool
+}
+
+func redactValue(v any, route redactRoute) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			switch {
+			case route.secret && k == "data":
+				if data, ok := child.(map[string]any); ok {
+					for key := range data {
+						data[key] = redacted
+					}
+					continue
+				}
+				v[k] = redacted
+			case route.secret && k == "value", route.routerKey && k == "key",
+				slices.Contains(redactedFields, k):
+				if _, ok := child.(string); ok {
+					v[k] = redacted
+					continue
+				}
+				v[k] = redactValue(child, route)
+			default:
+				v[k] = redactValue(child, route)
+			}
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redactValue(child, route)
+		}
+	}
+	return v
+}
+
+// writeCurl writes a curl command that sends req again. Tokens and API keys
+// are taken from the environment variables the CLI reads them from; cookies
+// and redacted body fields have to be filled in by hand.
+func writeCurl(b *strings.Builder, req *http.Request, body []byte) {
+	fmt.Fprintf(b, "curl -X %s %s", req.Method, shellQuote(req.URL.String()))
+	for _, name := range slices.Sorted(maps.Keys(req.Header)) {
+		for _, value := range req.Header[name] {
+			switch {
+			case strings.EqualFold(name, "Authorization") && strings.HasPrefix(value, "Bearer "):
+				b.WriteString(` -H "Authorization: Bearer $INTERACTIVE_TOKEN"`)
+			case strings.EqualFold(name, "Authorization") && strings.HasPrefix(value, "Basic "):
+				b.WriteString(` -H "Authorization: Basic ` +
+					`$(printf %s "$INTERACTIVE_API_KEY" | base64 | tr -d '\n')"`)
+			case isRedactedHeader(name):
+				b.WriteString(" -H " + shellQuote(name+": "+redacted))
+			default:
+				b.WriteString(" -H " + shellQuote(name+": "+value))
+			}
+		}
+	}
+	if len(body) > 0 {
+		body = RedactBody(req.URL.Path, req.Header.Get("Content-Type"), body)
+		b.WriteString(" --data-raw " + shellQuote(string(body)))
+	}
+	b.WriteString("\n\n")
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// errReader returns err once the body it ends has been read.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,223 @@
+package clients
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "secret data",
+			path:        "/api/v1/projects/p/secrets",
+			contentType: "application/json",
+			body:        `{"name":"api-keys","data":{"API_KEY":"s3cret"}}`,
+			want:        `{"data":{"API_KEY":"[REDACTED]"},"name":"api-keys"}`,
+		},
+		{
+			name: "secret key value",
+			path: "/api/v1/projects/p/secrets/api-keys/keys/API_KEY",
+			body: `{"value":"s3cret"}`,
+			want: `{"value":"[REDACTED]"}`,
+		},
+		{
+			name:        "mcp auth credential",
+			path:        "/api/v1/projects/p/mcps",
+			contentType: "application/json",
+			body:        `{"name":"search","auth":{"type":"bearer","credential":"tok"}}`,
+			want:        `{"auth":{"credential":"[REDACTED]","type":"bearer"},"name":"search"}`,
+		},
+		{
+			name:        "value outside secret routes",
+			path:        "/api/v1/projects/p/services",
+			contentType: "application/json",
+			body:        `{"env":[{"name":"MODE","value":"prod"}]}`,
+			want:        `{"env":[{"name":"MODE","value":"prod"}]}`,
+		},
+		{
+			name:        "created router key",
+			path:        "/api/v1/projects/p/router-keys",
+			contentType: "application/json",
+			body:        `{"id":"k1","key":"sk-abc"}`,
+			want:        `{"id":"k1","key":"[REDACTED]"}`,
+		},
+		{
+			name:        "key outside router key routes",
+			path:        "/api/v1/projects/p/services",
+			contentType: "application/json",
+			body:        `{"labels":[{"key":"team","value":"core"}]}`,
+			want:        `{"labels":[{"key":"team","value":"core"}]}`,
+		},
+		{
+			name:        "not JSON",
+			path:        "/api/v1/projects/p/secrets",
+			contentType: "text/plain",
+			body:        `password=s3cret`,
+			want:        `password=s3cret`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RedactBody(tt.path, tt.contentType, []byte(tt.body))
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("RedactBody() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDebugTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		_, _ = w.Write([]byte(`{"name":"api-keys","data":{"API_KEY":"s3cret"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	var out strings.Builder
+	client := &http.Client{Transport: &DebugTransport{
+		Base:    http.DefaultTransport,
+		Options: DebugOptions{Out: &out, Trace: true, Curl: true},
+	}}
+	req, err := http.NewRequest(
+		http.MethodPost,
+		server.URL+"/api/v1/secrets",
+		strings.NewReader(`{"data":{"API_KEY":"s3cret"}}`),
+	)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer tok")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if !strings.Contains(string(body), "s3cret") {
+		t.Errorf("response body = %q, want it passed on unredacted", body)
+	}
+
+	log := out.String()
+	for _, want := range []string{
+		`curl -X POST '` + server.URL + `/api/v1/secrets'`,
+		`-H "Authorization: Bearer $INTERACTIVE_TOKEN"`,
+		"--> POST " + server.URL + "/api/v1/secrets\n",
+		"Authorization: [REDACTED]\n",
+		"<-- 200 OK POST " + server.URL + "/api/v1/secrets",
+		"Set-Cookie: [REDACTED]\n",
+		`{"data":{"API_KEY":"[REDACTED]"},"name":"api-keys"}`,
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log doesn't contain %q:\n%s", want, log)
+		}
+	}
+	for _, leak := range []string{"s3cret", "tok\n", "session=abc"} {
+		if strings.Contains(log, leak) {
+			t.Errorf("log leaks %q:\n%s", leak, log)
+		}
+	}
+}
+
+func TestDebugTransportStreamsUnendingBodies(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte(`{"line":"first"}` + "\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(done) })
+
+	var out syncBuilder
+	client := &http.Client{Transport: &DebugTransport{
+		Base:    http.DefaultTransport,
+		Options: DebugOptions{Out: &out, Trace: true},
+	}}
+
+	type result struct {
+		line string
+		err  error
+	}
+	results := make(chan result, 1)
+	var body io.ReadCloser
+	go func() {
+		resp, err := client.Get(server.URL + "/v1/logs?follow=true")
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		body = resp.Body
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		results <- result{line: line, err: err}
+	}()
+
+	select {
+	case r := <-results:
+		if r.err != nil {
+			t.Fatalf("reading the first line: %v", r.err)
+		}
+		if r.line != `{"line":"first"}`+"\n" {
+			t.Errorf("first line = %q", r.line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first line of the stream wasn't passed on while the body was open")
+	}
+	if log := out.String(); !strings.Contains(log, "<-- 200 OK GET "+server.URL) {
+		t.Errorf("response wasn't logged before the body ended:\n%s", log)
+	}
+
+	body.Close()
+	log := out.String()
+	for _, want := range []string{
+		"<-- body of GET " + server.URL + "/v1/logs?follow=true (17 bytes)\n",
+		`{"line":"first"}`,
+		"(closed before the end of the body)",
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log doesn't contain %q:\n%s", want, log)
+		}
+	}
+}
+
+// syncBuilder is a strings.Builder safe for concurrent use.
+type syncBuilder struct {
+	mu gosync.Mutex
+	b  strings.Builder
+}
+
+func (b *syncBuilder) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuilder) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -82,22 +81,17 @@ func CollectionsPath(orgId, projectId, database string) string {
 	)
 }
 
-// collectionErr turns a non-2xx collections response into an error, preferring
-// the server's message. If the body could not be read, the read error is folded
-// into the fallback message so transient network blips don't produce empty
-// errors.
+// collectionErr turns a non-2xx collections response into an APIError,
+// preferring the server's message. If the body could not be read, the read
+// error is folded into the fallback message so transient network blips don't
+// produce empty errors.
 func collectionErr(resp *http.Response, action string) error {
 	respBody, readErr := io.ReadAll(resp.Body)
-	if msg := clients.ExtractServerMessage(respBody); msg != "" {
-		return errors.New(msg)
-	}
+	fallback := fmt.Sprintf("failed to %s: server returned %s", action, resp.Status)
 	if readErr != nil {
-		return fmt.Errorf(
-			"failed to %s: server returned %s (reading response body: %w)",
-			action, resp.Status, readErr,
-		)
+		fallback = fmt.Sprintf("%s (reading response body: %v)", fallback, readErr)
 	}
-	return fmt.Errorf("failed to %s: server returned %s", action, resp.Status)
+	return clients.NewAPIError(resp, respBody, fallback)
 }
 
 // serverMessage returns a 2xx response's server message, or turns a non-2xx
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients"
 )
 
 // SearchHit is one ranked result.
@@ -31,7 +33,8 @@ func searchBase(orgId, projectId, database, collection string) string {
 	return CollectionsPath(orgId, projectId, database) + "/" + url.PathEscape(collection)
 }
 
-// postSearch POSTs a JSON body and decodes a SearchResponse.
+// postSearch POSTs a JSON body and decodes a SearchResponse. Searches only
+// read, so they are retried like GETs.
 func (c *DeploymentClient) postSearch(
 	ctx context.Context,
 	path string,
@@ -39,6 +42,7 @@ func (c *DeploymentClient) postSearch(
 	action string,
 ) (*SearchResponse, error) {
 	var result SearchResponse
+	ctx = clients.RetrySafe(ctx)
 	if err := c.sendJSONInto(ctx, http.MethodPost, path, body, action, &result); err != nil {
 		return nil, err
 	}
@@ -107,7 +111,7 @@ func (c *DeploymentClient) SearchBatch(
 	path := searchBase(orgId, projectId, database, collection) + "/search/batch"
 	var result BatchSearchResponse
 	if err := c.sendJSONInto(
-		ctx,
+		clients.RetrySafe(ctx),
 		http.MethodPost,
 		path,
 		body,
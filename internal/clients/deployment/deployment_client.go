@@ -109,16 +109,14 @@ func NewDeploymentClient(
 	cookies []*http.Cookie,
 ) (*DeploymentClient, error) {
 	if token == "" && apiKey == "" && len(cookies) == 0 {
-		return nil, fmt.Errorf(
-			"no authentication method available: provide a token, API key, or log in",
-		)
+		return nil, clients.ErrNoCredentials
 	}
 
 	return &DeploymentClient{
 		token:      token,
 		apiKey:     apiKey,
 		cookies:    cookies,
-		httpClient: &http.Client{Timeout: timeout},
+		httpClient: clients.NewHTTPClient(timeout),
 		hostname:   hostname,
 	}, nil
 }
@@ -186,10 +184,9 @@ func (c *DeploymentClient) sendJSONRequest(
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if msg := clients.ExtractServerMessage(respBody); msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("request failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("request failed with status %s", resp.Status),
+		)
 	}
 	return respBody, nil
 }
@@ -345,10 +342,9 @@ func (c *DeploymentClient) CreateService(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("service creation failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("service creation failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -420,10 +416,9 @@ func (c *DeploymentClient) sendServiceUpdate(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("service update failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("service update failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -460,10 +455,9 @@ func (c *DeploymentClient) DeleteService(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("service deletion failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("service deletion failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -503,10 +497,9 @@ func (c *DeploymentClient) RestartService(
 		return serverMessage, nil
 	}
 
-	if serverMessage != "" {
-		return "", fmt.Errorf("%s", serverMessage)
-	}
-	return "", fmt.Errorf("service restart failed with status %s", resp.Status)
+	return "", clients.NewAPIError(
+		resp, respBody, fmt.Sprintf("service restart failed with status %s", resp.Status),
+	)
 }
 
 func (c *DeploymentClient) DeactivateService(
@@ -543,10 +536,9 @@ func (c *DeploymentClient) DeactivateService(
 		return serverMessage, nil
 	}
 
-	if serverMessage != "" {
-		return "", fmt.Errorf("%s", serverMessage)
-	}
-	return "", fmt.Errorf("service deactivate failed with status %s", resp.Status)
+	return "", clients.NewAPIError(
+		resp, respBody, fmt.Sprintf("service deactivate failed with status %s", resp.Status),
+	)
 }
 
 func (c *DeploymentClient) ActivateService(
@@ -583,10 +575,9 @@ func (c *DeploymentClient) ActivateService(
 		return serverMessage, nil
 	}
 
-	if serverMessage != "" {
-		return "", fmt.Errorf("%s", serverMessage)
-	}
-	return "", fmt.Errorf("service activate failed with status %s", resp.Status)
+	return "", clients.NewAPIError(
+		resp, respBody, fmt.Sprintf("service activate failed with status %s", resp.Status),
+	)
 }
 
 func (c *DeploymentClient) ListServices(
@@ -622,11 +613,9 @@ func (c *DeploymentClient) ListServices(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("service listing failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("service listing failed with status %s", resp.Status),
+		)
 	}
 
 	var result servicesResponse
@@ -665,11 +654,9 @@ func (c *DeploymentClient) DescribeService(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("service describe failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("service describe failed with status %s", resp.Status),
+		)
 	}
 
 	var result DescribeServiceResponse
@@ -726,10 +713,9 @@ func (c *DeploymentClient) CreateSecret(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("secret creation failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("secret creation failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -781,10 +767,9 @@ func (c *DeploymentClient) ReplaceSecret(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("secret replace failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("secret replace failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -821,10 +806,9 @@ func (c *DeploymentClient) DeleteSecret(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("secret delete failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("secret delete failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -868,10 +852,9 @@ func (c *DeploymentClient) DeleteSecretKey(
 		return serverMessage, nil
 	}
 
-	if serverMessage != "" {
-		return "", fmt.Errorf("%s", serverMessage)
-	}
-	return "", fmt.Errorf("secret key delete failed with status %s", resp.Status)
+	return "", clients.NewAPIError(
+		resp, respBody, fmt.Sprintf("secret key delete failed with status %s", resp.Status),
+	)
 }
 
 func (c *DeploymentClient) UpdateSecretKey(
@@ -924,10 +907,9 @@ func (c *DeploymentClient) UpdateSecretKey(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("secret key update failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("secret key update failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -961,11 +943,9 @@ func (c *DeploymentClient) GetSecret(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("failed to get secret: server returned %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("failed to get secret: server returned %s", resp.Status),
+		)
 	}
 
 	var raw map[string]json.RawMessage
@@ -1021,11 +1001,9 @@ func (c *DeploymentClient) ListSecrets(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("failed to list secrets: server returned %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("failed to list secrets: server returned %s", resp.Status),
+		)
 	}
 
 	var result secretsResponse
@@ -1107,11 +1085,9 @@ func (c *DeploymentClient) ListReplicas(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("replicas request failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("replicas request failed with status %s", resp.Status),
+		)
 	}
 
 	var result replicasResponse
@@ -1150,11 +1126,11 @@ func (c *DeploymentClient) DescribeReplica(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("describe replica request failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp,
+			respBody,
+			fmt.Sprintf("describe replica request failed with status %s", resp.Status),
+		)
 	}
 
 	var result ReplicaStatus
@@ -1254,11 +1230,9 @@ func (c *DeploymentClient) fetchLogs(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("logs request failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("logs request failed with status %s", resp.Status),
+		)
 	}
 
 	limit, _ := strconv.Atoi(resp.Header.Get("X-Log-Limit"))
@@ -1397,52 +1371,50 @@ type refValidationError struct {
 	Message           string  `json:"message"`
 }
 
-// fmtAgentValErr parses a 422 response from the deployment-operator
-// and returns a human-readable error message. The detail field contains either
-// a JSON array (structural/Pydantic errors) or a JSON object (reference errors).
-func fmtAgentValErr(body []byte) string {
+// agentValidationError turns a 422 response from the deployment-operator into
+// an APIError listing each invalid field. The detail field contains either a
+// JSON array (structural/Pydantic errors) or a JSON object (reference errors).
+func agentValidationError(resp *http.Response, body []byte) *clients.APIError {
 	var envelope agentValidationEnvelope
 	if err := json.Unmarshal(body, &envelope); err != nil || len(envelope.Detail) == 0 {
-		return clients.ExtractServerMessage(body)
+		return clients.NewAPIError(resp, body, "")
 	}
 
 	var inner any
 	if err := json.Unmarshal(envelope.Detail, &inner); err != nil {
-		return clients.ExtractServerMessage(body)
+		return clients.NewAPIError(resp, body, "")
 	}
 
 	switch inner.(type) {
 	case []any:
 		var fields []structValidationField
 		if err := json.Unmarshal(envelope.Detail, &fields); err != nil {
-			return clients.ExtractServerMessage(body)
+			return clients.NewAPIError(resp, body, "")
 		}
-		return formatStructErrors(fields)
+		details := make([]clients.ErrorDetail, len(fields))
+		for i, f := range fields {
+			details[i] = clients.ErrorDetail{Path: f.formatPath(), Message: f.Msg}
+		}
+		return clients.NewAPIErrorDetails(
+			resp, "Agent configuration validation failed:", details, "",
+		)
 
 	case map[string]any:
 		var ref refValidationDetail
 		if err := json.Unmarshal(envelope.Detail, &ref); err != nil {
-			return clients.ExtractServerMessage(body)
+			return clients.NewAPIError(resp, body, "")
+		}
+		details := make([]clients.ErrorDetail, len(ref.Errors))
+		for i, e := range ref.Errors {
+			details[i] = clients.ErrorDetail{Path: e.Path, Message: e.formatMessage()}
 		}
-		return formatRefErrors(ref)
+		return clients.NewAPIErrorDetails(resp, ref.Detail, details, "")
 
 	default:
-		return clients.ExtractServerMessage(body)
+		return clients.NewAPIError(resp, body, "")
 	}
 }
 
-func formatStructErrors(fields []structValidationField) string {
-	var b strings.Builder
-	b.WriteString("Agent configuration validation failed:")
-	for _, f := range fields {
-		b.WriteString("\n  - ")
-		b.WriteString(f.formatPath())
-		b.WriteString(": ")
-		b.WriteString(f.Msg)
-	}
-	return b.String()
-}
-
 func (f structValidationField) formatPath() string {
 	parts := make([]string, len(f.Loc))
 	for i, loc := range f.Loc {
@@ -1451,18 +1423,6 @@ func (f structValidationField) formatPath() string {
 	return strings.Join(parts, ".")
 }
 
-func formatRefErrors(ref refValidationDetail) string {
-	var b strings.Builder
-	b.WriteString(ref.Detail)
-	for _, e := range ref.Errors {
-		b.WriteString("\n  - ")
-		b.WriteString(e.Path)
-		b.WriteString(": ")
-		b.WriteString(e.formatMessage())
-	}
-	return b.String()
-}
-
 func (e refValidationError) formatMessage() string {
 	if e.Message != "" {
 		return e.Message
@@ -1526,16 +1486,15 @@ func (c *DeploymentClient) CreateAgent(
 	}
 
 	if resp.StatusCode == http.StatusUnprocessableEntity {
-		return "", fmt.Errorf("%s", fmtAgentValErr(respBody))
+		return "", agentValidationError(resp, respBody)
 	}
 
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("agent creation failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("agent creation failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -1601,16 +1560,15 @@ func (c *DeploymentClient) sendAgentUpdate(
 	}
 
 	if resp.StatusCode == http.StatusUnprocessableEntity {
-		return "", fmt.Errorf("%s", fmtAgentValErr(respBody))
+		return "", agentValidationError(resp, respBody)
 	}
 
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("agent update failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("agent update failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -1647,10 +1605,9 @@ func (c *DeploymentClient) DeleteAgent(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("agent deletion failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("agent deletion failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -1689,11 +1646,9 @@ func (c *DeploymentClient) ListAgents(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("agent listing failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("agent listing failed with status %s", resp.Status),
+		)
 	}
 
 	var result agentsResponse
@@ -1732,11 +1687,9 @@ func (c *DeploymentClient) DescribeAgent(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("agent describe failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("agent describe failed with status %s", resp.Status),
+		)
 	}
 
 	var result DescribeAgentResponse
@@ -1781,10 +1734,9 @@ func (c *DeploymentClient) RestartAgent(
 		return serverMessage, nil
 	}
 
-	if serverMessage != "" {
-		return "", fmt.Errorf("%s", serverMessage)
-	}
-	return "", fmt.Errorf("agent restart failed with status %s", resp.Status)
+	return "", clients.NewAPIError(
+		resp, respBody, fmt.Sprintf("agent restart failed with status %s", resp.Status),
+	)
 }
 
 func (c *DeploymentClient) DeactivateAgent(
@@ -1821,10 +1773,9 @@ func (c *DeploymentClient) DeactivateAgent(
 		return serverMessage, nil
 	}
 
-	if serverMessage != "" {
-		return "", fmt.Errorf("%s", serverMessage)
-	}
-	return "", fmt.Errorf("agent deactivate failed with status %s", resp.Status)
+	return "", clients.NewAPIError(
+		resp, respBody, fmt.Sprintf("agent deactivate failed with status %s", resp.Status),
+	)
 }
 
 func (c *DeploymentClient) ActivateAgent(
@@ -1861,10 +1812,9 @@ func (c *DeploymentClient) ActivateAgent(
 		return serverMessage, nil
 	}
 
-	if serverMessage != "" {
-		return "", fmt.Errorf("%s", serverMessage)
-	}
-	return "", fmt.Errorf("agent activate failed with status %s", resp.Status)
+	return "", clients.NewAPIError(
+		resp, respBody, fmt.Sprintf("agent activate failed with status %s", resp.Status),
+	)
 }
 
 func (c *DeploymentClient) GetAgentLogs(
@@ -1914,11 +1864,9 @@ func (c *DeploymentClient) ListCatalogAgents(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("catalog request failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("catalog request failed with status %s", resp.Status),
+		)
 	}
 
 	var result catalogAgentsResponse
@@ -1953,11 +1901,11 @@ func (c *DeploymentClient) ListCatalogAgentVersions(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("catalog versions request failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp,
+			respBody,
+			fmt.Sprintf("catalog versions request failed with status %s", resp.Status),
+		)
 	}
 
 	var result catalogVersionsResponse
@@ -2009,11 +1957,11 @@ func (c *DeploymentClient) DescribeAgentRevision(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("agent revision request failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp,
+			respBody,
+			fmt.Sprintf("agent revision request failed with status %s", resp.Status),
+		)
 	}
 
 	var result AgentRevisionResponse
@@ -2065,11 +2013,11 @@ func (c *DeploymentClient) DescribeServiceRevision(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("service revision request failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp,
+			respBody,
+			fmt.Sprintf("service revision request failed with status %s", resp.Status),
+		)
 	}
 
 	var result ServiceRevisionResponse
@@ -2100,11 +2048,9 @@ func (c *DeploymentClient) fetchRevisions(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("%s request failed with status %s", label, resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("%s request failed with status %s", label, resp.Status),
+		)
 	}
 
 	var result revisionsResponse
@@ -2229,10 +2175,9 @@ func (c *DeploymentClient) CreateDatabase(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("database creation failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("database creation failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -2296,10 +2241,9 @@ func (c *DeploymentClient) sendDatabaseUpdate(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("database update failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("database update failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -2336,10 +2280,9 @@ func (c *DeploymentClient) DeleteDatabase(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("database deletion failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("database deletion failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
@@ -2379,10 +2322,9 @@ func (c *DeploymentClient) DeactivateDatabase(
 		return serverMessage, nil
 	}
 
-	if serverMessage != "" {
-		return "", fmt.Errorf("%s", serverMessage)
-	}
-	return "", fmt.Errorf("database deactivate failed with status %s", resp.Status)
+	return "", clients.NewAPIError(
+		resp, respBody, fmt.Sprintf("database deactivate failed with status %s", resp.Status),
+	)
 }
 
 func (c *DeploymentClient) ActivateDatabase(
@@ -2419,10 +2361,9 @@ func (c *DeploymentClient) ActivateDatabase(
 		return serverMessage, nil
 	}
 
-	if serverMessage != "" {
-		return "", fmt.Errorf("%s", serverMessage)
-	}
-	return "", fmt.Errorf("database activate failed with status %s", resp.Status)
+	return "", clients.NewAPIError(
+		resp, respBody, fmt.Sprintf("database activate failed with status %s", resp.Status),
+	)
 }
 
 func (c *DeploymentClient) ListDatabases(
@@ -2458,11 +2399,11 @@ func (c *DeploymentClient) ListDatabases(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("failed to list databases: server returned %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp,
+			respBody,
+			fmt.Sprintf("failed to list databases: server returned %s", resp.Status),
+		)
 	}
 
 	var result databasesResponse
@@ -2501,11 +2442,11 @@ func (c *DeploymentClient) DescribeDatabase(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("failed to describe database: server returned %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp,
+			respBody,
+			fmt.Sprintf("failed to describe database: server returned %s", resp.Status),
+		)
 	}
 
 	var result DescribeDatabaseResponse
@@ -2560,11 +2501,9 @@ func (c *DeploymentClient) TriggerDatabaseBackup(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("trigger backup failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("trigger backup failed with status %s", resp.Status),
+		)
 	}
 
 	var result BackupOutput
@@ -2603,11 +2542,9 @@ func (c *DeploymentClient) ListDatabaseBackups(
 		if err != nil {
 			return nil, fmt.Errorf("failed to read error response: %w", err)
 		}
-		msg := clients.ExtractServerMessage(respBody)
-		if msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("failed to list backups: server returned %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("failed to list backups: server returned %s", resp.Status),
+		)
 	}
 
 	var result backupsResponse
@@ -2658,10 +2595,9 @@ func (c *DeploymentClient) RestoreDatabase(
 	serverMessage := clients.ExtractServerMessage(respBody)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if serverMessage != "" {
-			return "", fmt.Errorf("%s", serverMessage)
-		}
-		return "", fmt.Errorf("database restore failed with status %s", resp.Status)
+		return "", clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("database restore failed with status %s", resp.Status),
+		)
 	}
 
 	return serverMessage, nil
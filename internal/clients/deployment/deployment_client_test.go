@@ -275,9 +275,17 @@ func TestFormatAgentValidationError(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := fmtAgentValErr(tt.body)
-			if got != tt.want {
-				t.Errorf("fmtAgentValErr() = %q, want %q", got, tt.want)
+			resp := &http.Response{
+				StatusCode: http.StatusUnprocessableEntity,
+				Status:     "422 Unprocessable Entity",
+				Header:     http.Header{},
+			}
+			err := agentValidationError(resp, tt.body)
+			if got := err.Error(); got != tt.want {
+				t.Errorf("agentValidationError() = %q, want %q", got, tt.want)
+			}
+			if !err.Validation() {
+				t.Errorf("agentValidationError().Validation() = false, want true")
 			}
 		})
 	}
@@ -0,0 +1,119 @@
+package clients
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// APIError is a response with a non-2xx status from either API, or a
+// platform API response whose envelope reports success=false. Its text is
+// the server's message followed by its details, one per line, or a fallback
+// when the server sent no message.
+type APIError struct {
+	StatusCode int
+	// Message is the server's message; empty when the body had none.
+	Message string
+	// RequestID is the response's X-Request-Id, for support requests.
+	RequestID string
+	// Details are the schema or validation errors the server listed.
+	Details []ErrorDetail
+
+	fallback string
+}
+
+// ErrorDetail is one schema or validation error: the path of the offending
+// field and what is wrong with it.
+type ErrorDetail struct {
+	Path    string
+	Message string
+}
+
+// NewAPIError returns the error of resp, whose body was read into body.
+// fallback is its text when the body has no message; it defaults to "server
+// returned <status>".
+func NewAPIError(resp *http.Response, body []byte, fallback string) *APIError {
+	msg, details := parseServerMessage(body)
+	return NewAPIErrorDetails(resp, msg, details, fallback)
+}
+
+// NewAPIErrorDetails is NewAPIError for bodies the caller has parsed itself,
+// like the agent validation errors of the deployment API.
+func NewAPIErrorDetails(
+	resp *http.Response,
+	msg string,
+	details []ErrorDetail,
+	fallback string,
+) *APIError {
+	if fallback == "" {
+		fallback = "server returned " + resp.Status
+	}
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    msg,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+		Details:    details,
+		fallback:   fallback,
+	}
+}
+
+// envelopeStatuses maps the error codes of platform success=false envelopes
+// to the status a non-2xx response would have had.
+var envelopeStatuses = map[string]int{
+	"BAD_REQUEST":      http.StatusBadRequest,
+	"VALIDATION_ERROR": http.StatusBadRequest,
+	"SCHEMA_INVALID":   http.StatusBadRequest,
+	"UNAUTHORIZED":     http.StatusUnauthorized,
+	"FORBIDDEN":        http.StatusForbidden,
+	"NOT_FOUND":        http.StatusNotFound,
+	"CONFLICT":         http.StatusConflict,
+}
+
+// NewEnvelopeError returns the error of a 2xx platform response whose body
+// reports success=false. Its StatusCode is the one the envelope's error code
+// stands for, or 0 when the code is unknown; fallback is its text when the
+// body has no message.
+func NewEnvelopeError(body []byte, fallback string) *APIError {
+	msg, details := parseServerMessage(body)
+	var envelope platformAPIError
+	_ = json.Unmarshal(body, &envelope)
+	return &APIError{
+		StatusCode: envelopeStatuses[strings.ToUpper(envelope.Error.Code)],
+		Message:    msg,
+		Details:    details,
+		fallback:   fallback,
+	}
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return e.fallback
+	}
+	return formatDetails(e.Message, e.Details)
+}
+
+// Validation reports whether the server rejected the request's content: a
+// 400 or 422 response, or one that listed details.
+func (e *APIError) Validation() bool {
+	return e.StatusCode == http.StatusBadRequest ||
+		e.StatusCode == http.StatusUnprocessableEntity ||
+		len(e.Details) > 0
+}
+
+// formatDetails appends details to msg, one per line.
+func formatDetails(msg string, details []ErrorDetail) string {
+	if msg == "" || len(details) == 0 {
+		return msg
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, d := range details {
+		b.WriteString("\n  - ")
+		if d.Path != "" {
+			b.WriteString(d.Path)
+			b.WriteString(": ")
+		}
+		b.WriteString(d.Message)
+	}
+	return b.String()
+}
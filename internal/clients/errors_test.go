@@ -0,0 +1,136 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		fallback string
+		want     *APIError
+		wantText string
+	}{
+		{
+			name:     "server message",
+			status:   http.StatusNotFound,
+			body:     `{"message": "service \"api\" not found"}`,
+			want:     &APIError{StatusCode: 404, Message: `service "api" not found`},
+			wantText: `service "api" not found`,
+		},
+		{
+			name:   "schema errors become details",
+			status: http.StatusBadRequest,
+			body: `{"detail": {"error": {"message": "Invalid prompt", "details": ` +
+				`{"schema_errors": [{"path": "content", "message": "required"}]}}}}`,
+			want: &APIError{
+				StatusCode: 400,
+				Message:    "Invalid prompt",
+				Details:    []ErrorDetail{{Path: "content", Message: "required"}},
+			},
+			wantText: "Invalid prompt\n  - content: required",
+		},
+		{
+			name:     "empty body uses the fallback",
+			status:   http.StatusConflict,
+			fallback: "secret creation failed with status 409 Conflict",
+			want:     &APIError{StatusCode: 409},
+			wantText: "secret creation failed with status 409 Conflict",
+		},
+		{
+			name:     "default fallback",
+			status:   http.StatusForbidden,
+			want:     &APIError{StatusCode: 403},
+			wantText: "server returned 403 Forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Status:     fmt.Sprintf("%d %s", tt.status, http.StatusText(tt.status)),
+				Header:     http.Header{"X-Request-Id": {"req-1"}},
+			}
+			tt.want.RequestID = "req-1"
+
+			got := NewAPIError(resp, []byte(tt.body), tt.fallback)
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreUnexported(APIError{})); diff != "" {
+				t.Errorf("NewAPIError() mismatch (-want +got):\n%s", diff)
+			}
+			if got.Error() != tt.wantText {
+				t.Errorf("Error() = %q, want %q", got.Error(), tt.wantText)
+			}
+		})
+	}
+}
+
+func TestAPIErrorWrapped(t *testing.T) {
+	resp := &http.Response{StatusCode: 422, Status: "422 Unprocessable Entity"}
+	err := fmt.Errorf("failed to fetch schema: %w", NewAPIError(resp, nil, ""))
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("errors.As(%v) = false, want an *APIError", err)
+	}
+	if !apiErr.Validation() {
+		t.Errorf("Validation() = false, want true for a 422")
+	}
+	want := "failed to fetch schema: server returned 422 Unprocessable Entity"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestNewEnvelopeError(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantText   string
+	}{
+		{
+			name:       "known code",
+			body:       `{"success": false, "error": {"code": "NOT_FOUND", "message": "queue not found"}}`,
+			wantStatus: http.StatusNotFound,
+			wantText:   "queue not found",
+		},
+		{
+			name:       "unknown code",
+			body:       `{"success": false, "error": {"code": "INTERNAL", "message": "boom"}}`,
+			wantStatus: 0,
+			wantText:   "boom",
+		},
+		{
+			name:       "no message",
+			body:       `{"success": false}`,
+			wantStatus: 0,
+			wantText:   `{"success": false}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("assign queue: %w", NewEnvelopeError([]byte(tt.body), "fallback"))
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("errors.As(%v) = false, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, tt.wantStatus)
+			}
+			if apiErr.Error() != tt.wantText {
+				t.Errorf("Error() = %q, want %q", apiErr.Error(), tt.wantText)
+			}
+		})
+	}
+}
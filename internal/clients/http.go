@@ -3,7 +3,7 @@ package clients
 import (
 	"encoding/base64"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -60,62 +60,57 @@ type trpcError struct {
 //  4. Platform success envelope: {"data": {"message": "..."}}
 //  5. Plain text fallback
 func ExtractServerMessage(body []byte) string {
+	msg, details := parseServerMessage(body)
+	return formatDetails(msg, details)
+}
+
+// parseServerMessage is ExtractServerMessage, with the schema errors of
+// nested platform errors returned as details rather than appended to the
+// message.
+func parseServerMessage(body []byte) (string, []ErrorDetail) {
 	if len(body) == 0 {
-		return ""
+		return "", nil
 	}
 
 	var dp deploymentError
 	if err := json.Unmarshal(body, &dp); err == nil {
 		if msg := strings.TrimSpace(dp.Message); msg != "" {
-			return msg
+			return msg, nil
 		}
 	}
 
 	var pp platformError
 	if err := json.Unmarshal(body, &pp); err == nil {
-		msg := strings.TrimSpace(pp.Detail.Error.Message)
-		errs := pp.Detail.Error.Details.SchemaErrors
-
-		if msg != "" && len(errs) > 0 {
-			var b strings.Builder
-			b.WriteString(msg)
-			for _, e := range errs {
-				b.WriteString("\n  - ")
-				if e.Path != "" {
-					b.WriteString(e.Path)
-					b.WriteString(": ")
-				}
-				b.WriteString(e.Message)
+		if msg := strings.TrimSpace(pp.Detail.Error.Message); msg != "" {
+			var details []ErrorDetail
+			for _, e := range pp.Detail.Error.Details.SchemaErrors {
+				details = append(details, ErrorDetail{Path: e.Path, Message: e.Message})
 			}
-			return b.String()
-		}
-
-		if msg != "" {
-			return msg
+			return msg, details
 		}
 	}
 
 	var pa platformAPIError
 	if err := json.Unmarshal(body, &pa); err == nil && !pa.Success {
 		if msg := strings.TrimSpace(pa.Error.Message); msg != "" {
-			return msg
+			return msg, nil
 		}
 	}
 
 	var sp simpleError
 	if err := json.Unmarshal(body, &sp); err == nil {
 		if msg := strings.TrimSpace(sp.Detail); msg != "" {
-			return msg
+			return msg, nil
 		}
 	}
 
 	var te trpcError
 	if err := json.Unmarshal(body, &te); err == nil {
 		if msg := strings.TrimSpace(te.Error.JSON.Message); msg != "" {
-			return msg
+			return msg, nil
 		}
 		if msg := strings.TrimSpace(te.Error.Message); msg != "" {
-			return msg
+			return msg, nil
 		}
 	}
 
@@ -126,17 +121,19 @@ func ExtractServerMessage(body []byte) string {
 	}
 	if err := json.Unmarshal(body, &pm); err == nil {
 		if msg := strings.TrimSpace(pm.Data.Message); msg != "" {
-			return msg
+			return msg, nil
 		}
 	}
 
-	if msg := strings.TrimSpace(string(body)); msg != "" {
-		return msg
-	}
-
-	return ""
+	return strings.TrimSpace(string(body)), nil
 }
 
+// ErrNoCredentials is returned when there is no token, API key, or session to
+// authenticate with.
+var ErrNoCredentials = errors.New(
+	"no authentication method available: provide a token, API key, or log in",
+)
+
 // ApplyRequestHeaders adds authentication to an HTTP request.
 // Priority: Bearer token > API key (Basic) > session cookies.
 // Returns an error if no authentication method is available.
@@ -168,5 +165,5 @@ func ApplyRequestHeaders(
 		return nil
 	}
 
-	return fmt.Errorf("no authentication method available: provide a token, API key, or log in")
+	return ErrNoCredentials
 }
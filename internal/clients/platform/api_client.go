@@ -46,24 +46,35 @@ func NewAPIClient(
 	token string,
 	apiKey string,
 	cookies []*http.Cookie,
+) (*APIClient, error) {
+	return NewAPIClientWithContext(context.Background(), hostname, timeout, token, apiKey, cookies)
+}
+
+// NewAPIClientWithContext is NewAPIClient with a context for validating an
+// API key, which takes a request.
+func NewAPIClientWithContext(
+	ctx context.Context,
+	hostname string,
+	timeout time.Duration,
+	token string,
+	apiKey string,
+	cookies []*http.Cookie,
 ) (*APIClient, error) {
 	if token == "" && apiKey == "" && len(cookies) == 0 {
-		return nil, fmt.Errorf(
-			"no authentication method available: provide a token, API key, or log in",
-		)
+		return nil, clients.ErrNoCredentials
 	}
 
 	client := &APIClient{
 		token:        token,
 		apiKey:       apiKey,
 		cookies:      cookies,
-		httpClient:   &http.Client{Timeout: timeout},
+		httpClient:   clients.NewHTTPClient(timeout),
 		hostname:     hostname,
 		isApiKeyMode: apiKey != "" && token == "",
 	}
 
 	if client.isApiKeyMode {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 		if err := client.validateApiKey(ctx); err != nil {
 			return nil, err
@@ -141,10 +152,7 @@ func (c *APIClient) validateApiKey(ctx context.Context) error {
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		if msg := clients.ExtractServerMessage(body); msg != "" {
-			return fmt.Errorf("API key validation failed: %s", msg)
-		}
-		return fmt.Errorf("API key validation failed with status %s", resp.Status)
+		return fmt.Errorf("API key validation failed: %w", clients.NewAPIError(resp, body, ""))
 	}
 
 	c.cachedOrgId = resp.Header.Get("x-org-id")
@@ -181,10 +189,10 @@ func (c *APIClient) ListOrganizations(ctx context.Context) ([]Organization, erro
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		if msg := clients.ExtractServerMessage(body); msg != "" {
-			return nil, fmt.Errorf("failed to list organizations: %s", msg)
-		}
-		return nil, fmt.Errorf("failed to list organizations: server returned %s", resp.Status)
+		return nil, fmt.Errorf(
+			"failed to list organizations: %w",
+			clients.NewAPIError(resp, body, ""),
+		)
 	}
 
 	var payload struct {
@@ -276,10 +284,7 @@ func (c *APIClient) ListProjects(ctx context.Context, orgId string) ([]Project,
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		if msg := clients.ExtractServerMessage(body); msg != "" {
-			return nil, fmt.Errorf("failed to list projects: %s", msg)
-		}
-		return nil, fmt.Errorf("failed to list projects: server returned %s", resp.Status)
+		return nil, fmt.Errorf("failed to list projects: %w", clients.NewAPIError(resp, body, ""))
 	}
 
 	var payload struct {
@@ -1099,10 +1104,9 @@ func (c *APIClient) CreatePrompt(
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if msg := clients.ExtractServerMessage(respBody); msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("prompt creation failed with status %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("prompt creation failed with status %s", resp.Status),
+		)
 	}
 
 	var envelope promptAPIResponse
@@ -1182,10 +1186,9 @@ func (c *APIClient) ListPrompts(
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if msg := clients.ExtractServerMessage(respBody); msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("failed to list prompts: server returned %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("failed to list prompts: server returned %s", resp.Status),
+		)
 	}
 
 	var envelope promptAPIResponse
@@ -1239,10 +1242,9 @@ func (c *APIClient) GetPrompt(
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if msg := clients.ExtractServerMessage(respBody); msg != "" {
-			return nil, fmt.Errorf("%s", msg)
-		}
-		return nil, fmt.Errorf("failed to get prompt: server returned %s", resp.Status)
+		return nil, clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("failed to get prompt: server returned %s", resp.Status),
+		)
 	}
 
 	var envelope promptAPIResponse
@@ -1293,10 +1295,9 @@ func (c *APIClient) DeletePrompt(
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if msg := clients.ExtractServerMessage(respBody); msg != "" {
-			return fmt.Errorf("%s", msg)
-		}
-		return fmt.Errorf("prompt deletion failed with status %s", resp.Status)
+		return clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("prompt deletion failed with status %s", resp.Status),
+		)
 	}
 
 	return nil
@@ -1331,7 +1332,7 @@ func GetPromptSchema(
 		u.RawQuery = q.Encode()
 	}
 
-	httpClient := &http.Client{Timeout: timeout}
+	httpClient := clients.NewHTTPClient(timeout)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create schema request: %w", err)
@@ -1349,10 +1350,7 @@ func GetPromptSchema(
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if msg := clients.ExtractServerMessage(body); msg != "" {
-			return nil, fmt.Errorf("failed to fetch schema: %s", msg)
-		}
-		return nil, fmt.Errorf("failed to fetch schema: server returned %s", resp.Status)
+		return nil, fmt.Errorf("failed to fetch schema: %w", clients.NewAPIError(resp, body, ""))
 	}
 
 	// Prompt schema endpoint wraps the response in a {success, data} envelope,
@@ -1366,7 +1364,7 @@ func GetPromptSchema(
 	}
 
 	if !envelope.Success {
-		return nil, fmt.Errorf("schema endpoint returned success=false")
+		return nil, clients.NewEnvelopeError(body, "schema endpoint returned success=false")
 	}
 
 	return &envelope.Data, nil
@@ -1388,7 +1386,7 @@ func GetAgentCompatibilityMatrix(
 	}
 	u.Path = "/api/platform/v1/agents/compatibility-matrix"
 
-	httpClient := &http.Client{Timeout: timeout}
+	httpClient := clients.NewHTTPClient(timeout)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf(
@@ -1412,12 +1410,9 @@ func GetAgentCompatibilityMatrix(
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if msg := clients.ExtractServerMessage(body); msg != "" {
-			return nil, fmt.Errorf("failed to fetch compatibility matrix: %s", msg)
-		}
 		return nil, fmt.Errorf(
-			"failed to fetch compatibility matrix: server returned %s",
-			resp.Status,
+			"failed to fetch compatibility matrix: %w",
+			clients.NewAPIError(resp, body, ""),
 		)
 	}
 
@@ -1462,10 +1457,7 @@ func (c *APIClient) GetAgentSchema(
 	// Agent schema endpoint returns SchemaResponse directly, without the
 	// {success, data} envelope used by the prompt schema endpoint.
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if msg := clients.ExtractServerMessage(body); msg != "" {
-			return nil, fmt.Errorf("failed to fetch schema: %s", msg)
-		}
-		return nil, fmt.Errorf("failed to fetch schema: server returned %s", resp.Status)
+		return nil, fmt.Errorf("failed to fetch schema: %w", clients.NewAPIError(resp, body, ""))
 	}
 
 	var result SchemaResponse
@@ -1500,10 +1492,9 @@ func (c *APIClient) DeletePromptByName(
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if msg := clients.ExtractServerMessage(respBody); msg != "" {
-			return fmt.Errorf("%s", msg)
-		}
-		return fmt.Errorf("prompt deletion failed with status %s", resp.Status)
+		return clients.NewAPIError(
+			resp, respBody, fmt.Sprintf("prompt deletion failed with status %s", resp.Status),
+		)
 	}
 
 	return nil
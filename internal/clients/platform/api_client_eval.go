@@ -3,7 +3,6 @@ package platform
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -28,10 +27,9 @@ func (c *APIClient) doAndRead(req *http.Request, action string) ([]byte, error)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if msg := clients.ExtractServerMessage(body); msg != "" {
-			return nil, errors.New(msg)
-		}
-		return nil, fmt.Errorf("failed to %s: server returned %s", action, resp.Status)
+		return nil, clients.NewAPIError(
+			resp, body, fmt.Sprintf("failed to %s: server returned %s", action, resp.Status),
+		)
 	}
 
 	return body, nil
@@ -52,10 +50,7 @@ func decodeSuccess[T any](body []byte, action string) (T, error) {
 	}
 	if !envelope.Success {
 		var zero T
-		if msg := clients.ExtractServerMessage(body); msg != "" {
-			return zero, errors.New(msg)
-		}
-		return zero, fmt.Errorf("%s returned success=false", action)
+		return zero, clients.NewEnvelopeError(body, action+" returned success=false")
 	}
 	return envelope.Data, nil
 }
@@ -162,10 +157,7 @@ func checkSuccess(body []byte, action string) (string, error) {
 		return "", fmt.Errorf("%s: failed to parse response: %w", action, err)
 	}
 	if !envelope.Success {
-		if msg := clients.ExtractServerMessage(body); msg != "" {
-			return "", errors.New(msg)
-		}
-		return "", fmt.Errorf("%s returned success=false", action)
+		return "", clients.NewEnvelopeError(body, action+" returned success=false")
 	}
 	if msg := clients.ExtractServerMessage(body); msg != "" {
 		return msg, nil
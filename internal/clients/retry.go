@@ -0,0 +1,249 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how requests are retried after transient failures:
+// connection errors and 429, 502, 503, and 504 responses.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt; 0
+	// disables retries.
+	MaxRetries int
+	// BaseDelay is the wait before the first retry. It doubles with each
+	// retry, up to MaxDelay, and is jittered.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy is the policy of the clients NewHTTPClient makes. The
+// CLI sets MaxRetries from --max-retries.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+}
+
+// NewHTTPClient returns an HTTP client on DefaultTransport. It retries
+// idempotent requests with DefaultRetryPolicy, records or replays each
+// attempt with DefaultCassette, and traces each attempt as DefaultDebug
+// asks. The timeout limits each attempt, including reading the response
+// body, rather than all attempts together.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	var base http.RoundTripper = DefaultTransport
+	if DefaultCassette != nil {
+		base = DefaultCassette.Transport(base)
+	}
+	if DefaultDebug.Trace || DefaultDebug.Curl {
+		base = &DebugTransport{Base: base, Options: DefaultDebug}
+	}
+	return &http.Client{Transport: &RetryTransport{
+		Base:    base,
+		Policy:  DefaultRetryPolicy,
+		Timeout: timeout,
+	}}
+}
+
+type retrySafeKey struct{}
+
+// RetrySafe marks the requests made with ctx as safe to retry whatever their
+// method, e.g. a POST that only searches. Creates must not be marked: a
+// retry after a lost response would create the resource twice.
+func RetrySafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retrySafeKey{}, true)
+}
+
+// retryable reports whether req may be sent again: GET, HEAD, OPTIONS, PUT,
+// and DELETE requests are idempotent, and other methods are retried only
+// when marked with RetrySafe.
+func retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	safe, _ := req.Context().Value(retrySafeKey{}).(bool)
+	return safe
+}
+
+// RetryTransport is an http.RoundTripper that retries retryable requests
+// (see RetrySafe) on connection errors and on 429, 502, 503, and 504
+// responses, waiting with jittered exponential backoff or as long as the
+// response's Retry-After asks. A Retry-After longer than MaxDelay is not
+// waited out; the response is returned as it is.
+type RetryTransport struct {
+	Base   http.RoundTripper
+	Policy RetryPolicy
+	// Timeout limits each attempt; 0 means no limit.
+	Timeout time.Duration
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	maxRetries := t.Policy.MaxRetries
+	if !retryable(req) {
+		maxRetries = 0
+	}
+
+	// The body is read up front so every attempt can send it again.
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody && maxRetries > 0 {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if body != nil {
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+			attemptReq.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+		resp, err := t.send(attemptReq)
+		if ctx.Err() != nil {
+			return resp, err
+		}
+
+		wait, retry := t.backoff(attempt, resp, err)
+		if !retry || attempt >= maxRetries {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send makes one attempt, limited by the transport's timeout until the
+// response body is closed.
+func (t *RetryTransport) send(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t.Timeout <= 0 {
+		return base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.Timeout)
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && req.Context().Err() == nil {
+			return nil, &timeoutError{err: err}
+		}
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff reports whether the outcome of an attempt is worth retrying, and
+// how long to wait first.
+func (t *RetryTransport) backoff(
+	attempt int,
+	resp *http.Response,
+	err error,
+) (time.Duration, bool) {
+	if err != nil {
+		return t.jitteredDelay(attempt), true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+	default:
+		return 0, false
+	}
+
+	wait := t.jitteredDelay(attempt)
+	if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		if t.Policy.MaxDelay > 0 && after > t.Policy.MaxDelay {
+			return 0, false
+		}
+		wait = max(wait, after)
+	}
+	return wait, true
+}
+
+// jitteredDelay returns a random wait between half and all of the backoff
+// delay of the attempt.
+func (t *RetryTransport) jitteredDelay(attempt int) time.Duration {
+	d := t.Policy.BaseDelay
+	for range attempt {
+		d *= 2
+		if t.Policy.MaxDelay > 0 && d >= t.Policy.MaxDelay {
+			d = t.Policy.MaxDelay
+			break
+		}
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// parseRetryAfter parses a Retry-After header: a number of seconds or an
+// HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// cancelOnClose releases an attempt's timeout once its response body is
+// closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// timeoutError reports an attempt that ran out of time, like the error
+// http.Client returns when its Timeout is exceeded.
+type timeoutError struct {
+	err error
+}
+
+func (e *timeoutError) Error() string {
+	return "request timed out: " + e.err.Error()
+}
+
+func (e *timeoutError) Unwrap() error { return e.err }
+
+func (e *timeoutError) Timeout() bool { return true }
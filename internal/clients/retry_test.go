@@ -0,0 +1,214 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestRetryClient(maxRetries int, timeout time.Duration) *http.Client {
+	return &http.Client{Transport: &RetryTransport{
+		Policy: RetryPolicy{
+			MaxRetries: maxRetries,
+			BaseDelay:  time.Millisecond,
+			MaxDelay:   time.Second,
+		},
+		Timeout: timeout,
+	}}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		safe       bool
+		statuses   []int
+		retryAfter string
+		wantStatus int
+		wantBodies []string
+	}{
+		{
+			name:       "GET is retried until it succeeds",
+			method:     http.MethodGet,
+			statuses:   []int{503, 502, 200},
+			wantStatus: 200,
+			wantBodies: []string{"", "", ""},
+		},
+		{
+			name:       "PUT is retried with its body",
+			method:     http.MethodPut,
+			statuses:   []int{429, 200},
+			wantStatus: 200,
+			wantBodies: []string{`{"a":1}`, `{"a":1}`},
+		},
+		{
+			name:       "retries run out",
+			method:     http.MethodDelete,
+			statuses:   []int{504, 504, 504, 504},
+			wantStatus: 504,
+			wantBodies: []string{`{"a":1}`, `{"a":1}`, `{"a":1}`},
+		},
+		{
+			name:       "POST is not retried",
+			method:     http.MethodPost,
+			statuses:   []int{503, 200},
+			wantStatus: 503,
+			wantBodies: []string{`{"a":1}`},
+		},
+		{
+			name:       "POST marked safe is retried",
+			method:     http.MethodPost,
+			safe:       true,
+			statuses:   []int{503, 200},
+			wantStatus: 200,
+			wantBodies: []string{`{"a":1}`, `{"a":1}`},
+		},
+		{
+			name:       "client errors are not retried",
+			method:     http.MethodGet,
+			statuses:   []int{404, 200},
+			wantStatus: 404,
+			wantBodies: []string{""},
+		},
+		{
+			name:       "Retry-After beyond the maximum delay is not waited out",
+			method:     http.MethodGet,
+			statuses:   []int{429, 200},
+			retryAfter: "120",
+			wantStatus: 429,
+			wantBodies: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.statuses[len(bodies)-1])
+			}
+			server := httptest.NewServer(http.HandlerFunc(handler))
+			t.Cleanup(server.Close)
+
+			var body io.Reader
+			if tt.method != http.MethodGet {
+				body = strings.NewReader(`{"a":1}`)
+			}
+			ctx := context.Background()
+			if tt.safe {
+				ctx = RetrySafe(ctx)
+			}
+			req, err := http.NewRequestWithContext(ctx, tt.method, server.URL, body)
+			if err != nil {
+				t.Fatalf("NewRequest() error = %v", err)
+			}
+			// Bodies are set like the API clients set them, without GetBody.
+			req.GetBody = nil
+
+			resp, err := newTestRetryClient(2, 0).Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantBodies, bodies); diff != "" {
+				t.Errorf("request bodies mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRetryTransportTimeoutPerAttempt(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+
+	resp, err := newTestRetryClient(1, 100*time.Millisecond).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if string(body) != "ok" || attempts != 2 {
+		t.Errorf("body = %q after %d attempts, want ok after 2", body, attempts)
+	}
+}
+
+func TestRetryTransportStopsWhenCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+
+	start := time.Now()
+	_, err = newTestRetryClient(5, 0).Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Do() took %v, want it to stop when the context is done", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "3", want: 3 * time.Second, wantOK: true},
+		{value: "-1", wantOK: false},
+		{value: "Fri, 02 Jan 2026 15:04:35 GMT", want: 30 * time.Second, wantOK: true},
+		{value: "Fri, 02 Jan 2026 15:00:00 GMT", want: 0, wantOK: true},
+		{value: "soon", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf(
+					"parseRetryAfter(%q) = %v, %v, want %v, %v",
+					tt.value, got, ok, tt.want, tt.wantOK,
+				)
+			}
+		})
+	}
+}
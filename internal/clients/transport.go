@@ -0,0 +1,79 @@
+package clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TransportOptions configures how every client connects: which CAs it
+// trusts and which certificate, if any, it presents.
+type TransportOptions struct {
+	// CAFile is a PEM bundle of CAs to trust besides the system's, e.g. the
+	// CA of a TLS-inspecting proxy.
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and its key, for
+	// mutual TLS; both or neither must be set.
+	CertFile string
+	KeyFile  string
+}
+
+// DefaultTransport is the transport every client is built on: the API
+// clients, image pushes, logins, and, through NewWebsocketDialer, port
+// forwarding tunnels. The CLI sets it with NewTransport from --ca-file,
+// --client-cert, and --client-key.
+var DefaultTransport = http.DefaultTransport.(*http.Transport)
+
+// NewTransport returns a transport that goes through the proxy HTTPS_PROXY,
+// HTTP_PROXY, and NO_PROXY name, with the CAs and client certificate of opts.
+func NewTransport(opts TransportOptions) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	if opts == (TransportOptions{}) {
+		return t, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s has no PEM certificates", opts.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, errors.New("a client certificate needs both a certificate and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	t.TLSClientConfig = cfg
+	return t, nil
+}
+
+// NewWebsocketDialer returns a dialer that connects like DefaultTransport:
+// through the same proxy, with the same TLS config.
+func NewWebsocketDialer() *websocket.Dialer {
+	return &websocket.Dialer{
+		Proxy:            DefaultTransport.Proxy,
+		TLSClientConfig:  DefaultTransport.TLSClientConfig,
+		HandshakeTimeout: 45 * time.Second,
+	}
+}
@@ -0,0 +1,164 @@
+package clients
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writePEM writes a PEM block of typ to a file in dir and returns its path.
+func writePEM(t *testing.T, dir, name, typ string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+// writeClientCert writes a self-signed client certificate and its key to dir.
+func writeClientCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "iai-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	return writePEM(t, dir, "client.crt", "CERTIFICATE", der),
+		writePEM(t, dir, "client.key", "EC PRIVATE KEY", keyDER)
+}
+
+func TestNewTransport(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(handler))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	certFile, keyFile := writeClientCert(t, dir)
+
+	tests := []struct {
+		name       string
+		opts       TransportOptions
+		wantErr    string
+		wantStatus int
+	}{
+		{
+			name:    "server CA not trusted",
+			wantErr: "certificate",
+		},
+		{
+			name:       "CA bundle",
+			opts:       TransportOptions{CAFile: caFile},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "CA bundle and client certificate",
+			opts:       TransportOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewTransport(tt.opts)
+			if err != nil {
+				t.Fatalf("NewTransport() error = %v", err)
+			}
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Get() error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestNewTransportErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("failed to write ca.pem: %v", err)
+	}
+	certFile, _ := writeClientCert(t, dir)
+
+	tests := []struct {
+		name    string
+		opts    TransportOptions
+		wantErr string
+	}{
+		{
+			name:    "missing CA bundle",
+			opts:    TransportOptions{CAFile: filepath.Join(dir, "missing.pem")},
+			wantErr: "failed to read CA bundle",
+		},
+		{
+			name:    "CA bundle without certificates",
+			opts:    TransportOptions{CAFile: notPEM},
+			wantErr: "has no PEM certificates",
+		},
+		{
+			name:    "certificate without key",
+			opts:    TransportOptions{CertFile: certFile},
+			wantErr: "needs both a certificate and a key file",
+		},
+		{
+			name:    "key that isn't one",
+			opts:    TransportOptions{CertFile: certFile, KeyFile: notPEM},
+			wantErr: "failed to load client certificate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTransport(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewTransport() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
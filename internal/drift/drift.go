@@ -0,0 +1,358 @@
+// Package drift compares a stack file with the live stack and tells changes
+// made outside the CLI apart from local changes that haven't been synced.
+package drift
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
+)
+
+// Statuses of a resource and of the whole report, from best to worst.
+const (
+	InSync  = "in-sync"
+	Pending = "pending" // the stack file changed and hasn't been synced
+	Drift   = "drift"   // the live resource was changed outside the CLI
+)
+
+// cliSource is RevisionSource.Type for revisions made by iai.
+const cliSource = "cli"
+
+// Report is the drift status of every resource in a stack file or live stack.
+type Report struct {
+	StackID   string     `json:"stackId"`
+	Status    string     `json:"status"`
+	Resources []Resource `json:"resources"`
+}
+
+// Resource is the drift status of one resource.
+type Resource struct {
+	Type   string `json:"type"` // e.g. "service" or "routine"
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Change is what a sync would do: "create", "update", or "delete".
+	Change string   `json:"change,omitempty"`
+	Fields []string `json:"fields,omitempty"` // fields that differ, for updates
+	// Revision, Actor, Source, and Updated describe the latest live
+	// revision, for resource types that keep revisions (services, agents,
+	// mcps).
+	Revision int                        `json:"revision,omitempty"`
+	Actor    *deployment.RevisionActor  `json:"actor,omitempty"`
+	Source   *deployment.RevisionSource `json:"source,omitempty"`
+	Updated  string                     `json:"updated,omitempty"`
+}
+
+// Counts returns how many resources have each status.
+func (r *Report) Counts() map[string]int {
+	counts := map[string]int{}
+	for _, res := range r.Resources {
+		counts[res.Status]++
+	}
+	return counts
+}
+
+type section struct {
+	typeName string
+	diff     files.ResourceTypeDiff
+	local    []string
+	live     []string
+	// revisions lists the resource's revisions; nil for types without any.
+	revisions func(name string) ([]deployment.RevisionMeta, error)
+}
+
+// Detect compares local with live. MCP credentials are left out of the
+// comparison, since the live side never carries them. A resource that
+// differs is drift when its latest live revision was made by something
+// other than the CLI, and a pending local change otherwise, including when
+// the type keeps no revisions (databases, secrets, context items) or the
+// revision carries no source.
+func Detect(
+	ctx context.Context,
+	client *deployment.DeploymentClient,
+	orgId, projectId string,
+	local, live *files.StackConfig,
+) (*Report, error) {
+	d := files.DiffStackConfigs(local.WithoutCredentials(), live.WithoutCredentials())
+	sections := []section{{
+		typeName: "secret",
+		diff:     d.Secrets,
+		local:    slices.Collect(maps.Keys(local.Secrets)),
+		live:     slices.Collect(maps.Keys(live.Secrets)),
+	}}
+	for _, kind := range files.ContextKinds {
+		cd, ok := d.Context[kind.Section]
+		if !ok {
+			continue
+		}
+		sections = append(sections, section{
+			typeName: kind.TypeName,
+			diff:     cd,
+			local:    slices.Collect(maps.Keys(local.ContextItems(kind.Section))),
+			live:     slices.Collect(maps.Keys(live.ContextItems(kind.Section))),
+		})
+	}
+	sections = append(sections,
+		section{
+			typeName: "service",
+			diff:     d.Services,
+			local:    slices.Collect(maps.Keys(local.Services)),
+			live:     slices.Collect(maps.Keys(live.Services)),
+			revisions: func(name string) ([]deployment.RevisionMeta, error) {
+				return client.ListServiceRevisions(ctx, orgId, projectId, name)
+			},
+		},
+		section{
+			typeName: "agent",
+			diff:     d.Agents,
+			local:    slices.Collect(maps.Keys(local.Agents)),
+			live:     slices.Collect(maps.Keys(live.Agents)),
+			revisions: func(name string) ([]deployment.RevisionMeta, error) {
+				return client.ListAgentRevisions(ctx, orgId, projectId, name)
+			},
+		},
+		section{
+			typeName: "database",
+			diff:     d.Databases,
+			local:    slices.Collect(maps.Keys(local.Databases)),
+			live:     slices.Collect(maps.Keys(live.Databases)),
+		},
+		section{
+			typeName: "mcp",
+			diff:     d.Mcps,
+			local:    slices.Collect(maps.Keys(local.Mcps)),
+			live:     slices.Collect(maps.Keys(live.Mcps)),
+			revisions: func(name string) ([]deployment.RevisionMeta, error) {
+				return client.ListMcpRevisions(ctx, orgId, projectId, name)
+			},
+		},
+	)
+
+	report := &Report{StackID: d.StackID, Status: InSync}
+	for _, s := range sections {
+		resources, err := s.detect()
+		if err != nil {
+			return nil, err
+		}
+		report.Resources = append(report.Resources, resources...)
+	}
+	for _, res := range report.Resources {
+		if rank(res.Status) > rank(report.Status) {
+			report.Status = res.Status
+		}
+	}
+	return report, nil
+}
+
+func (s section) detect() ([]Resource, error) {
+	updated := make(map[string][]string)
+	for _, rc := range s.diff.Updated {
+		updated[rc.Name] = slices.Sorted(maps.Keys(rc.Changes))
+	}
+
+	var resources []Resource
+	for _, name := range union(s.local, s.live) {
+		res := Resource{Type: s.typeName, Name: name, Status: InSync}
+		fields, isUpdated := updated[name]
+		switch {
+		case slices.Contains(s.diff.Created, name):
+			res.Change = "create"
+		case slices.Contains(s.diff.Deleted, name):
+			res.Change = "delete"
+		case isUpdated:
+			res.Change, res.Fields = "update", fields
+		}
+		if res.Change != "" {
+			res.Status = Pending
+		}
+		// Only resources that exist live have revisions to attribute.
+		if res.Change != "" && res.Change != "create" && s.revisions != nil {
+			revisions, err := s.revisions(name)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"failed to list revisions of %s %q: %w", s.typeName, name, err,
+				)
+			}
+			if latest, ok := latestRevision(revisions); ok {
+				res.Revision = latest.Revision
+				res.Actor, res.Source, res.Updated = latest.Actor, latest.Source, latest.Updated
+				if latest.Source != nil && latest.Source.Type != "" &&
+					latest.Source.Type != cliSource {
+					res.Status = Drift
+				}
+			}
+		}
+		resources = append(resources, res)
+	}
+	return resources, nil
+}
+
+func latestRevision(revisions []deployment.RevisionMeta) (deployment.RevisionMeta, bool) {
+	if len(revisions) == 0 {
+		return deployment.RevisionMeta{}, false
+	}
+	latest := revisions[0]
+	for _, r := range revisions[1:] {
+		if r.Revision > latest.Revision {
+			latest = r
+		}
+	}
+	return latest, true
+}
+
+func rank(status string) int {
+	switch status {
+	case Pending:
+		return 1
+	case Drift:
+		return 2
+	}
+	return 0
+}
+
+func union(a, b []string) []string {
+	names := slices.Concat(a, b)
+	sort.Strings(names)
+	return slices.Compact(names)
+}
+
+// PrintReport prints the resources that aren't in sync and a summary line.
+func PrintReport(out io.Writer, r *Report) {
+	fmt.Fprintf(out, "Stack: %s\n", r.StackID)
+	for _, res := range r.Resources {
+		if res.Status == InSync {
+			continue
+		}
+		label := "local change pending"
+		if res.Status == Drift {
+			label = "changed outside the CLI"
+		}
+		fmt.Fprintf(out, "\n  %s %s %s: %s\n", marker(res.Change), res.Type, res.Name, label)
+		if len(res.Fields) > 0 {
+			fmt.Fprintf(out, "    fields: %s\n", strings.Join(res.Fields, ", "))
+		}
+		if res.Revision > 0 {
+			fmt.Fprintf(
+				out,
+				"    live revision %d by %s via %s (%s)\n",
+				res.Revision,
+				output.FormatRevisionActor(res.Actor),
+				output.FormatRevisionSource(res.Source),
+				output.LocalTime(res.Updated),
+			)
+		}
+	}
+
+	counts := r.Counts()
+	fmt.Fprintf(
+		out,
+		"\n%d in sync, %d with local changes pending, %d drifted.\n",
+		counts[InSync], counts[Pending], counts[Drift],
+	)
+}
+
+func marker(change string) string {
+	switch change {
+	case "create":
+		return "+"
+	case "delete":
+		return "-"
+	}
+	return "~"
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes r as a JUnit XML report: one test suite per resource
+// type and one test case per resource, failed when the resource has drifted
+// or has local changes pending (the failure type tells them apart).
+func WriteJUnit(out io.Writer, r *Report) error {
+	suites := junitSuites{Name: "iai stacks drift " + r.StackID}
+	byType := make(map[string]int)
+	for _, res := range r.Resources {
+		i, ok := byType[res.Type]
+		if !ok {
+			i = len(suites.Suites)
+			byType[res.Type] = i
+			suites.Suites = append(suites.Suites, junitSuite{Name: res.Type})
+		}
+		suite := &suites.Suites[i]
+		tc := junitCase{Name: res.Name, ClassName: r.StackID + "." + res.Type}
+		if res.Status != InSync {
+			tc.Failure = junitFailureFor(res)
+			suite.Failures++
+			suites.Failures++
+		}
+		suite.Tests++
+		suites.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+func junitFailureFor(res Resource) *junitFailure {
+	f := &junitFailure{
+		Type:    res.Status,
+		Message: fmt.Sprintf("%s %s: local change pending (%s)", res.Type, res.Name, res.Change),
+	}
+	if res.Status == Drift {
+		f.Message = fmt.Sprintf(
+			"%s %s: changed outside the CLI by %s via %s",
+			res.Type, res.Name,
+			output.FormatRevisionActor(res.Actor),
+			output.FormatRevisionSource(res.Source),
+		)
+	}
+	var text []string
+	if len(res.Fields) > 0 {
+		text = append(text, "fields: "+strings.Join(res.Fields, ", "))
+	}
+	if res.Revision > 0 {
+		text = append(text, fmt.Sprintf("live revision: %d (%s)", res.Revision, res.Updated))
+	}
+	f.Text = strings.Join(text, "\n")
+	return f
+}
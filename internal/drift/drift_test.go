@@ -0,0 +1,195 @@
+package drift
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestDeployClient(t *testing.T, handler http.HandlerFunc) *deployment.DeploymentClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := deployment.NewDeploymentClient(server.URL, 5*time.Second, "test-token", "", nil)
+	if err != nil {
+		t.Fatalf("NewDeploymentClient() error = %v", err)
+	}
+	return client
+}
+
+func testConfigs() (local, live *files.StackConfig) {
+	local = &files.StackConfig{
+		StackId: "stack-1",
+		Services: map[string]files.ServiceConfig{
+			"api":    {ServicePort: 8080, Replicas: 2},
+			"web":    {ServicePort: 3000, Replicas: 3},
+			"worker": {ServicePort: 9000},
+		},
+		Databases: map[string]files.DatabaseConfig{"db": {Instances: 2}},
+	}
+	live = &files.StackConfig{
+		StackId: "stack-1",
+		Services: map[string]files.ServiceConfig{
+			"api": {ServicePort: 8080, Replicas: 2},
+			"web": {ServicePort: 3000, Replicas: 5},
+			"old": {ServicePort: 80},
+		},
+		Databases: map[string]files.DatabaseConfig{"db": {Instances: 1}},
+	}
+	return local, live
+}
+
+func TestDetectAttributesLiveChanges(t *testing.T) {
+	const base = "/v1/organizations/o1/projects/p1/services"
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case base + "/web/revisions":
+			fmt.Fprint(w, `{"revisions":[
+				{"revision":7,"updated":"2026-03-01T10:00:00Z","actor":{"type":"user","displayName":"dana"},"source":{"type":"console"}},
+				{"revision":6,"source":{"type":"cli","version":"1.2.0"}}
+			]}`)
+		case base + "/old/revisions":
+			fmt.Fprint(w, `{"revisions":[{"revision":2,"source":{"type":"cli","version":"1.2.0"}}]}`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	local, live := testConfigs()
+	report, err := Detect(context.Background(), client, "o1", "p1", local, live)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	want := &Report{
+		StackID: "stack-1",
+		Status:  Drift,
+		Resources: []Resource{
+			{Type: "service", Name: "api", Status: InSync},
+			{
+				Type: "service", Name: "old", Status: Pending, Change: "delete",
+				Revision: 2, Source: &deployment.RevisionSource{Type: "cli", Version: "1.2.0"},
+			},
+			{
+				Type: "service", Name: "web", Status: Drift, Change: "update",
+				Fields:   []string{"replicas"},
+				Revision: 7,
+				Actor:    &deployment.RevisionActor{Type: "user", DisplayName: "dana"},
+				Source:   &deployment.RevisionSource{Type: "console"},
+				Updated:  "2026-03-01T10:00:00Z",
+			},
+			{Type: "service", Name: "worker", Status: Pending, Change: "create"},
+			{
+				Type: "database", Name: "db", Status: Pending, Change: "update",
+				Fields: []string{"instances"},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDetectIgnoresMcpCredentials(t *testing.T) {
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	mcp := files.McpConfig{
+		Type:        "external",
+		EndpointURL: "https://tools.example.com/mcp",
+		Auth:        deployment.McpAuthBody{Type: "bearer"},
+	}
+	live := &files.StackConfig{StackId: "stack-1", Mcps: map[string]files.McpConfig{"tools": mcp}}
+	mcp.Auth.Credential = "tok"
+	local := &files.StackConfig{StackId: "stack-1", Mcps: map[string]files.McpConfig{"tools": mcp}}
+
+	report, err := Detect(context.Background(), client, "o1", "p1", local, live)
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+
+	want := &Report{
+		StackID:   "stack-1",
+		Status:    InSync,
+		Resources: []Resource{{Type: "mcp", Name: "tools", Status: InSync}},
+	}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+	if local.Mcps["tools"].Auth.Credential != "tok" {
+		t.Errorf("Detect() modified the local config")
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	report := &Report{
+		StackID: "stack-1",
+		Status:  Drift,
+		Resources: []Resource{
+			{Type: "secret", Name: "creds", Status: InSync},
+			{Type: "service", Name: "api", Status: Pending, Change: "create"},
+			{
+				Type: "service", Name: "web", Status: Drift, Change: "update",
+				Fields: []string{"replicas"}, Revision: 7, Updated: "2026-03-01T10:00:00Z",
+				Actor:  &deployment.RevisionActor{Type: "api_key", DisplayName: "deployer"},
+				Source: &deployment.RevisionSource{Type: "api"},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := WriteJUnit(&out, report); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="iai stacks drift stack-1" tests="3" failures="2">
+  <testsuite name="secret" tests="1" failures="0">
+    <testcase name="creds" classname="stack-1.secret"></testcase>
+  </testsuite>
+  <testsuite name="service" tests="2" failures="2">
+    <testcase name="api" classname="stack-1.service">
+      <failure type="pending" message="service api: local change pending (create)"></failure>
+    </testcase>
+    <testcase name="web" classname="stack-1.service">
+      <failure type="drift" message="service web: changed outside the CLI by deployer (API key) via api">fields: replicas&#xA;live revision: 7 (2026-03-01T10:00:00Z)</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("JUnit mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPrintReportSummarizes(t *testing.T) {
+	var out bytes.Buffer
+	PrintReport(&out, &Report{StackID: "stack-1", Resources: []Resource{
+		{Type: "agent", Name: "chat", Status: InSync},
+		{
+			Type: "agent", Name: "triage", Status: Pending, Change: "update",
+			Fields: []string{"version"},
+		},
+	}})
+
+	for _, want := range []string{
+		"  ~ agent triage: local change pending\n    fields: version\n",
+		"1 in sync, 1 with local changes pending, 0 drifted.\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
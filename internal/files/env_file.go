@@ -3,6 +3,7 @@ package files
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -18,11 +19,16 @@ func ParseEnvFile(filePath string) (map[string]string, error) {
 	}
 	defer file.Close()
 
+	return ParseEnv(file)
+}
+
+// ParseEnv parses KEY=VALUE pairs from r using the same rules as ParseEnvFile.
+func ParseEnv(r io.Reader) (map[string]string, error) {
 	result := make(map[string]string)
 	var errors []string
 	lineNum := 0
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		lineNum++
 		line := strings.TrimSpace(scanner.Text())
@@ -0,0 +1,309 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"gopkg.in/yaml.v3"
+)
+
+// ComposeFileName is the name of the compose file WriteCompose writes.
+const ComposeFileName = "compose.yaml"
+
+// composeSecretsDir is the directory, relative to the compose file, holding
+// the env file of each secret.
+const composeSecretsDir = "secrets"
+
+// composePostgresVersion is the postgres image used for databases without a
+// postgresVersion, matching the platform's default of the latest version.
+const composePostgresVersion = "18"
+
+// composeDBCredential is the user name, password, and database name of the
+// local postgres containers.
+const composeDBCredential = "app"
+
+// Compose is a docker-compose file.
+type Compose struct {
+	Services map[string]ComposeService `yaml:"services"`
+	Volumes  map[string]struct{}       `yaml:"volumes,omitempty"`
+}
+
+// ComposeService is a service of a docker-compose file.
+type ComposeService struct {
+	Image       string              `yaml:"image"`
+	Ports       []string            `yaml:"ports,omitempty"`
+	Environment map[string]string   `yaml:"environment,omitempty"`
+	EnvFile     []string            `yaml:"env_file,omitempty"`
+	Volumes     []string            `yaml:"volumes,omitempty"`
+	DependsOn   []string            `yaml:"depends_on,omitempty"`
+	Healthcheck *ComposeHealthcheck `yaml:"healthcheck,omitempty"`
+	Deploy      *ComposeDeploy      `yaml:"deploy,omitempty"`
+}
+
+type ComposeHealthcheck struct {
+	Test        []string `yaml:"test"`
+	Interval    string   `yaml:"interval,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+}
+
+type ComposeDeploy struct {
+	Replicas int `yaml:"replicas"`
+}
+
+// BuildCompose converts the services and databases of cfg into a compose
+// file for running the stack locally:
+//   - a service keeps its image, env, and healthcheck (probed with curl, so
+//     the image needs it), runs its replicas, or autoscaling's minimum, and
+//     publishes servicePort on the same host port, or the next free one;
+//     services with several replicas publish it on random host ports;
+//   - each secretRefs entry becomes an env file, secrets/<name>.env;
+//   - a database becomes a postgres container of its postgresVersion, and a
+//     service whose env names it as a host starts after it.
+//
+// Agents and mcps don't run outside the platform and are left out.
+func BuildCompose(cfg *StackConfig) *Compose {
+	c := &Compose{Services: make(map[string]ComposeService)}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Databases)) {
+		version := cfg.Databases[name].PostgresVersion
+		if version == "" {
+			version = composePostgresVersion
+		}
+		if c.Volumes == nil {
+			c.Volumes = make(map[string]struct{})
+		}
+		c.Volumes[name+"-data"] = struct{}{}
+		c.Services[name] = ComposeService{
+			Image: "postgres:" + version,
+			Environment: map[string]string{
+				"POSTGRES_USER":     composeDBCredential,
+				"POSTGRES_PASSWORD": composeDBCredential,
+				"POSTGRES_DB":       composeDBCredential,
+			},
+			Volumes: []string{name + "-data:" + composePostgresDataDir(version)},
+			Healthcheck: &ComposeHealthcheck{
+				Test:     []string{"CMD", "pg_isready", "-U", composeDBCredential},
+				Interval: "5s",
+			},
+		}
+	}
+
+	deps := make(map[string][]string)
+	for _, ref := range StackReferences(cfg) {
+		from, ok := strings.CutPrefix(ref.From, "service/")
+		to, isDB := strings.CutPrefix(ref.To, "database/")
+		if ok && isDB && !slices.Contains(deps[from], to) {
+			deps[from] = append(deps[from], to)
+		}
+	}
+
+	hostPorts := make(map[int]bool)
+	for _, name := range slices.Sorted(maps.Keys(cfg.Services)) {
+		svc := cfg.Services[name]
+		cs := ComposeService{
+			Image:     composeImage(svc.Image),
+			DependsOn: deps[name],
+		}
+		for _, e := range svc.Env {
+			if cs.Environment == nil {
+				cs.Environment = make(map[string]string)
+			}
+			// Compose expands $ in environment values; $$ is a literal one.
+			cs.Environment[e.Name] = strings.ReplaceAll(e.Value, "$", "$$")
+		}
+		for _, ref := range svc.SecretRefs {
+			cs.EnvFile = append(cs.EnvFile, composeEnvFile(ref.SecretName))
+		}
+
+		replicas := svc.Replicas
+		if svc.Autoscaling != nil {
+			replicas = svc.Autoscaling.MinReplicas
+		}
+		if replicas > 1 {
+			cs.Deploy = &ComposeDeploy{Replicas: replicas}
+		}
+		if port := svc.ServicePort; port > 0 {
+			if replicas > 1 {
+				cs.Ports = []string{strconv.Itoa(port)}
+			} else {
+				host := port
+				for hostPorts[host] {
+					host++
+				}
+				hostPorts[host] = true
+				cs.Ports = []string{fmt.Sprintf("%d:%d", host, port)}
+			}
+		}
+
+		if hc := svc.Healthcheck; hc != nil && hc.Path != "" && svc.ServicePort > 0 {
+			url := fmt.Sprintf("http://localhost:%d%s", svc.ServicePort, hc.Path)
+			cs.Healthcheck = &ComposeHealthcheck{
+				Test:     []string{"CMD-SHELL", "curl -fsS -o /dev/null " + url + " || exit 1"},
+				Interval: "10s",
+			}
+			if hc.InitialDelaySeconds != nil {
+				cs.Healthcheck.StartPeriod = fmt.Sprintf("%ds", *hc.InitialDelaySeconds)
+			}
+		}
+		c.Services[name] = cs
+	}
+	return c
+}
+
+// composePostgresDataDir returns where the volume of a postgres container of
+// version is mounted. From 18, the image keeps its data in a versioned
+// directory under /var/lib/postgresql, and refuses to start with a volume on
+// the older /var/lib/postgresql/data.
+func composePostgresDataDir(version string) string {
+	major, _, _ := strings.Cut(version, ".")
+	if n, err := strconv.Atoi(major); err == nil && n < 18 {
+		return "/var/lib/postgresql/data"
+	}
+	return "/var/lib/postgresql"
+}
+
+// composeImage returns the reference of img for docker. Internal images are
+// referred to by name and tag, as 'iai images build' tags them locally.
+func composeImage(img deployment.ImageSpec) string {
+	ref := img.Name + ":" + img.Tag
+	if img.Repository != "" && img.Type != "internal" {
+		ref = strings.TrimSuffix(img.Repository, "/") + "/" + ref
+	}
+	return ref
+}
+
+func composeEnvFile(secret string) string {
+	return composeSecretsDir + "/" + secret + ".env"
+}
+
+// WriteCompose writes the compose file of cfg to dir, with an env file for
+// each secret its services reference. An env file that already exists is
+// left alone, so values filled in by hand survive a re-export. Otherwise:
+//   - the secret <database>-app of a database of the stack gets the
+//     credentials of its local postgres container;
+//   - with resolve, a secret cfg declares gets its values, read from its
+//     sources as in a sync; baseDir is the stack file's directory;
+//   - any other secret gets a placeholder with its keys, when known, and
+//     empty values.
+//
+// It returns a note for each env file that needs filling in.
+func WriteCompose(
+	ctx context.Context,
+	cfg *StackConfig,
+	baseDir, dir string,
+	resolve bool,
+) ([]string, error) {
+	compose := BuildCompose(cfg)
+	if err := os.MkdirAll(filepath.Join(dir, composeSecretsDir), 0o755); err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(compose)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ComposeFileName), data, 0o644); err != nil {
+		return nil, err
+	}
+
+	var secrets []string
+	for _, svc := range compose.Services {
+		for _, f := range svc.EnvFile {
+			if !slices.Contains(secrets, f) {
+				secrets = append(secrets, f)
+			}
+		}
+	}
+	slices.Sort(secrets)
+
+	var notes []string
+	for _, f := range secrets {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		if _, err := os.Stat(path); err == nil {
+			continue
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(f, composeSecretsDir+"/"), ".env")
+		values, placeholder, err := composeSecretValues(ctx, cfg, baseDir, name, resolve)
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %w", name, err)
+		}
+		var b strings.Builder
+		if placeholder {
+			fmt.Fprintf(&b, "# Placeholder for secret %q: fill in the values.\n", name)
+			notes = append(notes, fmt.Sprintf("%s is a placeholder; fill in its values", path))
+		}
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			if strings.ContainsAny(values[key], "\r\n") {
+				return nil, fmt.Errorf(
+					"secret %q: the value of %s spans several lines, which an env file can't hold",
+					name, key,
+				)
+			}
+			fmt.Fprintf(&b, "%s=%s\n", key, composeEnvValue(values[key]))
+		}
+		if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+			return nil, err
+		}
+	}
+	return notes, nil
+}
+
+// composeEnvValue returns v as written in an env file. Values compose would
+// expand, unescape, or trim are double-quoted, with $ written as $$.
+func composeEnvValue(v string) string {
+	if !strings.ContainsAny(v, "$\"'\\# \t") {
+		return v
+	}
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$").Replace(v)
+	return `"` + v + `"`
+}
+
+// composeSecretValues returns the env file values of the secret name, and
+// whether they are placeholders.
+func composeSecretValues(
+	ctx context.Context,
+	cfg *StackConfig,
+	baseDir, name string,
+	resolve bool,
+) (map[string]string, bool, error) {
+	if db, ok := strings.CutSuffix(name, "-app"); ok {
+		if _, ok := cfg.Databases[db]; ok {
+			c := composeDBCredential
+			return map[string]string{
+				"host":     db,
+				"port":     "5432",
+				"dbname":   c,
+				"username": c,
+				"user":     c,
+				"password": c,
+				"uri":      fmt.Sprintf("postgresql://%s:%s@%s:5432/%s", c, c, db, c),
+			}, false, nil
+		}
+	}
+
+	secret, declared := cfg.Secrets[name]
+	if declared && resolve {
+		values, err := secret.resolve(ctx, baseDir)
+		return values, false, err
+	}
+
+	values := make(map[string]string)
+	for _, key := range secret.Keys {
+		values[key] = ""
+	}
+	for key := range secret.Env {
+		values[key] = ""
+	}
+	return values, true, nil
+}
@@ -2,21 +2,28 @@ package files
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
 	"gopkg.in/yaml.v3"
 )
 
 type StackConfig struct {
-	Organization string                    `yaml:"organization" json:"organization"`
-	Project      string                    `yaml:"project"      json:"project"`
-	StackId      string                    `yaml:"stack-id"     json:"stackId"`
-	Services     map[string]ServiceConfig  `yaml:"services"     json:"services"`
-	Agents       map[string]AgentConfig    `yaml:"agents"       json:"agents"`
-	Databases    map[string]DatabaseConfig `yaml:"databases"    json:"databases"`
-	Mcps         map[string]McpConfig      `yaml:"mcps"         json:"mcps"`
+	Organization string                       `yaml:"organization"         json:"organization"`
+	Project      string                       `yaml:"project"              json:"project"`
+	StackId      string                       `yaml:"stack-id"             json:"stackId"`
+	Services     map[string]ServiceConfig     `yaml:"services"             json:"services"`
+	Agents       map[string]AgentConfig       `yaml:"agents"               json:"agents"`
+	Databases    map[string]DatabaseConfig    `yaml:"databases"            json:"databases"`
+	Mcps         map[string]McpConfig         `yaml:"mcps"                 json:"mcps"`
+	Secrets      map[string]SecretConfig      `yaml:"secrets,omitempty"    json:"secrets,omitempty"`
+	Routines     map[string]ContextItemConfig `yaml:"routines,omitempty"   json:"routines,omitempty"`
+	Policies     map[string]ContextItemConfig `yaml:"policies,omitempty"   json:"policies,omitempty"`
+	Variables    map[string]ContextItemConfig `yaml:"variables,omitempty"  json:"variables,omitempty"`
+	Glossaries   map[string]ContextItemConfig `yaml:"glossaries,omitempty" json:"glossaries,omitempty"`
+	Macros       map[string]ContextItemConfig `yaml:"macros,omitempty"     json:"macros,omitempty"`
+	Prompts      map[string]ContextItemConfig `yaml:"prompts,omitempty"    json:"prompts,omitempty"`
 }
 
 type ServiceConfig struct {
@@ -66,17 +73,52 @@ type McpConfig struct {
 	Headers     map[string]string      `yaml:"headers,omitempty"     json:"headers,omitempty"`
 }
 
-func LoadStackConfig(path string) (*StackConfig, error) {
+// WithoutCredentials returns a copy of c with the MCP credentials blanked.
+// Live configs never carry them, so comparisons with one leave them out.
+func (c *StackConfig) WithoutCredentials() *StackConfig {
+	stripped := *c
+	stripped.Mcps = make(map[string]McpConfig, len(c.Mcps))
+	for name, mcp := range c.Mcps {
+		mcp.Auth.Credential = ""
+		stripped.Mcps[name] = mcp
+	}
+	return &stripped
+}
+
+// LoadStackConfig loads the stack file or stack directory at path,
+// deep-merges each overlay file onto it in order, and expands ${VAR} and
+// ${VAR:-default} references from the environment. Includes (!include and
+// $ref) resolve relative to the file they appear in; other relative paths
+// inside any of the files (context item and secret env files) resolve against
+// StackBaseDir(path).
+func LoadStackConfig(path string, overlays ...string) (*StackConfig, error) {
 	if path == "" {
+		if len(overlays) > 0 {
+			return nil, fmt.Errorf("overlays require a base stack file")
+		}
 		return &StackConfig{}, nil
 	}
-	data, err := os.ReadFile(path)
+
+	root, err := loadStackNode(path, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
+	}
+	for _, overlay := range overlays {
+		node, err := loadStackNode(overlay, nil)
+		if err != nil {
+			return nil, fmt.Errorf("overlay %s: %w", overlay, err)
+		}
+		root = mergeNodes(root, node)
 	}
 
+	return decodeStackConfig(root, StackBaseDir(path))
+}
+
+// decodeStackConfig decodes a merged stack file tree, checks the rules every
+// stack file must follow, and reads context item files relative to baseDir.
+func decodeStackConfig(root *yaml.Node, baseDir string) (*StackConfig, error) {
 	var cfg StackConfig
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if err := root.Decode(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
@@ -103,6 +145,14 @@ func LoadStackConfig(path string) (*StackConfig, error) {
 		cfg.Mcps = make(map[string]McpConfig)
 	}
 
+	if err := validateSecrets(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := resolveContextFiles(&cfg, baseDir); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
@@ -217,6 +267,47 @@ func McpConfigFromDescribe(mcp *deployment.DescribeMcpResponse) McpConfig {
 	}
 }
 
+func ServiceConfigFromRevision(rev *deployment.ServiceRevisionResponse) ServiceConfig {
+	return ServiceConfig{
+		ServicePort: rev.ServicePort,
+		Image:       rev.Image,
+		Resources:   rev.Resources,
+		Env:         rev.Env,
+		SecretRefs:  rev.SecretRefs,
+		Endpoint:    rev.Endpoint != "",
+		Replicas:    rev.Replicas,
+		Autoscaling: rev.Autoscaling,
+		Healthcheck: rev.Healthcheck,
+		Schedule:    rev.Schedule,
+	}
+}
+
+func AgentConfigFromRevision(rev *deployment.AgentRevisionResponse) AgentConfig {
+	return AgentConfig{
+		Id:          rev.Id,
+		Version:     rev.Version,
+		AgentConfig: rev.AgentConfig,
+		SecretRefs:  rev.SecretRefs,
+		Endpoint:    rev.Endpoint != "",
+		Schedule:    rev.Schedule,
+		Env:         rev.Env,
+	}
+}
+
+// McpConfigFromRevision converts an mcp revision snapshot, as returned by
+// DescribeMcpRevision. Like the live spec, it never carries the credential.
+func McpConfigFromRevision(rev map[string]any) (McpConfig, error) {
+	data, err := json.Marshal(rev)
+	if err != nil {
+		return McpConfig{}, fmt.Errorf("failed to encode mcp revision: %w", err)
+	}
+	var desc deployment.DescribeMcpResponse
+	if err := json.Unmarshal(data, &desc); err != nil {
+		return McpConfig{}, fmt.Errorf("failed to decode mcp revision: %w", err)
+	}
+	return McpConfigFromDescribe(&desc), nil
+}
+
 func (s ServiceConfig) ToCreateRequest(stackId string) deployment.CreateServiceBody {
 	body := deployment.CreateServiceBody{
 		ServicePort: s.ServicePort,
@@ -306,6 +397,14 @@ func FetchLiveStack(
 	return cfg, nil
 }
 
+// MarshalStackConfig encodes cfg as a stack file LoadStackConfig reads back
+// into the same config: $ in values is escaped, so nothing in them is
+// expanded as a variable.
 func MarshalStackConfig(cfg *StackConfig) ([]byte, error) {
-	return yaml.Marshal(cfg)
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return nil, err
+	}
+	escapeNode(&doc)
+	return yaml.Marshal(&doc)
 }
@@ -730,6 +730,31 @@ func TestMcpConfigFromDescribe(t *testing.T) {
 	}
 }
 
+func TestMcpConfigFromRevision(t *testing.T) {
+	rev := map[string]any{
+		"revision":    float64(4),
+		"status":      "ready",
+		"type":        "external",
+		"endpointUrl": "https://mcp.example.com/mcp",
+		"auth":        map[string]any{"type": "bearer", "header": "Authorization"},
+		"headers":     map[string]any{"X-Team": "support"},
+	}
+
+	got, err := McpConfigFromRevision(rev)
+	if err != nil {
+		t.Fatalf("McpConfigFromRevision() error = %v", err)
+	}
+	want := McpConfig{
+		Type:        "external",
+		EndpointURL: "https://mcp.example.com/mcp",
+		Auth:        deployment.McpAuthBody{Type: "bearer", Header: "Authorization"},
+		Headers:     map[string]string{"X-Team": "support"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("McpConfigFromRevision() mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestMcpAuthYAMLOmitsEmptyFields(t *testing.T) {
 	config := StackConfig{
 		Organization: "test-org",
@@ -0,0 +1,354 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/platform"
+)
+
+// ContextItemConfig declares one context item (routine, policy, variable,
+// glossary, macro, or prompt) in a stack file. Content is given inline or
+// read from File, which is resolved relative to the stack file.
+type ContextItemConfig struct {
+	Content       string   `yaml:"content,omitempty"       json:"content,omitempty"`
+	File          string   `yaml:"file,omitempty"          json:"file,omitempty"`
+	Type          string   `yaml:"type,omitempty"          json:"type,omitempty"` // prompts only: text | chat
+	Labels        []string `yaml:"labels,omitempty"        json:"labels,omitempty"`
+	Tags          []string `yaml:"tags,omitempty"          json:"tags,omitempty"`
+	SchemaVersion string   `yaml:"schemaVersion,omitempty" json:"schemaVersion,omitempty"`
+}
+
+// ContextKind describes a context item section of a stack file and the prompt
+// route it syncs through.
+type ContextKind struct {
+	Section      string // stack file key, e.g. "routines"
+	TypeName     string // singular name, e.g. "routine"
+	RouteSegment string // typed prompt route; empty for the generic /prompts endpoint
+	ListFolder   string // prompt-type filter for the generic endpoint's list call
+}
+
+// ContextKinds lists the context item sections in the order sync applies them.
+// They all run before agents, which reference them from agentConfig.
+var ContextKinds = []ContextKind{
+	{Section: "routines", TypeName: "routine", RouteSegment: "routines"},
+	{Section: "policies", TypeName: "policy", RouteSegment: "policies"},
+	{Section: "variables", TypeName: "variable", RouteSegment: "variables"},
+	{Section: "glossaries", TypeName: "glossary", RouteSegment: "glossaries"},
+	{Section: "macros", TypeName: "macro", RouteSegment: "macros"},
+	{Section: "prompts", TypeName: "prompt", ListFolder: "prompts"},
+}
+
+// ContextItems returns the stack file's items for the given section, or nil.
+func (c *StackConfig) ContextItems(section string) map[string]ContextItemConfig {
+	switch section {
+	case "routines":
+		return c.Routines
+	case "policies":
+		return c.Policies
+	case "variables":
+		return c.Variables
+	case "glossaries":
+		return c.Glossaries
+	case "macros":
+		return c.Macros
+	case "prompts":
+		return c.Prompts
+	}
+	return nil
+}
+
+func (c *StackConfig) setContextItems(section string, items map[string]ContextItemConfig) {
+	switch section {
+	case "routines":
+		c.Routines = items
+	case "policies":
+		c.Policies = items
+	case "variables":
+		c.Variables = items
+	case "glossaries":
+		c.Glossaries = items
+	case "macros":
+		c.Macros = items
+	case "prompts":
+		c.Prompts = items
+	}
+}
+
+// resolveContextFiles reads every item's File into Content so the rest of the
+// CLI only deals with inline content.
+func resolveContextFiles(cfg *StackConfig, baseDir string) error {
+	for _, kind := range ContextKinds {
+		items := cfg.ContextItems(kind.Section)
+		for name, item := range items {
+			if item.File == "" {
+				continue
+			}
+			if item.Content != "" {
+				return fmt.Errorf(
+					"%s %q: content and file are mutually exclusive",
+					kind.TypeName, name,
+				)
+			}
+			path := item.File
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(baseDir, path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s %q content: %w", kind.TypeName, name, err)
+			}
+			item.Content = string(data)
+			item.File = ""
+			items[name] = item
+		}
+	}
+	return nil
+}
+
+func (i ContextItemConfig) ToCreateRequest(name string) platform.CreatePromptBody {
+	return platform.CreatePromptBody{
+		Name:          name,
+		Prompt:        i.Content,
+		Labels:        i.Labels,
+		Tags:          i.Tags,
+		PromptType:    i.Type,
+		SchemaVersion: i.SchemaVersion,
+	}
+}
+
+// ContextItemFromPrompt converts a live prompt version into the stack file
+// form. The server-managed "latest" label is dropped.
+func ContextItemFromPrompt(p *platform.PromptDetail, kind ContextKind) ContextItemConfig {
+	var labels []string
+	for _, l := range p.Labels {
+		if l != "latest" {
+			labels = append(labels, l)
+		}
+	}
+	item := ContextItemConfig{
+		Content:       promptContent(p.Prompt),
+		Labels:        labels,
+		Tags:          p.Tags,
+		SchemaVersion: p.SchemaVersion,
+	}
+	if kind.RouteSegment == "" {
+		item.Type = p.Type
+	}
+	return item
+}
+
+// promptContent returns the prompt as text: JSON strings are unquoted and
+// structured (chat) prompts are indented JSON.
+func promptContent(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err == nil {
+		return buf.String()
+	}
+	return string(raw)
+}
+
+// ContextItemChanged reports whether a new version is needed for local to be
+// live: the content differs, or a declared label, tag, or setting is missing.
+func ContextItemChanged(live, local ContextItemConfig) bool {
+	return len(contextItemChanges(live, local)) > 0
+}
+
+func contextItemChanges(live, local ContextItemConfig) []fieldChange {
+	var changes []fieldChange
+
+	if !sameContent(live.Content, local.Content) {
+		changes = append(changes, fieldChange{
+			path: "content",
+			old:  contentDigest(live.Content),
+			new:  contentDigest(local.Content),
+		})
+	}
+	if missing := missingValues(live.Labels, local.Labels); len(missing) > 0 {
+		changes = append(changes, fieldChange{
+			path: "labels",
+			old:  strings.Join(live.Labels, ", "),
+			new:  strings.Join(local.Labels, ", "),
+		})
+	}
+	if missing := missingValues(live.Tags, local.Tags); len(missing) > 0 {
+		changes = append(changes, fieldChange{
+			path: "tags",
+			old:  strings.Join(live.Tags, ", "),
+			new:  strings.Join(local.Tags, ", "),
+		})
+	}
+	if local.SchemaVersion != "" && local.SchemaVersion != live.SchemaVersion {
+		changes = append(changes, fieldChange{
+			path: "schemaVersion",
+			old:  live.SchemaVersion,
+			new:  local.SchemaVersion,
+		})
+	}
+	if local.Type != "" && local.Type != live.Type {
+		changes = append(changes, fieldChange{path: "type", old: live.Type, new: local.Type})
+	}
+
+	return changes
+}
+
+// sameContent compares prompt content, ignoring trailing newlines (YAML block
+// scalars add one) and JSON formatting of structured prompts.
+func sameContent(a, b string) bool {
+	a = strings.TrimRight(a, "\n")
+	b = strings.TrimRight(b, "\n")
+	if a == b {
+		return true
+	}
+	var ja, jb any
+	if json.Unmarshal([]byte(a), &ja) != nil || json.Unmarshal([]byte(b), &jb) != nil {
+		return false
+	}
+	ca, errA := json.Marshal(ja)
+	cb, errB := json.Marshal(jb)
+	return errA == nil && errB == nil && bytes.Equal(ca, cb)
+}
+
+// contentDigest identifies content in diff output without printing it.
+func contentDigest(content string) string {
+	if content == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.TrimRight(content, "\n")))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+func missingValues(have, want []string) []string {
+	set := make(map[string]bool, len(have))
+	for _, v := range have {
+		set[v] = true
+	}
+	var missing []string
+	for _, v := range want {
+		if !set[v] {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+// ListContextItemNames returns the names of the existing items of a kind.
+func ListContextItemNames(
+	ctx context.Context,
+	apiClient *platform.APIClient,
+	projectId string,
+	kind ContextKind,
+) (map[string]bool, error) {
+	result, err := apiClient.ListPrompts(
+		ctx,
+		projectId,
+		kind.RouteSegment,
+		platform.PromptListOptions{Limit: 1000, Folder: kind.ListFolder},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", kind.Section, err)
+	}
+	names := make(map[string]bool, len(result.Prompts))
+	for _, p := range result.Prompts {
+		if p.RowType != "folder" {
+			names[p.Name] = true
+		}
+	}
+	return names, nil
+}
+
+// FetchLiveContext fills live with the latest version of every context item
+// local declares. Context items aren't tagged with a stack ID, so the stack
+// file decides which ones belong to the stack; undeclared items are never
+// exported, diffed, or deleted.
+func FetchLiveContext(
+	ctx context.Context,
+	apiClient *platform.APIClient,
+	projectId string,
+	local, live *StackConfig,
+) error {
+	for _, kind := range ContextKinds {
+		declared := local.ContextItems(kind.Section)
+		if len(declared) == 0 {
+			continue
+		}
+
+		existing, err := ListContextItemNames(ctx, apiClient, projectId, kind)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(declared))
+		for name := range declared {
+			if existing[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		items := make(map[string]ContextItemConfig, len(names))
+		for _, name := range names {
+			p, err := apiClient.GetPrompt(ctx, projectId, kind.RouteSegment, name, 0, "latest")
+			if err != nil {
+				return fmt.Errorf("failed to get %s %q: %w", kind.TypeName, name, err)
+			}
+			items[name] = ContextItemFromPrompt(p, kind)
+		}
+		live.setContextItems(kind.Section, items)
+	}
+	return nil
+}
+
+// FetchReferencedContext adds to live, by name only, the existing context
+// items its agents reference from agentConfig. Context items aren't tagged
+// with a stack ID, so this is how a live stack finds out which of its
+// references resolve.
+func FetchReferencedContext(
+	ctx context.Context,
+	apiClient *platform.APIClient,
+	projectId string,
+	live *StackConfig,
+) error {
+	referenced := make(map[string][]string)
+	for _, ref := range StackReferences(live) {
+		typ, name, _ := strings.Cut(ref.To, "/")
+		referenced[typ] = append(referenced[typ], name)
+	}
+
+	for _, kind := range ContextKinds {
+		names := referenced[kind.TypeName]
+		if len(names) == 0 {
+			continue
+		}
+		existing, err := ListContextItemNames(ctx, apiClient, projectId, kind)
+		if err != nil {
+			return err
+		}
+		items := live.ContextItems(kind.Section)
+		if items == nil {
+			items = make(map[string]ContextItemConfig)
+		}
+		for _, name := range names {
+			if _, ok := items[name]; !ok && existing[name] {
+				items[name] = ContextItemConfig{}
+			}
+		}
+		live.setContextItems(kind.Section, items)
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package files
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/platform"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadStackConfigContextItems(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "routines"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	err := os.WriteFile(
+		filepath.Join(tmpDir, "routines", "greet.md"),
+		[]byte("Greet the customer by name.\n"),
+		0o600,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configFile := filepath.Join(tmpDir, "stack.yaml")
+	content := `stack-id: stack-1
+routines:
+  greet:
+    file: routines/greet.md
+    labels: [production]
+policies:
+  refunds:
+    content: Refunds over 100 EUR need approval.
+prompts:
+  system:
+    type: text
+    content: You are a support agent.
+`
+	if err := os.WriteFile(configFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadStackConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadStackConfig() error = %v", err)
+	}
+
+	if diff := cmp.Diff(map[string]ContextItemConfig{
+		"greet": {Content: "Greet the customer by name.\n", Labels: []string{"production"}},
+	}, cfg.Routines); diff != "" {
+		t.Errorf("Routines mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]ContextItemConfig{
+		"refunds": {Content: "Refunds over 100 EUR need approval."},
+	}, cfg.Policies); diff != "" {
+		t.Errorf("Policies mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]ContextItemConfig{
+		"system": {Type: "text", Content: "You are a support agent."},
+	}, cfg.Prompts); diff != "" {
+		t.Errorf("Prompts mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadStackConfigContextItemErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		errContains string
+	}{
+		{
+			name: "content and file together",
+			config: `macros:
+  sig:
+    content: inline
+    file: sig.txt
+`,
+			errContains: `macro "sig": content and file are mutually exclusive`,
+		},
+		{
+			name: "missing file",
+			config: `glossaries:
+  terms:
+    file: missing.yaml
+`,
+			errContains: `failed to read glossary "terms" content`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "stack.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadStackConfig(configFile)
+			if err == nil {
+				t.Fatal("LoadStackConfig() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("expected error containing %q, got: %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestContextItemFromPrompt(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt *platform.PromptDetail
+		kind   ContextKind
+		want   ContextItemConfig
+	}{
+		{
+			name: "typed item drops latest label and type",
+			prompt: &platform.PromptDetail{
+				Type:   "text",
+				Prompt: json.RawMessage(`"Say hello."`),
+				Labels: []string{"latest", "production"},
+				Tags:   []string{"support"},
+			},
+			kind: ContextKinds[0],
+			want: ContextItemConfig{
+				Content: "Say hello.",
+				Labels:  []string{"production"},
+				Tags:    []string{"support"},
+			},
+		},
+		{
+			name: "generic chat prompt keeps type and indents content",
+			prompt: &platform.PromptDetail{
+				Type:   "chat",
+				Prompt: json.RawMessage(`[{"role":"system","content":"Hi"}]`),
+				Labels: []string{"latest"},
+			},
+			kind: ContextKinds[len(ContextKinds)-1],
+			want: ContextItemConfig{
+				Type: "chat",
+				Content: `[
+  {
+    "role": "system",
+    "content": "Hi"
+  }
+]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ContextItemFromPrompt(tt.prompt, tt.kind)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ContextItemFromPrompt() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestContextItemChanged(t *testing.T) {
+	live := ContextItemConfig{
+		Content: `{"a": 1}`,
+		Labels:  []string{"production", "staging"},
+		Tags:    []string{"support"},
+	}
+
+	tests := []struct {
+		name  string
+		local ContextItemConfig
+		want  bool
+	}{
+		{
+			name:  "same content with trailing newline",
+			local: ContextItemConfig{Content: "{\"a\": 1}\n"},
+			want:  false,
+		},
+		{
+			name:  "same JSON with different formatting",
+			local: ContextItemConfig{Content: "{\n  \"a\": 1\n}", Labels: []string{"staging"}},
+			want:  false,
+		},
+		{
+			name:  "different content",
+			local: ContextItemConfig{Content: `{"a": 2}`},
+			want:  true,
+		},
+		{
+			name:  "declared label missing live",
+			local: ContextItemConfig{Content: `{"a": 1}`, Labels: []string{"canary"}},
+			want:  true,
+		},
+		{
+			name:  "schema version set locally",
+			local: ContextItemConfig{Content: `{"a": 1}`, SchemaVersion: "2"},
+			want:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContextItemChanged(live, tt.local); got != tt.want {
+				t.Errorf("ContextItemChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffStackConfigsContextItems(t *testing.T) {
+	live := &StackConfig{
+		StackId: "t",
+		Routines: map[string]ContextItemConfig{
+			"greet":    {Content: "Say hello."},
+			"escalate": {Content: "Hand off."},
+		},
+	}
+	local := &StackConfig{
+		StackId: "t",
+		Routines: map[string]ContextItemConfig{
+			"greet":    {Content: "Say hello."},
+			"escalate": {Content: "Hand off to a human."},
+			"farewell": {Content: "Say goodbye."},
+		},
+	}
+
+	d := DiffStackConfigs(local, live)
+
+	routines, ok := d.Context["routines"]
+	if !ok {
+		t.Fatalf("Context = %+v, want a routines section", d.Context)
+	}
+	if diff := cmp.Diff([]string{"farewell"}, routines.Created); diff != "" {
+		t.Errorf("Created mismatch (-want +got):\n%s", diff)
+	}
+	if len(routines.Updated) != 1 || routines.Updated[0].Name != "escalate" {
+		t.Errorf("Updated = %+v, want [escalate]", routines.Updated)
+	}
+	if _, ok := d.Context["policies"]; ok {
+		t.Error("Context has a policies section, want only declared sections")
+	}
+
+	var buf strings.Builder
+	if err := PrintStackDiffDetailed(&buf, local, live, d); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if !strings.Contains(got, "~ routine escalate (update)") ||
+		!strings.Contains(got, "content: sha256:") {
+		t.Errorf("output missing content digest update:\n%s", got)
+	}
+	if strings.Contains(got, "Hand off") {
+		t.Errorf("output prints content, want digests only:\n%s", got)
+	}
+}
@@ -0,0 +1,216 @@
+package files
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"gopkg.in/yaml.v3"
+)
+
+// CopyMapping rewrites the references of a stack copied to another project or
+// stack ID. Each map goes from the name in the source project to the name in
+// the target project.
+type CopyMapping struct {
+	// Images maps image repositories, e.g. a staging registry to production.
+	Images map[string]string `yaml:"images,omitempty"`
+	// Secrets maps secret names in secretRefs.
+	Secrets map[string]string `yaml:"secrets,omitempty"`
+	// Mcps renames mcps, and the agentConfig mcps entries that name them.
+	Mcps map[string]string `yaml:"mcps,omitempty"`
+}
+
+// LoadCopyMapping reads a mapping file. Unknown keys are rejected, so a typo
+// doesn't silently copy a reference unchanged.
+func LoadCopyMapping(path string) (*CopyMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping file: %w", err)
+	}
+	var m CopyMapping
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse mapping file %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Apply rewrites the image repositories, secret names, and mcp names of cfg.
+// It returns a warning for each env value that mentions a renamed mcp as a
+// host, since env values are not rewritten.
+func (m *CopyMapping) Apply(cfg *StackConfig) ([]string, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	renamedFrom := make(map[string]string)
+	for _, from := range slices.Sorted(maps.Keys(m.Mcps)) {
+		to := m.Mcps[from]
+		if _, ok := cfg.Mcps[from]; !ok {
+			return nil, fmt.Errorf("mapping renames mcp %q, which the stack doesn't have", from)
+		}
+		if _, taken := cfg.Mcps[to]; taken && m.Mcps[to] == "" {
+			return nil, fmt.Errorf("mapping renames mcp %q to %q, which already exists", from, to)
+		}
+		if other, ok := renamedFrom[to]; ok && to != "" {
+			return nil, fmt.Errorf("mapping renames both mcp %q and %q to %q", other, from, to)
+		}
+		renamedFrom[to] = from
+	}
+
+	var warnings []string
+	for _, ref := range StackReferences(cfg) {
+		name, ok := strings.CutPrefix(ref.To, "mcp/")
+		if to := m.Mcps[name]; ok && to != "" && strings.HasPrefix(ref.Via, "env ") {
+			warnings = append(warnings, fmt.Sprintf(
+				"%s: %s mentions mcp %q, which is renamed to %q; env values are not rewritten",
+				ref.From, ref.Via, name, to,
+			))
+		}
+	}
+
+	for name, svc := range cfg.Services {
+		svc.Image = m.image(svc.Image)
+		svc.SecretRefs = m.secretRefs(svc.SecretRefs)
+		cfg.Services[name] = svc
+	}
+	for name, agent := range cfg.Agents {
+		agent.SecretRefs = m.secretRefs(agent.SecretRefs)
+		m.renameAgentConfigMcps(agent.AgentConfig)
+		cfg.Agents[name] = agent
+	}
+
+	mcps := make(map[string]McpConfig, len(cfg.Mcps))
+	for name, mcp := range cfg.Mcps {
+		mcp.Image = m.image(mcp.Image)
+		mcp.SecretRefs = m.secretRefs(mcp.SecretRefs)
+		if to := m.Mcps[name]; to != "" {
+			name = to
+		}
+		mcps[name] = mcp
+	}
+	cfg.Mcps = mcps
+
+	if len(cfg.Secrets) > 0 {
+		secrets := make(map[string]SecretConfig, len(cfg.Secrets))
+		for name, secret := range cfg.Secrets {
+			if to := m.Secrets[name]; to != "" {
+				name = to
+			}
+			secrets[name] = secret
+		}
+		cfg.Secrets = secrets
+	}
+
+	return warnings, nil
+}
+
+func (m *CopyMapping) image(img deployment.ImageSpec) deployment.ImageSpec {
+	if to := m.Images[img.Repository]; to != "" {
+		img.Repository = to
+	}
+	return img
+}
+
+func (m *CopyMapping) secretRefs(refs []deployment.SecretRef) []deployment.SecretRef {
+	if len(refs) == 0 {
+		return refs
+	}
+	out := slices.Clone(refs)
+	for i, ref := range out {
+		if to := m.Secrets[ref.SecretName]; to != "" {
+			out[i].SecretName = to
+		}
+	}
+	return out
+}
+
+// renameAgentConfigMcps rewrites the id and hostname of agentConfig mcps
+// entries that name a renamed mcp.
+func (m *CopyMapping) renameAgentConfigMcps(agentConfig any) {
+	cfg, ok := agentConfig.(map[string]any)
+	if !ok {
+		return
+	}
+	entries, _ := cfg["mcps"].([]any)
+	for _, e := range entries {
+		entry, ok := e.(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"id", "hostname"} {
+			if name, _ := entry[key].(string); m.Mcps[name] != "" {
+				entry[key] = m.Mcps[name]
+			}
+		}
+	}
+}
+
+// CopyTarget is what a project a stack is copied into already has.
+type CopyTarget struct {
+	Secrets map[string]bool     // secret names
+	Images  map[string][]string // internal image names to their tags
+}
+
+// CopyWarnings lists what a copy of cfg can't bring along into target:
+// secret values, which are never read, and internal images, which live in
+// the source project's registry. Secrets cfg declares are created by the
+// sync of the copied file, so they aren't reported.
+func CopyWarnings(cfg *StackConfig, target CopyTarget) []string {
+	var warnings []string
+
+	seen := make(map[string]bool)
+	for _, ref := range StackReferences(cfg) {
+		name, ok := strings.CutPrefix(ref.To, "secret/")
+		_, declared := cfg.Secrets[name]
+		if !ok || seen[name] || declared || target.Secrets[name] {
+			continue
+		}
+		seen[name] = true
+		warnings = append(warnings, fmt.Sprintf(
+			"secret %q doesn't exist in the target project and secret values are never copied; "+
+				"create it there before the resources that reference it can start",
+			name,
+		))
+	}
+
+	checkImage := func(resource string, img deployment.ImageSpec) {
+		if img.Type != "internal" || slices.Contains(target.Images[img.Name], img.Tag) {
+			return
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"%s: image %s:%s is not in the target project's registry; push it there first",
+			resource, img.Name, img.Tag,
+		))
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Services)) {
+		checkImage("service/"+name, cfg.Services[name].Image)
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Mcps)) {
+		checkImage("mcp/"+name, cfg.Mcps[name].Image)
+	}
+
+	return warnings
+}
+
+// CredentialedMcps returns the mcps of cfg whose auth needs a credential.
+// Credentials are write-only, so a copy can't carry them over.
+func CredentialedMcps(cfg *StackConfig) []string {
+	var names []string
+	for name, mcp := range cfg.Mcps {
+		if mcp.Auth.Type != "" && !strings.EqualFold(mcp.Auth.Type, "none") &&
+			mcp.Auth.Credential == "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
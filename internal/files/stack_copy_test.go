@@ -0,0 +1,120 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/google/go-cmp/cmp"
+)
+
+func copyTestConfig() *StackConfig {
+	return &StackConfig{
+		StackId: "shop",
+		Services: map[string]ServiceConfig{
+			"api": {
+				Image: deployment.ImageSpec{
+					Type: "external", Repository: "registry.example.com/staging",
+					Name: "api", Tag: "v1",
+				},
+				Env: []deployment.EnvVar{
+					{Name: "SEARCH_URL", Value: "http://search-staging:8080"},
+				},
+				SecretRefs: []deployment.SecretRef{{SecretName: "staging-db"}},
+			},
+			"worker": {
+				Image: deployment.ImageSpec{Type: "internal", Name: "worker", Tag: "v2"},
+			},
+		},
+		Agents: map[string]AgentConfig{
+			"chat": {
+				AgentConfig: map[string]any{
+					"mcps": []any{map[string]any{"id": "search-staging"}},
+				},
+				SecretRefs: []deployment.SecretRef{{SecretName: "llm-keys"}},
+			},
+		},
+		Mcps: map[string]McpConfig{
+			"search-staging": {Type: "external", Auth: deployment.McpAuthBody{Type: "bearer"}},
+		},
+	}
+}
+
+func TestCopyMappingApply(t *testing.T) {
+	cfg := copyTestConfig()
+	m := &CopyMapping{
+		Images:  map[string]string{"registry.example.com/staging": "registry.example.com/prod"},
+		Secrets: map[string]string{"staging-db": "prod-db"},
+		Mcps:    map[string]string{"search-staging": "search"},
+	}
+
+	warnings, err := m.Apply(cfg)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	wantWarnings := []string{
+		`service/api: env SEARCH_URL mentions mcp "search-staging", which is renamed to "search"; ` +
+			"env values are not rewritten",
+	}
+	if diff := cmp.Diff(wantWarnings, warnings); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+	if got := cfg.Services["api"].Image.Repository; got != "registry.example.com/prod" {
+		t.Errorf("api image repository = %q, want registry.example.com/prod", got)
+	}
+	if got := cfg.Services["api"].SecretRefs[0].SecretName; got != "prod-db" {
+		t.Errorf("api secretRef = %q, want prod-db", got)
+	}
+	if _, ok := cfg.Mcps["search"]; !ok || len(cfg.Mcps) != 1 {
+		t.Errorf("mcps = %v, want only search", cfg.Mcps)
+	}
+	wantAgentConfig := map[string]any{"mcps": []any{map[string]any{"id": "search"}}}
+	if diff := cmp.Diff(wantAgentConfig, cfg.Agents["chat"].AgentConfig); diff != "" {
+		t.Errorf("agentConfig mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCopyMappingApplyRejectsUnknownMcp(t *testing.T) {
+	m := &CopyMapping{Mcps: map[string]string{"missing": "search"}}
+	if _, err := m.Apply(copyTestConfig()); err == nil {
+		t.Error("Apply() error = nil, want an error for an mcp the stack doesn't have")
+	}
+}
+
+func TestCopyMappingApplyRejectsCollidingMcps(t *testing.T) {
+	cfg := copyTestConfig()
+	cfg.Mcps["search-legacy"] = McpConfig{Type: "external"}
+	m := &CopyMapping{Mcps: map[string]string{
+		"search-staging": "search",
+		"search-legacy":  "search",
+	}}
+
+	_, err := m.Apply(cfg)
+	want := `mapping renames both mcp "search-legacy" and "search-staging" to "search"`
+	if err == nil || err.Error() != want {
+		t.Errorf("Apply() error = %v, want %q", err, want)
+	}
+}
+
+func TestCopyWarnings(t *testing.T) {
+	cfg := copyTestConfig()
+	cfg.Secrets = map[string]SecretConfig{"llm-keys": {Keys: []string{"OPENAI_API_KEY"}}}
+
+	got := CopyWarnings(cfg, CopyTarget{
+		Secrets: map[string]bool{},
+		Images:  map[string][]string{"worker": {"v1"}},
+	})
+
+	want := []string{
+		`secret "staging-db" doesn't exist in the target project and secret values are never ` +
+			"copied; create it there before the resources that reference it can start",
+		"service/worker: image worker:v2 is not in the target project's registry; " +
+			"push it there first",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"search-staging"}, CredentialedMcps(cfg)); diff != "" {
+		t.Errorf("CredentialedMcps() mismatch (-want +got):\n%s", diff)
+	}
+}
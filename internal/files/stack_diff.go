@@ -14,6 +14,10 @@ type StackDiff struct {
 	Agents    ResourceTypeDiff `json:"agents"`
 	Databases ResourceTypeDiff `json:"databases"`
 	Mcps      ResourceTypeDiff `json:"mcps"`
+	Secrets   ResourceTypeDiff `json:"secrets"`
+	// Context holds context item diffs keyed by section (e.g. "routines"),
+	// for the sections the local file declares.
+	Context map[string]ResourceTypeDiff `json:"context,omitempty"`
 }
 
 // ResourceTypeDiff records which resources were created, updated, or deleted.
@@ -55,18 +59,41 @@ func DiffStackConfigs(local, live *StackConfig) *StackDiff {
 		local.Databases, live.Databases,
 		func(a, b DatabaseConfig) []fieldChange { return diffFields(a, b) },
 	)
-	d.Mcps = diffResourceMap(
-		local.Mcps, live.Mcps,
-		func(a, b McpConfig) []fieldChange { return diffFields(a, b) },
-	)
+	d.Mcps = diffResourceMap(local.Mcps, live.Mcps, mcpChanges)
+	d.Secrets = diffResourceMap(local.Secrets, live.Secrets, secretChanges)
+	for _, kind := range ContextKinds {
+		items := local.ContextItems(kind.Section)
+		if len(items) == 0 {
+			continue
+		}
+		if d.Context == nil {
+			d.Context = make(map[string]ResourceTypeDiff)
+		}
+		d.Context[kind.Section] = diffResourceMap(
+			items, live.ContextItems(kind.Section), contextItemChanges,
+		)
+	}
 	return d
 }
 
+// SpecChanged reports whether local differs from live in any field, using the
+// same field-level comparison as DiffStackConfigs. Sync uses it to skip
+// updates that would only mint an identical revision.
+func SpecChanged(live, local any) bool {
+	return len(diffFields(live, local)) > 0
+}
+
 func (d *StackDiff) HasChanges() bool {
+	for _, cd := range d.Context {
+		if len(cd.Created)+len(cd.Updated)+len(cd.Deleted) > 0 {
+			return true
+		}
+	}
 	return len(d.Services.Created)+len(d.Services.Updated)+len(d.Services.Deleted)+
 		len(d.Agents.Created)+len(d.Agents.Updated)+len(d.Agents.Deleted)+
 		len(d.Databases.Created)+len(d.Databases.Updated)+len(d.Databases.Deleted)+
-		len(d.Mcps.Created)+len(d.Mcps.Updated)+len(d.Mcps.Deleted) > 0
+		len(d.Mcps.Created)+len(d.Mcps.Updated)+len(d.Mcps.Deleted)+
+		len(d.Secrets.Created)+len(d.Secrets.Updated)+len(d.Secrets.Deleted) > 0
 }
 
 func diffResourceMap[T any](
@@ -120,6 +147,24 @@ func PrintStackDiffDetailed(
 
 	fmt.Fprintf(out, "Stack: %s\n", d.StackID)
 
+	// Secrets and context items print first: sync applies them before the
+	// services and agents that reference them.
+	printSection(out, "secret", d.Secrets, func(name string) []fieldChange {
+		return secretChanges(live.Secrets[name], local.Secrets[name])
+	})
+	for _, kind := range ContextKinds {
+		cd, ok := d.Context[kind.Section]
+		if !ok {
+			continue
+		}
+		printSection(out, kind.TypeName, cd, func(name string) []fieldChange {
+			return contextItemChanges(
+				live.ContextItems(kind.Section)[name],
+				local.ContextItems(kind.Section)[name],
+			)
+		})
+	}
+
 	// Re-computes diffFields for display ordering; the sorted
 	// []fieldChange gives stable human output unlike the map in ResourceChange.
 	printSection(out, "service", d.Services, func(name string) []fieldChange {
@@ -132,7 +177,7 @@ func PrintStackDiffDetailed(
 		return diffFields(live.Databases[name], local.Databases[name])
 	})
 	printSection(out, "mcp", d.Mcps, func(name string) []fieldChange {
-		return diffFields(live.Mcps[name], local.Mcps[name])
+		return mcpChanges(live.Mcps[name], local.Mcps[name])
 	})
 
 	return nil
@@ -165,6 +210,21 @@ func printSection(
 	}
 }
 
+// mcpChanges is diffFields for mcps, with credentials shown as placeholders.
+func mcpChanges(live, local McpConfig) []fieldChange {
+	return diffFields(redactCredential(live), redactCredential(local))
+}
+
+// redactCredential replaces the credential of mcp, if it has one, with the
+// placeholder secret values get. Live configs never carry the credential, so
+// a local one always shows up as set.
+func redactCredential(mcp McpConfig) McpConfig {
+	if mcp.Auth.Credential != "" {
+		mcp.Auth.Credential = "(set)"
+	}
+	return mcp
+}
+
 // diffFields returns a sorted list of field-level changes between two config
 // values (live → local). An empty string for old means the field is new; an
 // empty string for new means the field was removed.
@@ -197,23 +257,59 @@ func diffFields(live, local any) []fieldChange {
 	return changes
 }
 
-// toFlatMap marshals a value to JSON, then flattens it to a key→value map
-// where keys are dot-separated paths (e.g. "image.tag", "resources.cpu").
+// toFlatMap converts a value to its document form, then flattens it to a
+// key→value map where keys are dot-separated paths (e.g. "image.tag",
+// "resources.cpu").
 func toFlatMap(v any) map[string]string {
-	data, err := json.Marshal(v)
+	raw, err := toDocument(v)
 	if err != nil {
 		return nil
 	}
-	var raw any
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return nil
-	}
 
 	result := make(map[string]string)
 	flatten(result, "", raw)
 	return result
 }
 
+// keyedLists names the lists of a resource whose entries are identified by a
+// field rather than their position, mapped to that field.
+var keyedLists = map[string]string{
+	"env":        "name",
+	"secretRefs": "secretName",
+}
+
+// toDocument marshals a value to JSON and back into maps and slices, with
+// keyed lists sorted by their key so reordering entries is not a change.
+func toDocument(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	if doc, ok := raw.(map[string]any); ok {
+		for field, key := range keyedLists {
+			if list, ok := doc[field].([]any); ok {
+				sort.SliceStable(list, func(i, j int) bool {
+					return listKey(list[i], key) < listKey(list[j], key)
+				})
+			}
+		}
+	}
+	return raw, nil
+}
+
+func listKey(entry any, key string) string {
+	if m, ok := entry.(map[string]any); ok {
+		s, _ := m[key].(string)
+		return s
+	}
+	return ""
+}
+
 func flatten(out map[string]string, prefix string, v any) {
 	switch val := v.(type) {
 	case map[string]any:
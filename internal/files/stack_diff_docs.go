@@ -0,0 +1,288 @@
+package files
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ResourceDocuments holds the live and local documents of a resource that a
+// StackDiff reports as changed, in the JSON form the API uses, for rendering
+// whole-resource diffs. Live is nil for a create and Local is nil for a
+// delete. Keyed lists (env by name, secretRefs by secretName) are sorted,
+// secret values and MCP credentials are replaced with placeholders, and
+// context item content with its digest.
+type ResourceDocuments struct {
+	Type   string
+	Name   string
+	Action string
+	Live   any
+	Local  any
+}
+
+// StackDiffDocuments returns the documents of every resource d reports as
+// created, updated, or deleted, in the order sync applies them.
+func StackDiffDocuments(local, live *StackConfig, d *StackDiff) ([]ResourceDocuments, error) {
+	var docs []ResourceDocuments
+
+	err := appendDocuments(&docs, "secret", d.Secrets, func(name string) (any, any, error) {
+		liveDoc, localDoc := secretDocuments(live.Secrets[name], local.Secrets[name])
+		return liveDoc, localDoc, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, kind := range ContextKinds {
+		cd, ok := d.Context[kind.Section]
+		if !ok {
+			continue
+		}
+		liveItems, localItems := live.ContextItems(kind.Section), local.ContextItems(kind.Section)
+		err := appendDocuments(&docs, kind.TypeName, cd, func(name string) (any, any, error) {
+			return contextItemDocuments(liveItems[name], localItems[name])
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sections := []struct {
+		kind        string
+		d           ResourceTypeDiff
+		live, local func(name string) any
+	}{
+		{
+			"service", d.Services,
+			func(n string) any { return live.Services[n] },
+			func(n string) any { return local.Services[n] },
+		},
+		{
+			"agent", d.Agents,
+			func(n string) any { return live.Agents[n] },
+			func(n string) any { return local.Agents[n] },
+		},
+		{
+			"database", d.Databases,
+			func(n string) any { return live.Databases[n] },
+			func(n string) any { return local.Databases[n] },
+		},
+		{
+			"mcp", d.Mcps,
+			func(n string) any { return redactCredential(live.Mcps[n]) },
+			func(n string) any { return redactCredential(local.Mcps[n]) },
+		},
+	}
+	for _, s := range sections {
+		err := appendDocuments(&docs, s.kind, s.d, func(name string) (any, any, error) {
+			return specDocuments(s.live(name), s.local(name))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// appendDocuments appends the documents of d's created, updated, and deleted
+// resources; documents returns the live and local document of a name, and
+// the side that doesn't exist is dropped.
+func appendDocuments(
+	docs *[]ResourceDocuments,
+	kind string,
+	d ResourceTypeDiff,
+	documents func(name string) (live, local any, err error),
+) error {
+	add := func(name, action string) error {
+		liveDoc, localDoc, err := documents(name)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", kind, name, err)
+		}
+		switch action {
+		case "create":
+			liveDoc = nil
+		case "delete":
+			localDoc = nil
+		}
+		*docs = append(*docs, ResourceDocuments{
+			Type: kind, Name: name, Action: action, Live: liveDoc, Local: localDoc,
+		})
+		return nil
+	}
+
+	for _, name := range d.Created {
+		if err := add(name, "create"); err != nil {
+			return err
+		}
+	}
+	for _, rc := range d.Updated {
+		if err := add(rc.Name, "update"); err != nil {
+			return err
+		}
+	}
+	for _, name := range d.Deleted {
+		if err := add(name, "delete"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func specDocuments(live, local any) (any, any, error) {
+	liveDoc, err := toDocument(live)
+	if err != nil {
+		return nil, nil, err
+	}
+	localDoc, err := toDocument(local)
+	if err != nil {
+		return nil, nil, err
+	}
+	// version is config-only and never returned by the API; see diffFields.
+	liveMap, _ := liveDoc.(map[string]any)
+	localMap, _ := localDoc.(map[string]any)
+	if _, ok := liveMap["version"]; !ok && localMap != nil {
+		delete(localMap, "version")
+	}
+	return liveDoc, localDoc, nil
+}
+
+func contextItemDocuments(live, local ContextItemConfig) (any, any, error) {
+	liveContent := contentDigest(live.Content)
+	localContent := contentDigest(local.Content)
+	if sameContent(live.Content, local.Content) {
+		localContent = liveContent
+	}
+	live.Content, live.File = liveContent, ""
+	local.Content, local.File = localContent, ""
+	return specDocuments(live, local)
+}
+
+// secretDocuments maps each key of a secret to a placeholder, so changed keys
+// show up without their values.
+func secretDocuments(live, local SecretConfig) (map[string]any, map[string]any) {
+	liveDoc := make(map[string]any, len(live.Hashes))
+	localDoc := make(map[string]any, len(local.Hashes))
+	for key, hash := range local.Hashes {
+		localDoc[key] = "(set)"
+		if liveHash, ok := live.Hashes[key]; ok && liveHash != hash {
+			localDoc[key] = "(new value)"
+		}
+	}
+	for key, hash := range live.Hashes {
+		liveDoc[key] = "(set)"
+		if localHash, ok := local.Hashes[key]; ok && localHash != hash {
+			liveDoc[key] = "(old value)"
+		}
+	}
+	return liveDoc, localDoc
+}
+
+// ResourcePatch is the RFC 6902 JSON Patch that turns the live document of a
+// resource into the local one.
+type ResourcePatch struct {
+	Type   string    `json:"type"`
+	Name   string    `json:"name"`
+	Action string    `json:"action"`
+	Patch  []PatchOp `json:"patch"`
+}
+
+// PatchOp is a single JSON Patch operation.
+type PatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// MarshalJSON omits the value of remove operations, which have none.
+func (p PatchOp) MarshalJSON() ([]byte, error) {
+	if p.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{p.Op, p.Path})
+	}
+	type op PatchOp
+	return json.Marshal(op(p))
+}
+
+// StackDiffPatches returns a JSON Patch per changed resource of docs. A
+// created resource is a single add of the whole document; a deleted one has
+// no operations, since the resource itself is removed.
+func StackDiffPatches(docs []ResourceDocuments) []ResourcePatch {
+	patches := make([]ResourcePatch, 0, len(docs))
+	for _, doc := range docs {
+		p := ResourcePatch{Type: doc.Type, Name: doc.Name, Action: doc.Action, Patch: []PatchOp{}}
+		switch doc.Action {
+		case "create":
+			p.Patch = append(p.Patch, PatchOp{Op: "add", Path: "", Value: doc.Local})
+		case "update":
+			p.Patch = diffDocuments(p.Patch, "", doc.Live, doc.Local)
+		}
+		patches = append(patches, p)
+	}
+	return patches
+}
+
+// diffDocuments appends the operations that turn from into to at path. Lists
+// are compared by position; keyed lists are already sorted by their key.
+func diffDocuments(ops []PatchOp, path string, from, to any) []PatchOp {
+	switch f := from.(type) {
+	case map[string]any:
+		t, ok := to.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(f)+len(t))
+		for k := range f {
+			keys = append(keys, k)
+		}
+		for k := range t {
+			if _, ok := f[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := path + "/" + escapePointer(k)
+			fv, inFrom := f[k]
+			tv, inTo := t[k]
+			switch {
+			case !inTo:
+				ops = append(ops, PatchOp{Op: "remove", Path: child})
+			case !inFrom:
+				ops = append(ops, PatchOp{Op: "add", Path: child, Value: tv})
+			default:
+				ops = diffDocuments(ops, child, fv, tv)
+			}
+		}
+		return ops
+	case []any:
+		t, ok := to.([]any)
+		if !ok {
+			break
+		}
+		common := min(len(f), len(t))
+		for i := 0; i < common; i++ {
+			ops = diffDocuments(ops, fmt.Sprintf("%s/%d", path, i), f[i], t[i])
+		}
+		for i := common; i < len(t); i++ {
+			ops = append(ops, PatchOp{Op: "add", Path: fmt.Sprintf("%s/%d", path, i), Value: t[i]})
+		}
+		// Remove from the end so earlier indexes stay valid.
+		for i := len(f) - 1; i >= common; i-- {
+			ops = append(ops, PatchOp{Op: "remove", Path: fmt.Sprintf("%s/%d", path, i)})
+		}
+		return ops
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		ops = append(ops, PatchOp{Op: "replace", Path: path, Value: to})
+	}
+	return ops
+}
+
+// escapePointer escapes a key for use in a JSON Pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
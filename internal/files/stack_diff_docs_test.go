@@ -0,0 +1,173 @@
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffStackConfigsIgnoresKeyedListOrder(t *testing.T) {
+	live := &StackConfig{
+		StackId: "t",
+		Services: map[string]ServiceConfig{"api": {
+			Env: []deployment.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}},
+			SecretRefs: []deployment.SecretRef{
+				{SecretName: "db"}, {SecretName: "api-keys"},
+			},
+		}},
+	}
+	local := &StackConfig{
+		StackId: "t",
+		Services: map[string]ServiceConfig{"api": {
+			Env: []deployment.EnvVar{{Name: "B", Value: "2"}, {Name: "A", Value: "1"}},
+			SecretRefs: []deployment.SecretRef{
+				{SecretName: "api-keys"}, {SecretName: "db"},
+			},
+		}},
+	}
+
+	if d := DiffStackConfigs(local, live); d.HasChanges() {
+		t.Errorf("HasChanges() = true for reordered env and secretRefs, want false: %+v", d)
+	}
+}
+
+func TestStackDiffPatches(t *testing.T) {
+	live := &StackConfig{
+		StackId: "t",
+		Services: map[string]ServiceConfig{
+			"api": {
+				ServicePort: 8080,
+				Replicas:    1,
+				Env: []deployment.EnvVar{
+					{Name: "MODE", Value: "a"}, {Name: "OLD", Value: "x"},
+				},
+			},
+			"gone": {ServicePort: 80},
+		},
+		Secrets: map[string]SecretConfig{
+			"creds": {Hashes: map[string]string{"TOKEN": "h1", "USER": "h2"}},
+		},
+	}
+	local := &StackConfig{
+		StackId: "t",
+		Services: map[string]ServiceConfig{
+			"api": {
+				Version:     "v2",
+				ServicePort: 8080,
+				Replicas:    2,
+				Env: []deployment.EnvVar{
+					{Name: "MODE", Value: "b"}, {Name: "NEW/PATH", Value: "y"},
+					{Name: "OLD", Value: "x"},
+				},
+			},
+			"web": {ServicePort: 3000},
+		},
+		Secrets: map[string]SecretConfig{
+			"creds": {Hashes: map[string]string{"TOKEN": "h3", "USER": "h2"}},
+		},
+	}
+
+	docs, err := StackDiffDocuments(local, live, DiffStackConfigs(local, live))
+	if err != nil {
+		t.Fatalf("StackDiffDocuments() error = %v", err)
+	}
+	data, err := json.Marshal(StackDiffPatches(docs))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var got any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	var want any
+	if err := json.Unmarshal([]byte(`[
+		{"type": "secret", "name": "creds", "action": "update", "patch": [
+			{"op": "replace", "path": "/TOKEN", "value": "(new value)"}
+		]},
+		{"type": "service", "name": "web", "action": "create", "patch": [
+			{"op": "add", "path": "", "value": {
+				"servicePort": 3000,
+				"image": {"type": "", "name": "", "tag": ""},
+				"resources": {"memory": "", "cpu": ""}
+			}}
+		]},
+		{"type": "service", "name": "api", "action": "update", "patch": [
+			{"op": "replace", "path": "/env/0/value", "value": "b"},
+			{"op": "replace", "path": "/env/1/name", "value": "NEW/PATH"},
+			{"op": "replace", "path": "/env/1/value", "value": "y"},
+			{"op": "add", "path": "/env/2", "value": {"name": "OLD", "value": "x"}},
+			{"op": "replace", "path": "/replicas", "value": 2}
+		]},
+		{"type": "service", "name": "gone", "action": "delete", "patch": []}
+	]`), &want); err != nil {
+		t.Fatalf("bad want: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("patches mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEscapePointer(t *testing.T) {
+	if got, want := escapePointer("a/b~c"), "a~1b~0c"; got != want {
+		t.Errorf("escapePointer() = %q, want %q", got, want)
+	}
+}
+
+func TestStackDiffRedactsMcpCredentials(t *testing.T) {
+	mcp := McpConfig{
+		Type:        "external",
+		EndpointURL: "https://tools.example.com/mcp",
+		Auth:        deployment.McpAuthBody{Type: "bearer"},
+	}
+	live := &StackConfig{StackId: "t", Mcps: map[string]McpConfig{"tools": mcp}}
+	mcp.Auth.Credential = "tok-secret"
+	local := &StackConfig{
+		StackId: "t",
+		Mcps:    map[string]McpConfig{"tools": mcp, "search": mcp},
+	}
+
+	d := DiffStackConfigs(local, live)
+	docs, err := StackDiffDocuments(local, live, d)
+	if err != nil {
+		t.Fatalf("StackDiffDocuments() error = %v", err)
+	}
+	patches, err := json.Marshal(StackDiffPatches(docs))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var detailed bytes.Buffer
+	if err := PrintStackDiffDetailed(&detailed, local, live, d); err != nil {
+		t.Fatalf("PrintStackDiffDetailed() error = %v", err)
+	}
+	summary, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	for name, out := range map[string]string{
+		"json-patch": string(patches),
+		"detailed":   detailed.String(),
+		"json":       string(summary),
+	} {
+		if strings.Contains(out, "tok-secret") {
+			t.Errorf("%s output contains the credential:\n%s", name, out)
+		}
+		if !strings.Contains(out, "(set)") {
+			t.Errorf("%s output doesn't show the credential as set:\n%s", name, out)
+		}
+	}
+	for _, doc := range docs {
+		data, err := json.Marshal(doc.Local)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		if strings.Contains(string(data), "tok-secret") {
+			t.Errorf("local document of mcp %s contains the credential: %s", doc.Name, data)
+		}
+	}
+}
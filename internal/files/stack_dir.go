@@ -0,0 +1,372 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// stackDirFiles are the names, in order of preference, of the file holding
+// the top-level fields of a stack directory.
+var stackDirFiles = []string{"stack.yaml", "stack.yml"}
+
+// stackFileExts are the extensions of the files a stack directory reads, and
+// of the included files parsed as YAML rather than read as text.
+var stackFileExts = []string{".yaml", ".yml", ".json"}
+
+// stackDirSections returns the sections of a stack directory that hold a
+// file per entry, in the order they are read.
+func stackDirSections() []string {
+	sections := []string{"secrets"}
+	for _, kind := range ContextKinds {
+		sections = append(sections, kind.Section)
+	}
+	return append(sections, "services", "agents", "databases", "mcps")
+}
+
+// StackBaseDir returns the directory relative paths of the stack file or
+// directory at path resolve against: the stack file's directory, or the
+// stack directory itself.
+func StackBaseDir(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return path
+	}
+	return filepath.Dir(path)
+}
+
+// loadStackDir reads a stack directory: the top-level fields from
+// stack.yaml, if there is one, and each entry of a section from its own file
+// in the section's directory, named after the entry, e.g. agents/support.yaml
+// for the agent "support". Files with other extensions and subdirectories
+// are skipped, so content files can sit next to the entries that include
+// them, and included YAML and JSON files in a subdirectory. An entry defined
+// both in stack.yaml and in a file of its own is an error, as is an entry
+// file another file includes.
+func loadStackDir(dir string, origins map[*yaml.Node]string) (*yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	rootFile := dir
+	for _, name := range stackDirFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		var err error
+		if root, err = loadYAMLFile(path, nil, origins); err != nil {
+			return nil, err
+		}
+		rootFile = path
+		break
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: the top level must be a mapping", rootFile)
+	}
+	if rootFile == dir {
+		recordOrigin(root, dir, origins)
+	}
+
+	included, err := stackDirIncludes(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, section := range stackDirSections() {
+		entries, err := os.ReadDir(filepath.Join(dir, section))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stack directory: %w", err)
+		}
+
+		var sectionNode *yaml.Node
+		defined := make(map[string]string)
+		if i := mappingIndex(root, section); i >= 0 {
+			sectionNode = root.Content[i+1]
+			for j := 0; j+1 < len(sectionNode.Content); j += 2 {
+				defined[sectionNode.Content[j].Value] = rootFile
+			}
+		}
+
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || !slices.Contains(stackFileExts, ext) {
+				continue
+			}
+			name := strings.TrimSuffix(entry.Name(), ext)
+			path := filepath.Join(dir, section, entry.Name())
+			if other, ok := defined[name]; ok {
+				return nil, fmt.Errorf(
+					"%s: %s %q is already defined in %s", path, section, name, other,
+				)
+			}
+			defined[name] = path
+			if abs, err := filepath.Abs(path); err == nil && included[abs] != "" {
+				return nil, fmt.Errorf(
+					"%s: included by %s, so it would also be read as %s %q; "+
+						"move it into a subdirectory, e.g. %s",
+					path, included[abs], section, name,
+					filepath.Join(dir, section, name, "config"+ext),
+				)
+			}
+
+			node, err := loadYAMLFile(path, nil, origins)
+			if err != nil {
+				return nil, err
+			}
+			if sectionNode == nil {
+				sectionNode = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: section}
+				recordOrigin(key, rootFile, origins)
+				recordOrigin(sectionNode, rootFile, origins)
+				root.Content = append(root.Content, key, sectionNode)
+			}
+			if sectionNode.ShortTag() == "!!null" {
+				sectionNode.Kind, sectionNode.Tag, sectionNode.Value = yaml.MappingNode, "!!map", ""
+			}
+			if sectionNode.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("%s: %s must be a mapping", rootFile, section)
+			}
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name, Line: node.Line}
+			recordOrigin(key, path, origins)
+			sectionNode.Content = append(sectionNode.Content, key, node)
+		}
+	}
+	return root, nil
+}
+
+// stackDirIncludes returns the files that stack.yaml and the entry files of
+// a stack directory include, by absolute path, each mapped to a file
+// including it. Files that don't parse are skipped; loading them reports
+// the error.
+func stackDirIncludes(dir string) (map[string]string, error) {
+	var paths []string
+	for _, name := range stackDirFiles {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	for _, section := range stackDirSections() {
+		entries, err := os.ReadDir(filepath.Join(dir, section))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stack directory: %w", err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && slices.Contains(stackFileExts, filepath.Ext(entry.Name())) {
+				paths = append(paths, filepath.Join(dir, section, entry.Name()))
+			}
+		}
+	}
+
+	included := make(map[string]string)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			continue
+		}
+		var unresolved []string
+		interpolateNode(&doc, path, &unresolved)
+		collectIncludes(&doc, path, included)
+	}
+	return included, nil
+}
+
+// collectIncludes adds the includes under n, which was read from file, to
+// included.
+func collectIncludes(n *yaml.Node, file string, included map[string]string) {
+	if target, ok := includeTarget(n); ok {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(file), target)
+		}
+		if abs, err := filepath.Abs(target); err == nil {
+			included[abs] = file
+		}
+		return
+	}
+	for i, child := range n.Content {
+		if n.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		collectIncludes(child, file, included)
+	}
+}
+
+// resolveIncludes replaces each include under n, which was read from file,
+// with the content of the file it names, and returns n or, when n is an
+// include itself, its replacement. An include is a scalar tagged !include
+// ("agentConfig: !include configs/support.yaml") or a mapping holding
+// only a $ref ("agentConfig: {$ref: configs/support.yaml}"); its path
+// is relative to file. A $ref to a fragment ("#/definitions/...") or a URL
+// is not an include, so JSON Schemas inside agentConfig keep their
+// references. chain lists the files being included, to catch cycles.
+func resolveIncludes(
+	n *yaml.Node,
+	file string,
+	chain []string,
+	origins map[*yaml.Node]string,
+) (*yaml.Node, error) {
+	if target, ok := includeTarget(n); ok {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(file), target)
+		}
+		included, err := loadInclude(target, chain, origins)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: failed to include %s: %w", file, n.Line, target, err)
+		}
+		return included, nil
+	}
+
+	for i, child := range n.Content {
+		// Keys of a mapping are never includes.
+		if n.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		resolved, err := resolveIncludes(child, file, chain, origins)
+		if err != nil {
+			return nil, err
+		}
+		n.Content[i] = resolved
+	}
+	return n, nil
+}
+
+// includeTarget returns the path n includes, if it is an include.
+func includeTarget(n *yaml.Node) (string, bool) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return n.Value, n.Tag == "!include"
+	case yaml.MappingNode:
+		if len(n.Content) != 2 || n.Content[0].Value != "$ref" ||
+			n.Content[1].Kind != yaml.ScalarNode {
+			return "", false
+		}
+		ref := n.Content[1].Value
+		if ref == "" || strings.HasPrefix(ref, "#") || strings.Contains(ref, "://") {
+			return "", false
+		}
+		return ref, true
+	}
+	return "", false
+}
+
+// loadInclude reads an included file: YAML and JSON files are parsed, with
+// their own includes resolved, and any other file becomes a string.
+func loadInclude(path string, chain []string, origins map[*yaml.Node]string) (*yaml.Node, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(chain, abs) {
+		return nil, fmt.Errorf("include cycle: %s", strings.Join(append(chain, abs), " -> "))
+	}
+
+	if slices.Contains(stackFileExts, strings.ToLower(filepath.Ext(path))) {
+		return loadYAMLFile(path, chain, origins)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	n := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: string(data), Line: 1}
+	recordOrigin(n, path, origins)
+	return n, nil
+}
+
+// WriteStackDir writes cfg as a stack directory LoadStackConfig reads back
+// into the same config: the top-level fields to stack.yaml, and each entry
+// of a section to its own file, e.g. agents/support.yaml. YAML files of
+// entries cfg doesn't have are removed from the section directories, so
+// writing over an earlier export doesn't bring deleted resources back. $ in
+// values is escaped, as by MarshalStackConfig.
+func WriteStackDir(cfg *StackConfig, dir string) error {
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return err
+	}
+	escapeNode(&doc)
+
+	sections := stackDirSections()
+	top := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	entries := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		if slices.Contains(sections, key.Value) {
+			entries[key.Value] = value
+			continue
+		}
+		top.Content = append(top.Content, key, value)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	topFile := stackDirFiles[0]
+	for _, name := range stackDirFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			topFile = name
+			break
+		}
+	}
+	if err := writeYAMLNode(filepath.Join(dir, topFile), top); err != nil {
+		return err
+	}
+
+	for _, section := range sections {
+		sectionDir := filepath.Join(dir, section)
+		written := make(map[string]bool)
+		if node := entries[section]; node != nil && len(node.Content) > 0 {
+			if err := os.MkdirAll(sectionDir, 0o755); err != nil {
+				return err
+			}
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				name := node.Content[i].Value
+				if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+					return fmt.Errorf("%s %q can't be written to a file of its own", section, name)
+				}
+				file := name + ".yaml"
+				err := writeYAMLNode(filepath.Join(sectionDir, file), node.Content[i+1])
+				if err != nil {
+					return err
+				}
+				written[file] = true
+			}
+		}
+
+		existing, err := os.ReadDir(sectionDir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, entry := range existing {
+			name := entry.Name()
+			isStackFile := slices.Contains(stackFileExts, filepath.Ext(name))
+			if entry.IsDir() || written[name] || !isStackFile {
+				continue
+			}
+			if err := os.Remove(filepath.Join(sectionDir, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeYAMLNode(path string, n *yaml.Node) error {
+	data, err := yaml.Marshal(n)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
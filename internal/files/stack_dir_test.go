@@ -0,0 +1,312 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/google/go-cmp/cmp"
+)
+
+func mkdirs(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatalf("failed to create test directory: %v", err)
+		}
+	}
+}
+
+func TestLoadStackConfigDirectory(t *testing.T) {
+	dir := t.TempDir()
+	mkdirs(t, dir, "services", "agents", "routines", "configs")
+	writeStackFile(t, dir, "stack.yaml", `organization: my-org
+project: dev
+stack-id: shop
+mcps:
+  search:
+    endpointUrl: https://search.example.com/mcp
+`)
+	writeStackFile(t, dir, "services/api.yaml", `servicePort: 8080
+image: {type: internal, name: api, tag: v1}
+resources: {cpu: "0.5", memory: 256M}
+`)
+	writeStackFile(t, dir, "agents/support.yaml", `id: support
+version: "2"
+agentConfig: !include ../configs/support.json
+`)
+	writeStackFile(t, dir, "configs/support.json", `{"mcps": [{"id": "search"}], "prompt": {"$ref": "support.md"}}`)
+	writeStackFile(t, dir, "configs/support.md", "Be helpful.\n")
+	writeStackFile(t, dir, "routines/greeting.yaml", "content: !include greeting.md\n")
+	writeStackFile(t, dir, "routines/greeting.md", "Say hello.\n")
+
+	cfg, err := LoadStackConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadStackConfig() error = %v", err)
+	}
+
+	if cfg.StackId != "shop" || cfg.Project != "dev" {
+		t.Errorf("StackId, Project = %q, %q, want shop, dev", cfg.StackId, cfg.Project)
+	}
+	if got := cfg.Services["api"].Image.Tag; got != "v1" {
+		t.Errorf("services.api.image.tag = %q, want v1", got)
+	}
+	if _, ok := cfg.Mcps["search"]; !ok {
+		t.Errorf("mcps = %v, want search from stack.yaml", cfg.Mcps)
+	}
+	wantAgentConfig := map[string]any{
+		"mcps":   []any{map[string]any{"id": "search"}},
+		"prompt": "Be helpful.\n",
+	}
+	if diff := cmp.Diff(wantAgentConfig, cfg.Agents["support"].AgentConfig); diff != "" {
+		t.Errorf("agentConfig mismatch (-want +got):\n%s", diff)
+	}
+	if got := cfg.Routines["greeting"].Content; got != "Say hello.\n" {
+		t.Errorf("routines.greeting.content = %q, want the included file", got)
+	}
+}
+
+func TestLoadStackConfigDirectoryRejectsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	mkdirs(t, dir, "agents")
+	writeStackFile(t, dir, "stack.yaml", `stack-id: shop
+agents:
+  support: {id: support, version: "1"}
+`)
+	writeStackFile(t, dir, "agents/support.yaml", "id: support\nversion: \"2\"\n")
+
+	_, err := LoadStackConfig(dir)
+	if err == nil || !strings.Contains(err.Error(), `agents "support" is already defined`) {
+		t.Fatalf("LoadStackConfig() error = %v, want a duplicate definition error", err)
+	}
+}
+
+func TestLoadStackConfigDirectoryIncludesFromSubdirectories(t *testing.T) {
+	dir := t.TempDir()
+	mkdirs(t, dir, "agents/support")
+	writeStackFile(t, dir, "stack.yaml", "stack-id: shop\n")
+	writeStackFile(t, dir, "agents/support.yaml", `id: support
+version: "1"
+agentConfig: !include support/config.yaml
+`)
+	included := writeStackFile(t, dir, "agents/support/config.yaml", "prompt: Be helpful.\n")
+
+	cfg, err := LoadStackConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadStackConfig() error = %v", err)
+	}
+	if len(cfg.Agents) != 1 {
+		t.Errorf("agents = %v, want only support", cfg.Agents)
+	}
+	want := map[string]any{"prompt": "Be helpful."}
+	if diff := cmp.Diff(want, cfg.Agents["support"].AgentConfig); diff != "" {
+		t.Errorf("agentConfig mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := WriteStackDir(cfg, dir); err != nil {
+		t.Fatalf("WriteStackDir() error = %v", err)
+	}
+	if _, err := os.Stat(included); err != nil {
+		t.Errorf("WriteStackDir() removed the included file: %v", err)
+	}
+}
+
+func TestLoadStackConfigDirectoryRejectsIncludedEntries(t *testing.T) {
+	dir := t.TempDir()
+	mkdirs(t, dir, "agents")
+	writeStackFile(t, dir, "agents/support.yaml", `id: support
+version: "1"
+agentConfig: !include support-config.yaml
+`)
+	writeStackFile(t, dir, "agents/support-config.yaml", "prompt: Be helpful.\n")
+
+	_, err := LoadStackConfig(dir)
+	want := "move it into a subdirectory"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("LoadStackConfig() error = %v, want one containing %q", err, want)
+	}
+}
+
+func TestResolveIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeStackFile(t, dir, "schema.yaml", `type: object
+properties:
+  user: {$ref: "#/definitions/user"}
+`)
+	writeStackFile(t, dir, "a.yaml", "next: !include b.yaml\n")
+	writeStackFile(t, dir, "b.yaml", "next: {$ref: a.yaml}\n")
+
+	tests := []struct {
+		name    string
+		content string
+		want    any
+		wantErr string
+	}{
+		{
+			name:    "include tag",
+			content: "agentConfig: !include schema.yaml\n",
+			want: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"user": map[string]any{"$ref": "#/definitions/user"},
+				},
+			},
+		},
+		{
+			name:    "ref mapping",
+			content: "agentConfig: {$ref: schema.yaml}\n",
+			want: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"user": map[string]any{"$ref": "#/definitions/user"},
+				},
+			},
+		},
+		{
+			name:    "ref to URL is kept",
+			content: "agentConfig: {$ref: https://example.com/schema.json}\n",
+			want:    map[string]any{"$ref": "https://example.com/schema.json"},
+		},
+		{
+			name:    "cycle",
+			content: "agentConfig: !include a.yaml\n",
+			wantErr: "include cycle",
+		},
+		{
+			name:    "missing file",
+			content: "agentConfig: !include missing.yaml\n",
+			wantErr: "failed to include",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stack := "stack-id: shop\nagents:\n  support:\n    " + tt.content
+			path := writeStackFile(t, dir, "stack.yaml", stack)
+
+			cfg, err := LoadStackConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadStackConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadStackConfig() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, cfg.Agents["support"].AgentConfig); diff != "" {
+				t.Errorf("agentConfig mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteStackDirRoundTrip(t *testing.T) {
+	cfg := &StackConfig{
+		Organization: "my-org",
+		Project:      "dev",
+		StackId:      "shop",
+		Services: map[string]ServiceConfig{
+			"api": {
+				ServicePort: 8080,
+				Image:       deployment.ImageSpec{Type: "internal", Name: "api", Tag: "v1"},
+				Resources:   deployment.Resources{CPU: "0.5", Memory: "256M"},
+				Env:         []deployment.EnvVar{{Name: "MODE", Value: "prod"}},
+			},
+		},
+		Agents: map[string]AgentConfig{
+			"support": {
+				Id:          "support",
+				Version:     "2",
+				AgentConfig: map[string]any{"mcps": []any{map[string]any{"id": "search"}}},
+			},
+		},
+		Databases: map[string]DatabaseConfig{},
+		Mcps: map[string]McpConfig{
+			"search": {Type: "external", EndpointURL: "https://search.example.com/mcp"},
+		},
+		Secrets:  map[string]SecretConfig{"db-creds": {Keys: []string{"DB_PASSWORD"}}},
+		Routines: map[string]ContextItemConfig{"greeting": {Content: "Say hello.\n"}},
+	}
+
+	dir := t.TempDir()
+	mkdirs(t, dir, "agents")
+	stale := writeStackFile(t, dir, "agents/retired.yml", "id: retired\nversion: \"1\"\n")
+
+	if err := WriteStackDir(cfg, dir); err != nil {
+		t.Fatalf("WriteStackDir() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "agents", "support.yaml")); err != nil {
+		t.Errorf("agents/support.yaml was not written: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale agents/retired.yml was not removed")
+	}
+
+	got, err := LoadStackConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadStackConfig() error = %v", err)
+	}
+	if diff := cmp.Diff(cfg, got); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExportedStackKeepsDollarSigns(t *testing.T) {
+	t.Setenv("HOME", "/root")
+	cfg := &StackConfig{
+		StackId: "shop",
+		Services: map[string]ServiceConfig{
+			"api": {
+				ServicePort: 8080,
+				Image:       deployment.ImageSpec{Name: "api", Tag: "1"},
+				Env: []deployment.EnvVar{
+					{Name: "PRICE", Value: "costs $$5"},
+					{Name: "DATA_DIR", Value: "${HOME}/x"},
+					{Name: "DEFAULTED", Value: "${UNSET_VAR:-fallback}"},
+				},
+			},
+		},
+		Agents: map[string]AgentConfig{
+			"support": {
+				Id:          "support",
+				Version:     "2",
+				AgentConfig: map[string]any{"greeting": "Pay $10 at ${STORE}"},
+			},
+		},
+		Databases: map[string]DatabaseConfig{},
+		Mcps:      map[string]McpConfig{},
+		Routines:  map[string]ContextItemConfig{"billing": {Content: "Refund up to $$20.\n"}},
+	}
+
+	t.Run("stack file", func(t *testing.T) {
+		data, err := MarshalStackConfig(cfg)
+		if err != nil {
+			t.Fatalf("MarshalStackConfig() error = %v", err)
+		}
+		path := writeStackFile(t, t.TempDir(), "stack.yaml", string(data))
+		got, err := LoadStackConfig(path)
+		if err != nil {
+			t.Fatalf("LoadStackConfig() error = %v", err)
+		}
+		if diff := cmp.Diff(cfg, got); diff != "" {
+			t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("stack directory", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := WriteStackDir(cfg, dir); err != nil {
+			t.Fatalf("WriteStackDir() error = %v", err)
+		}
+		got, err := LoadStackConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadStackConfig() error = %v", err)
+		}
+		if diff := cmp.Diff(cfg, got); diff != "" {
+			t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package files
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// StackGraph is the graph of the references between the resources of a
+// stack. Nodes are "type/name" IDs, e.g. "service/api" or "routine/bonus".
+type StackGraph struct {
+	Nodes []string
+	Edges []GraphEdge
+}
+
+// GraphEdge is a reference from one node to another. Via lists every place
+// the reference appears, e.g. "env DATABASE_URL". A dangling edge points at
+// a resource the stack doesn't have.
+type GraphEdge struct {
+	From     string
+	To       string
+	Via      []string
+	Dangling bool
+}
+
+// BuildStackGraph returns the graph of cfg: a node for each resource, secret,
+// and context item cfg has, and an edge for each of its StackReferences.
+// References to resources cfg doesn't have are kept as dangling edges, and
+// their targets are added to Nodes.
+func BuildStackGraph(cfg *StackConfig) *StackGraph {
+	declared := make(map[string]bool)
+	add := func(typ string, names iter.Seq[string]) {
+		for name := range names {
+			declared[typ+"/"+name] = true
+		}
+	}
+	add("service", maps.Keys(cfg.Services))
+	add("agent", maps.Keys(cfg.Agents))
+	add("database", maps.Keys(cfg.Databases))
+	add("mcp", maps.Keys(cfg.Mcps))
+	add("secret", maps.Keys(cfg.Secrets))
+	for _, kind := range ContextKinds {
+		add(kind.TypeName, maps.Keys(cfg.ContextItems(kind.Section)))
+	}
+
+	g := &StackGraph{}
+	nodes := maps.Clone(declared)
+	for _, ref := range StackReferences(cfg) {
+		n := len(g.Edges)
+		if n > 0 && g.Edges[n-1].From == ref.From && g.Edges[n-1].To == ref.To {
+			g.Edges[n-1].Via = append(g.Edges[n-1].Via, ref.Via)
+			continue
+		}
+		g.Edges = append(g.Edges, GraphEdge{
+			From:     ref.From,
+			To:       ref.To,
+			Via:      []string{ref.Via},
+			Dangling: !declared[ref.To],
+		})
+		nodes[ref.To] = true
+	}
+	g.Nodes = slices.Sorted(maps.Keys(nodes))
+	return g
+}
+
+// Dangling returns the dangling edges of g.
+func (g *StackGraph) Dangling() []GraphEdge {
+	var dangling []GraphEdge
+	for _, e := range g.Edges {
+		if e.Dangling {
+			dangling = append(dangling, e)
+		}
+	}
+	return dangling
+}
+
+// isDangling reports whether node is only known as the target of a dangling
+// edge.
+func (g *StackGraph) isDangling(node string) bool {
+	for _, e := range g.Edges {
+		if e.To == node {
+			return e.Dangling
+		}
+	}
+	return false
+}
+
+// WriteDOT writes g in the Graphviz DOT language, with dangling references
+// and their targets drawn dashed in red.
+func (g *StackGraph) WriteDOT(w io.Writer, name string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", name)
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		if g.isDangling(n) {
+			fmt.Fprintf(&b, "  %q [style=dashed, color=red];\n", n)
+			continue
+		}
+		fmt.Fprintf(&b, "  %q;\n", n)
+	}
+	for _, e := range g.Edges {
+		attrs := fmt.Sprintf("label=%q", strings.Join(e.Via, ", "))
+		if e.Dangling {
+			attrs += ", style=dashed, color=red"
+		}
+		fmt.Fprintf(&b, "  %q -> %q [%s];\n", e.From, e.To, attrs)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes g as a Mermaid flowchart, with dangling references and
+// their targets drawn dashed in red.
+func (g *StackGraph) WriteMermaid(w io.Writer) error {
+	ids := make(map[string]string, len(g.Nodes))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range g.Nodes {
+		ids[n] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[n], mermaidText(n))
+	}
+	var dangling []string
+	for i, e := range g.Edges {
+		arrow := "-->"
+		if e.Dangling {
+			arrow = "-.->"
+			dangling = append(dangling, fmt.Sprint(i))
+		}
+		via := mermaidText(strings.Join(e.Via, ", "))
+		fmt.Fprintf(&b, "  %s %s|\"%s\"| %s\n", ids[e.From], arrow, via, ids[e.To])
+	}
+	var danglingNodes []string
+	for _, n := range g.Nodes {
+		if g.isDangling(n) {
+			danglingNodes = append(danglingNodes, ids[n])
+		}
+	}
+	if len(danglingNodes) > 0 {
+		b.WriteString("  classDef dangling stroke:#d00,stroke-dasharray:5 5\n")
+		fmt.Fprintf(&b, "  class %s dangling\n", strings.Join(danglingNodes, ","))
+		fmt.Fprintf(&b, "  linkStyle %s stroke:#d00\n", strings.Join(dangling, ","))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidText escapes the quotes Mermaid labels can't hold.
+func mermaidText(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+// WriteTree writes g as a tree for the terminal: each resource nothing
+// references is a root, with the resources it references below it. A
+// resource reached again through a cycle is marked and not expanded, and
+// dangling references are marked.
+func (g *StackGraph) WriteTree(w io.Writer) error {
+	out := make(map[string][]GraphEdge)
+	referenced := make(map[string]bool)
+	for _, e := range g.Edges {
+		out[e.From] = append(out[e.From], e)
+		referenced[e.To] = true
+	}
+
+	var b strings.Builder
+	printed := make(map[string]bool)
+	var walk func(node, prefix string, path []string)
+	walk = func(node, prefix string, path []string) {
+		printed[node] = true
+		edges := out[node]
+		for i, e := range edges {
+			branch, indent := "├── ", "│   "
+			if i == len(edges)-1 {
+				branch, indent = "└── ", "    "
+			}
+			line := fmt.Sprintf("%s%s%s (%s)", prefix, branch, e.To, strings.Join(e.Via, ", "))
+			switch {
+			case e.Dangling:
+				line += " [dangling]"
+			case slices.Contains(path, e.To):
+				line += " [cycle]"
+			}
+			b.WriteString(line + "\n")
+			if !e.Dangling && !slices.Contains(path, e.To) {
+				walk(e.To, prefix+indent, append(path, e.To))
+			}
+		}
+	}
+
+	roots := make([]string, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		if !referenced[n] {
+			roots = append(roots, n)
+		}
+	}
+	// Resources referenced only from within a cycle have no root above
+	// them: once the roots are done, the first one not printed yet starts
+	// another tree.
+	for i := 0; ; i++ {
+		if i == len(roots) {
+			for _, n := range g.Nodes {
+				if !printed[n] && !g.isDangling(n) {
+					roots = append(roots, n)
+					break
+				}
+			}
+			if i == len(roots) {
+				break
+			}
+		}
+		b.WriteString(roots[i] + "\n")
+		walk(roots[i], "", []string{roots[i]})
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,173 @@
+package files
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/google/go-cmp/cmp"
+)
+
+func testGraphConfig() *StackConfig {
+	return &StackConfig{
+		StackId: "shop",
+		Services: map[string]ServiceConfig{
+			"api": {
+				Env: []deployment.EnvVar{
+					{Name: "DATABASE_URL", Value: "postgres://orders-db:5432/orders"},
+					{Name: "DB_HOST", Value: "orders-db"},
+				},
+			},
+		},
+		Agents: map[string]AgentConfig{
+			"chat": {
+				SecretRefs: []deployment.SecretRef{{SecretName: "api-keys"}},
+				AgentConfig: map[string]any{
+					"mcps": []any{map[string]any{"id": "tools"}},
+					"context": map[string]any{
+						"routines": []any{map[string]any{"id": "bonus", "version": 1}},
+						"policies": []any{map[string]any{"id": "refunds", "version": 1}},
+					},
+				},
+			},
+		},
+		Databases: map[string]DatabaseConfig{"orders-db": {}},
+		Mcps: map[string]McpConfig{
+			"tools": {Env: []deployment.EnvVar{{Name: "DB", Value: "orders-db"}}},
+		},
+		Secrets:  map[string]SecretConfig{"api-keys": {}},
+		Routines: map[string]ContextItemConfig{"bonus": {Content: "Offer a bonus."}},
+	}
+}
+
+func TestBuildStackGraph(t *testing.T) {
+	g := BuildStackGraph(testGraphConfig())
+
+	wantNodes := []string{
+		"agent/chat", "database/orders-db", "mcp/tools", "policy/refunds",
+		"routine/bonus", "secret/api-keys", "service/api",
+	}
+	if diff := cmp.Diff(wantNodes, g.Nodes); diff != "" {
+		t.Errorf("Nodes mismatch (-want +got):\n%s", diff)
+	}
+
+	wantDangling := []GraphEdge{{
+		From:     "agent/chat",
+		To:       "policy/refunds",
+		Via:      []string{"agentConfig.context.policies"},
+		Dangling: true,
+	}}
+	if diff := cmp.Diff(wantDangling, g.Dangling()); diff != "" {
+		t.Errorf("Dangling() mismatch (-want +got):\n%s", diff)
+	}
+
+	wantVia := []string{"env DATABASE_URL", "env DB_HOST"}
+	for _, e := range g.Edges {
+		if e.From == "service/api" {
+			if diff := cmp.Diff(wantVia, e.Via); diff != "" {
+				t.Errorf("service/api edge Via mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+}
+
+func TestStackGraphFormats(t *testing.T) {
+	g := BuildStackGraph(testGraphConfig())
+
+	tests := []struct {
+		name  string
+		write func(*strings.Builder) error
+		want  string
+	}{
+		{
+			name:  "tree",
+			write: func(b *strings.Builder) error { return g.WriteTree(b) },
+			want: `agent/chat
+├── mcp/tools (agentConfig.mcps)
+│   └── database/orders-db (env DB)
+├── policy/refunds (agentConfig.context.policies) [dangling]
+├── routine/bonus (agentConfig.context.routines)
+└── secret/api-keys (secretRefs)
+service/api
+└── database/orders-db (env DATABASE_URL, env DB_HOST)
+`,
+		},
+		{
+			name:  "dot",
+			write: func(b *strings.Builder) error { return g.WriteDOT(b, "shop") },
+			want: `digraph "shop" {
+  rankdir=LR;
+  node [shape=box];
+  "agent/chat";
+  "database/orders-db";
+  "mcp/tools";
+  "policy/refunds" [style=dashed, color=red];
+  "routine/bonus";
+  "secret/api-keys";
+  "service/api";
+  "agent/chat" -> "mcp/tools" [label="agentConfig.mcps"];
+  "agent/chat" -> "policy/refunds" [label="agentConfig.context.policies", style=dashed, color=red];
+  "agent/chat" -> "routine/bonus" [label="agentConfig.context.routines"];
+  "agent/chat" -> "secret/api-keys" [label="secretRefs"];
+  "mcp/tools" -> "database/orders-db" [label="env DB"];
+  "service/api" -> "database/orders-db" [label="env DATABASE_URL, env DB_HOST"];
+}
+`,
+		},
+		{
+			name:  "mermaid",
+			write: func(b *strings.Builder) error { return g.WriteMermaid(b) },
+			want: `flowchart LR
+  n0["agent/chat"]
+  n1["database/orders-db"]
+  n2["mcp/tools"]
+  n3["policy/refunds"]
+  n4["routine/bonus"]
+  n5["secret/api-keys"]
+  n6["service/api"]
+  n0 -->|"agentConfig.mcps"| n2
+  n0 -.->|"agentConfig.context.policies"| n3
+  n0 -->|"agentConfig.context.routines"| n4
+  n0 -->|"secretRefs"| n5
+  n2 -->|"env DB"| n1
+  n6 -->|"env DATABASE_URL, env DB_HOST"| n1
+  classDef dangling stroke:#d00,stroke-dasharray:5 5
+  class n3 dangling
+  linkStyle 1 stroke:#d00
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := tt.write(&b); err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStackGraphTreeCycle(t *testing.T) {
+	cfg := &StackConfig{
+		Mcps: map[string]McpConfig{
+			"a": {Env: []deployment.EnvVar{{Name: "PEER", Value: "http://b:8080"}}},
+			"b": {Env: []deployment.EnvVar{{Name: "PEER", Value: "http://a:8080"}}},
+		},
+	}
+
+	var b strings.Builder
+	if err := BuildStackGraph(cfg).WriteTree(&b); err != nil {
+		t.Fatalf("WriteTree() error = %v", err)
+	}
+	want := `mcp/a
+└── mcp/b (env PEER)
+    └── mcp/a (env PEER) [cycle]
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("WriteTree() mismatch (-want +got):\n%s", diff)
+	}
+}
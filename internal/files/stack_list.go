@@ -2,8 +2,11 @@ package files
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
 )
@@ -17,6 +20,36 @@ type StackInfo struct {
 	McpCount      int    `json:"mcpCount"`
 }
 
+// StackResource is a live service, agent, database, or mcp and the stack it
+// belongs to; StackID is empty for a resource no stack manages.
+type StackResource struct {
+	Type    string `json:"type"` // service, agent, database, or mcp
+	Name    string `json:"name"`
+	StackID string `json:"stackId,omitempty"`
+}
+
+func (r StackResource) String() string {
+	return r.Type + "/" + r.Name
+}
+
+// StackResourceTypes lists the resource types a stack manages.
+var StackResourceTypes = []string{"service", "agent", "database", "mcp"}
+
+// ParseStackResource parses a "type/name" reference, e.g. service/api. Plural
+// types (services/api) are accepted too.
+func ParseStackResource(ref string) (StackResource, error) {
+	kind, name, ok := strings.Cut(ref, "/")
+	kind = strings.TrimSuffix(kind, "s")
+	if !ok || name == "" || !slices.Contains(StackResourceTypes, kind) {
+		return StackResource{}, fmt.Errorf(
+			"invalid resource %q; use type/name, where type is one of %s",
+			ref,
+			strings.Join(StackResourceTypes, ", "),
+		)
+	}
+	return StackResource{Type: kind, Name: name}, nil
+}
+
 // ListStacks discovers stacks and their resource counts from live services,
 // agents, databases, and mcps. Resources without a stackId are skipped.
 func ListStacks(
@@ -24,26 +57,74 @@ func ListStacks(
 	deployClient *deployment.DeploymentClient,
 	orgId, projectId string,
 ) ([]StackInfo, error) {
+	resources, err := ListStackResources(ctx, deployClient, orgId, projectId)
+	if err != nil {
+		return nil, err
+	}
+	return SummarizeStacks(resources), nil
+}
+
+// SummarizeStacks counts the resources of each stack, sorted by stack ID.
+// Resources without a stackId are skipped.
+func SummarizeStacks(resources []StackResource) []StackInfo {
 	stacks := make(map[string]*StackInfo)
+	for _, r := range resources {
+		if r.StackID == "" {
+			continue
+		}
+		s, ok := stacks[r.StackID]
+		if !ok {
+			s = &StackInfo{StackID: r.StackID}
+			stacks[r.StackID] = s
+		}
+		switch r.Type {
+		case "service":
+			s.ServiceCount++
+		case "agent":
+			s.AgentCount++
+		case "database":
+			s.DatabaseCount++
+		case "mcp":
+			s.McpCount++
+		}
+	}
+
+	result := make([]StackInfo, 0, len(stacks))
+	for _, s := range stacks {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StackID < result[j].StackID
+	})
+	return result
+}
+
+// UnmanagedResources returns the resources that belong to no stack.
+func UnmanagedResources(resources []StackResource) []StackResource {
+	var unmanaged []StackResource
+	for _, r := range resources {
+		if r.StackID == "" {
+			unmanaged = append(unmanaged, r)
+		}
+	}
+	return unmanaged
+}
+
+// ListStackResources lists every live service, agent, database, and mcp of a
+// project with the stack it belongs to, by type and then name.
+func ListStackResources(
+	ctx context.Context,
+	deployClient *deployment.DeploymentClient,
+	orgId, projectId string,
+) ([]StackResource, error) {
+	var resources []StackResource
 
 	svcs, err := deployClient.ListServices(ctx, orgId, projectId, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list services: %w", err)
 	}
 	for _, svc := range svcs {
-		desc, err := deployClient.DescribeService(ctx, orgId, projectId, svc.Name)
-		if err != nil {
-			return nil, fmt.Errorf("failed to describe service %q: %w", svc.Name, err)
-		}
-		if desc.StackId == "" {
-			continue
-		}
-		s, ok := stacks[desc.StackId]
-		if !ok {
-			s = &StackInfo{StackID: desc.StackId}
-			stacks[desc.StackId] = s
-		}
-		s.ServiceCount++
+		resources = append(resources, StackResource{Type: "service", Name: svc.Name})
 	}
 
 	agents, err := deployClient.ListAgents(ctx, orgId, projectId, "")
@@ -51,19 +132,7 @@ func ListStacks(
 		return nil, fmt.Errorf("failed to list agents: %w", err)
 	}
 	for _, a := range agents {
-		desc, err := deployClient.DescribeAgent(ctx, orgId, projectId, a.Name)
-		if err != nil {
-			return nil, fmt.Errorf("failed to describe agent %q: %w", a.Name, err)
-		}
-		if desc.StackId == "" {
-			continue
-		}
-		s, ok := stacks[desc.StackId]
-		if !ok {
-			s = &StackInfo{StackID: desc.StackId}
-			stacks[desc.StackId] = s
-		}
-		s.AgentCount++
+		resources = append(resources, StackResource{Type: "agent", Name: a.Name})
 	}
 
 	dbs, err := deployClient.ListDatabases(ctx, orgId, projectId, "")
@@ -71,19 +140,15 @@ func ListStacks(
 		return nil, fmt.Errorf("failed to list databases: %w", err)
 	}
 	for _, db := range dbs {
-		desc, err := deployClient.DescribeDatabase(ctx, orgId, projectId, db.Name)
+		resources = append(resources, StackResource{Type: "database", Name: db.Name})
+	}
+
+	for i, r := range resources {
+		stackID, err := ResourceStackID(ctx, deployClient, orgId, projectId, r)
 		if err != nil {
-			return nil, fmt.Errorf("failed to describe database %q: %w", db.Name, err)
+			return nil, err
 		}
-		if desc.StackId == "" {
-			continue
-		}
-		s, ok := stacks[desc.StackId]
-		if !ok {
-			s = &StackInfo{StackID: desc.StackId}
-			stacks[desc.StackId] = s
-		}
-		s.DatabaseCount++
+		resources[i].StackID = stackID
 	}
 
 	mcps, err := deployClient.ListMcps(ctx, orgId, projectId, "")
@@ -92,23 +157,88 @@ func ListStacks(
 	}
 	// MCPs expose stackId in the list response, so no describe call is needed.
 	for _, mcp := range mcps {
-		if mcp.StackId == "" {
-			continue
+		resources = append(resources, StackResource{
+			Type: "mcp", Name: mcp.Name, StackID: mcp.StackId,
+		})
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		ti := slices.Index(StackResourceTypes, resources[i].Type)
+		tj := slices.Index(StackResourceTypes, resources[j].Type)
+		if ti != tj {
+			return ti < tj
 		}
-		s, ok := stacks[mcp.StackId]
-		if !ok {
-			s = &StackInfo{StackID: mcp.StackId}
-			stacks[mcp.StackId] = s
+		return resources[i].Name < resources[j].Name
+	})
+	return resources, nil
+}
+
+// ResourceStackID describes a live resource and returns the stack it belongs
+// to, or "" when no stack manages it.
+func ResourceStackID(
+	ctx context.Context,
+	deployClient *deployment.DeploymentClient,
+	orgId, projectId string,
+	r StackResource,
+) (string, error) {
+	switch r.Type {
+	case "service":
+		desc, err := deployClient.DescribeService(ctx, orgId, projectId, r.Name)
+		if err != nil {
+			return "", fmt.Errorf("failed to describe service %q: %w", r.Name, err)
 		}
-		s.McpCount++
+		return desc.StackId, nil
+	case "agent":
+		desc, err := deployClient.DescribeAgent(ctx, orgId, projectId, r.Name)
+		if err != nil {
+			return "", fmt.Errorf("failed to describe agent %q: %w", r.Name, err)
+		}
+		return desc.StackId, nil
+	case "database":
+		desc, err := deployClient.DescribeDatabase(ctx, orgId, projectId, r.Name)
+		if err != nil {
+			return "", fmt.Errorf("failed to describe database %q: %w", r.Name, err)
+		}
+		return desc.StackId, nil
+	case "mcp":
+		desc, err := deployClient.DescribeMcp(ctx, orgId, projectId, r.Name)
+		if err != nil {
+			return "", fmt.Errorf("failed to describe mcp %q: %w", r.Name, err)
+		}
+		return desc.StackId, nil
 	}
+	return "", fmt.Errorf("unknown resource type %q", r.Type)
+}
 
-	result := make([]StackInfo, 0, len(stacks))
-	for _, s := range stacks {
-		result = append(result, *s)
+// AdoptResource tags a live resource with stackID. Only the stack ID is
+// patched; the resource's spec is left as it is.
+func AdoptResource(
+	ctx context.Context,
+	deployClient *deployment.DeploymentClient,
+	orgId, projectId string,
+	r StackResource,
+	stackID string,
+) error {
+	raw, err := json.Marshal(stackID)
+	if err != nil {
+		return err
 	}
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].StackID < result[j].StackID
-	})
-	return result, nil
+	patch := deployment.UpdatePatch{"stackId": raw}
+
+	switch r.Type {
+	case "service":
+		_, err = deployClient.PatchService(ctx, orgId, projectId, r.Name, patch)
+	case "agent":
+		_, err = deployClient.PatchAgent(ctx, orgId, projectId, r.Name, patch)
+	case "database":
+		_, err = deployClient.PatchDatabase(ctx, orgId, projectId, r.Name, patch)
+	case "mcp":
+		_, err = deployClient.PatchMcp(ctx, orgId, projectId, r.Name, patch)
+	default:
+		return fmt.Errorf("unknown resource type %q", r.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to adopt %s: %w", r, err)
+	}
+	return nil
 }
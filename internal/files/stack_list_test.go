@@ -0,0 +1,120 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestDeployClient(t *testing.T, handler http.HandlerFunc) *deployment.DeploymentClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := deployment.NewDeploymentClient(server.URL, 5*time.Second, "test-token", "", nil)
+	if err != nil {
+		t.Fatalf("NewDeploymentClient() error = %v", err)
+	}
+	return client
+}
+
+func TestParseStackResource(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    StackResource
+		wantErr bool
+	}{
+		{ref: "service/api", want: StackResource{Type: "service", Name: "api"}},
+		{ref: "agents/support", want: StackResource{Type: "agent", Name: "support"}},
+		{ref: "mcp/search", want: StackResource{Type: "mcp", Name: "search"}},
+		{ref: "api", wantErr: true},
+		{ref: "service/", wantErr: true},
+		{ref: "secret/db-creds", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseStackResource(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStackResource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseStackResource() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListStackResourcesAndAdopt(t *testing.T) {
+	const base = "/v1/organizations/org-1/projects/proj-1"
+	var patched []string
+	var patchBody map[string]any
+
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			patched = append(patched, r.URL.Path)
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &patchBody)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"message":"updated"}`))
+			return
+		}
+		switch r.URL.Path {
+		case base + "/services":
+			_, _ = w.Write([]byte(`{"services":[{"name":"web"},{"name":"api"}]}`))
+		case base + "/services/api":
+			_, _ = w.Write([]byte(`{"name":"api","stackId":"shop"}`))
+		case base + "/services/web":
+			_, _ = w.Write([]byte(`{"name":"web"}`))
+		case base + "/agents":
+			_, _ = w.Write([]byte(`{"agents":[{"name":"support"}]}`))
+		case base + "/agents/support":
+			_, _ = w.Write([]byte(`{"name":"support"}`))
+		case base + "/databases":
+			_, _ = w.Write([]byte(`{"databases":[]}`))
+		case base + "/mcps":
+			_, _ = w.Write([]byte(`{"mcps":[{"name":"search","stackId":"shop"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	ctx := context.Background()
+	resources, err := ListStackResources(ctx, client, "org-1", "proj-1")
+	if err != nil {
+		t.Fatalf("ListStackResources() error = %v", err)
+	}
+	want := []StackResource{
+		{Type: "service", Name: "api", StackID: "shop"},
+		{Type: "service", Name: "web"},
+		{Type: "agent", Name: "support"},
+		{Type: "mcp", Name: "search", StackID: "shop"},
+	}
+	if diff := cmp.Diff(want, resources); diff != "" {
+		t.Errorf("ListStackResources() mismatch (-want +got):\n%s", diff)
+	}
+
+	wantStacks := []StackInfo{{StackID: "shop", ServiceCount: 1, McpCount: 1}}
+	if diff := cmp.Diff(wantStacks, SummarizeStacks(resources)); diff != "" {
+		t.Errorf("SummarizeStacks() mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, r := range UnmanagedResources(resources) {
+		if err := AdoptResource(ctx, client, "org-1", "proj-1", r, "shop"); err != nil {
+			t.Fatalf("AdoptResource(%s) error = %v", r, err)
+		}
+	}
+	wantPatched := []string{base + "/services/web", base + "/agents/support"}
+	if diff := cmp.Diff(wantPatched, patched); diff != "" {
+		t.Errorf("patched resources mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]any{"stackId": "shop"}, patchBody); diff != "" {
+		t.Errorf("patch body mismatch (-want +got):\n%s", diff)
+	}
+}
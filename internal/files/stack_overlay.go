@@ -0,0 +1,194 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadStackNode reads a stack file, or a stack directory (see
+// loadStackDir), into a YAML node, expands ${VAR} references in it, and
+// resolves its includes. An empty file yields an empty mapping. When origins
+// is non-nil, every node is recorded with the file it was read from.
+func loadStackNode(path string, origins map[*yaml.Node]string) (*yaml.Node, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if info.IsDir() {
+		return loadStackDir(path, origins)
+	}
+	return loadYAMLFile(path, nil, origins)
+}
+
+// loadYAMLFile reads one YAML file, expands its ${VAR} references, and
+// resolves its includes. chain lists the files that include it, outermost
+// first.
+func loadYAMLFile(path string, chain []string, origins map[*yaml.Node]string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML in %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		recordOrigin(root, path, origins)
+		return root, nil
+	}
+	root := doc.Content[0]
+
+	var unresolved []string
+	interpolateNode(root, path, &unresolved)
+	if len(unresolved) > 0 {
+		return nil, fmt.Errorf(
+			"found %d unresolved variables:\n%s",
+			len(unresolved),
+			strings.Join(unresolved, "\n"),
+		)
+	}
+	recordOrigin(root, path, origins)
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return resolveIncludes(root, path, append(chain[:len(chain):len(chain)], abs), origins)
+}
+
+// varPattern matches $$ (an escaped dollar sign), ${VAR}, and ${VAR:-default}.
+var varPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolateNode expands variable references in every scalar under n from
+// the process environment. Unset variables without a default are appended to
+// unresolved as "file:line: VAR is not set".
+func interpolateNode(n *yaml.Node, path string, unresolved *[]string) {
+	if n.Kind != yaml.ScalarNode {
+		for _, child := range n.Content {
+			interpolateNode(child, path, unresolved)
+		}
+		return
+	}
+	if !strings.Contains(n.Value, "$") {
+		return
+	}
+
+	expanded := varPattern.ReplaceAllStringFunc(n.Value, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+		sub := varPattern.FindStringSubmatch(match)
+		name, hasDefault, def := sub[1], sub[2] != "", sub[3]
+		if value, ok := os.LookupEnv(name); ok && (value != "" || !hasDefault) {
+			return value
+		}
+		if hasDefault {
+			return def
+		}
+		*unresolved = append(*unresolved, fmt.Sprintf("  %s:%d: %s is not set", path, n.Line, name))
+		return match
+	})
+	if expanded == n.Value {
+		return
+	}
+	n.Value = expanded
+	// Let an unquoted ${PORT} resolve to an int (or bool, ...) like a literal would.
+	if n.Style == 0 {
+		n.Tag = ""
+	}
+}
+
+// escapeNode escapes every $ in the scalars under n as $$, so interpolateNode
+// reads a config written out by the exporters back unchanged.
+func escapeNode(n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode {
+		for _, child := range n.Content {
+			escapeNode(child)
+		}
+		return
+	}
+	n.Value = strings.ReplaceAll(n.Value, "$", "$$")
+}
+
+// mergeNodes deep-merges overlay onto base and returns the result. Mappings
+// merge key by key, and a null overlay value removes the key. env lists merge
+// by variable name. Any other value in overlay replaces the one in base.
+func mergeNodes(base, overlay *yaml.Node) *yaml.Node {
+	if base == nil || base.Kind != yaml.MappingNode || overlay.Kind != yaml.MappingNode {
+		return overlay
+	}
+
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		idx := mappingIndex(base, key.Value)
+
+		if value.ShortTag() == "!!null" {
+			if idx >= 0 {
+				base.Content = append(base.Content[:idx], base.Content[idx+2:]...)
+			}
+			continue
+		}
+		if idx < 0 {
+			base.Content = append(base.Content, key, value)
+			continue
+		}
+
+		existing := base.Content[idx+1]
+		if key.Value == "env" && existing.Kind == yaml.SequenceNode &&
+			value.Kind == yaml.SequenceNode {
+			base.Content[idx+1] = mergeEnvLists(existing, value)
+		} else {
+			base.Content[idx+1] = mergeNodes(existing, value)
+		}
+	}
+	return base
+}
+
+// mergeEnvLists merges two env lists by name: overlay entries replace base
+// entries with the same name, and new names are appended in overlay order.
+func mergeEnvLists(base, overlay *yaml.Node) *yaml.Node {
+	for _, entry := range overlay.Content {
+		name := envEntryName(entry)
+		replaced := false
+		if name != "" {
+			for i, existing := range base.Content {
+				if envEntryName(existing) == name {
+					base.Content[i] = entry
+					replaced = true
+					break
+				}
+			}
+		}
+		if !replaced {
+			base.Content = append(base.Content, entry)
+		}
+	}
+	return base
+}
+
+func envEntryName(n *yaml.Node) string {
+	if n.Kind != yaml.MappingNode {
+		return ""
+	}
+	if idx := mappingIndex(n, "name"); idx >= 0 {
+		return n.Content[idx+1].Value
+	}
+	return ""
+}
+
+// mappingIndex returns the index of key's key node in a mapping node, or -1.
+func mappingIndex(n *yaml.Node, key string) int {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,171 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeStackFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	return path
+}
+
+func TestLoadStackConfigOverlays(t *testing.T) {
+	dir := t.TempDir()
+	base := writeStackFile(t, dir, "stack.yaml", `organization: my-org
+project: dev
+stack-id: shop
+services:
+  api:
+    servicePort: 8080
+    image:
+      type: internal
+      name: api
+      tag: dev
+    resources:
+      memory: 256M
+      cpu: "0.5"
+    replicas: 1
+    env:
+      - name: LOG_LEVEL
+        value: debug
+      - name: REGION
+        value: eu
+  worker:
+    servicePort: 9000
+    image:
+      type: internal
+      name: worker
+      tag: dev
+    resources:
+      memory: 128M
+      cpu: "0.25"
+`)
+	prod := writeStackFile(t, dir, "prod.yaml", `project: prod
+services:
+  api:
+    image:
+      tag: "1.4.0"
+    replicas: 3
+    env:
+      - name: LOG_LEVEL
+        value: warn
+      - name: FEATURE_X
+        value: "on"
+  worker: ~
+`)
+
+	cfg, err := LoadStackConfig(base, prod)
+	if err != nil {
+		t.Fatalf("LoadStackConfig() error = %v", err)
+	}
+
+	if cfg.Project != "prod" || cfg.Organization != "my-org" {
+		t.Errorf("organization/project = %q/%q, want my-org/prod", cfg.Organization, cfg.Project)
+	}
+	if _, ok := cfg.Services["worker"]; ok {
+		t.Error("worker still present, want it removed by the null overlay value")
+	}
+
+	want := ServiceConfig{
+		ServicePort: 8080,
+		Image:       deployment.ImageSpec{Type: "internal", Name: "api", Tag: "1.4.0"},
+		Resources:   deployment.Resources{Memory: "256M", CPU: "0.5"},
+		Replicas:    3,
+		Env: []deployment.EnvVar{
+			{Name: "LOG_LEVEL", Value: "warn"},
+			{Name: "REGION", Value: "eu"},
+			{Name: "FEATURE_X", Value: "on"},
+		},
+	}
+	if diff := cmp.Diff(want, cfg.Services["api"]); diff != "" {
+		t.Errorf("api mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadStackConfigInterpolation(t *testing.T) {
+	t.Setenv("IAI_TEST_TAG", "2.0.1")
+	t.Setenv("IAI_TEST_PORT", "8443")
+	t.Setenv("IAI_TEST_EMPTY", "")
+
+	dir := t.TempDir()
+	path := writeStackFile(t, dir, "stack.yaml", `stack-id: shop-${IAI_TEST_ENV:-dev}
+services:
+  api:
+    servicePort: ${IAI_TEST_PORT}
+    image:
+      type: internal
+      name: api
+      tag: "${IAI_TEST_TAG}"
+    resources:
+      memory: ${IAI_TEST_EMPTY:-256M}
+      cpu: "1"
+    env:
+      - name: PRICE
+        value: $$5
+`)
+
+	cfg, err := LoadStackConfig(path)
+	if err != nil {
+		t.Fatalf("LoadStackConfig() error = %v", err)
+	}
+
+	if cfg.StackId != "shop-dev" {
+		t.Errorf("StackId = %q, want shop-dev", cfg.StackId)
+	}
+	api := cfg.Services["api"]
+	if api.ServicePort != 8443 {
+		t.Errorf("ServicePort = %d, want 8443", api.ServicePort)
+	}
+	if api.Image.Tag != "2.0.1" {
+		t.Errorf("Image.Tag = %q, want 2.0.1", api.Image.Tag)
+	}
+	if api.Resources.Memory != "256M" {
+		t.Errorf("Resources.Memory = %q, want the default for an empty variable", api.Resources.Memory)
+	}
+	if diff := cmp.Diff([]deployment.EnvVar{{Name: "PRICE", Value: "$5"}}, api.Env); diff != "" {
+		t.Errorf("Env mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadStackConfigUnresolvedVariables(t *testing.T) {
+	dir := t.TempDir()
+	base := writeStackFile(t, dir, "stack.yaml", `stack-id: shop
+project: ${IAI_TEST_MISSING_PROJECT}
+`)
+	overlay := writeStackFile(t, dir, "prod.yaml", `organization: my-org
+stack-id: ${IAI_TEST_MISSING_A}-${IAI_TEST_MISSING_B}
+`)
+
+	_, err := LoadStackConfig(base)
+	if err == nil {
+		t.Fatal("LoadStackConfig() expected error, got nil")
+	}
+	if want := base + ":2: IAI_TEST_MISSING_PROJECT is not set"; !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error containing %q, got: %v", want, err)
+	}
+
+	t.Setenv("IAI_TEST_MISSING_PROJECT", "dev")
+	_, err = LoadStackConfig(base, overlay)
+	if err == nil {
+		t.Fatal("LoadStackConfig() expected error, got nil")
+	}
+	for _, want := range []string{
+		"found 2 unresolved variables",
+		overlay + ":2: IAI_TEST_MISSING_A is not set",
+		overlay + ":2: IAI_TEST_MISSING_B is not set",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got: %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package files
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+)
+
+// Reference records that one stack resource uses another. Resources are
+// identified as "type/name", e.g. "service/api" or "database/orders-db".
+type Reference struct {
+	From string
+	To   string
+	Via  string // where the reference appears, e.g. "env DATABASE_URL"
+}
+
+// StackReferences finds the references between the resources of cfg:
+//   - secretRefs entries reference secrets;
+//   - agentConfig mcps entries reference mcps by id (or hostname);
+//   - agentConfig context entries (routines, policies, glossaries, ...) and
+//     prompt ids reference context items;
+//   - env values that mention a service, database, or mcp of the stack as a
+//     host name (e.g. "postgres://orders-db:5432/app") reference it.
+//
+// Secret and agentConfig references are reported even when the target isn't
+// declared in cfg. The result is sorted by From, then To.
+func StackReferences(cfg *StackConfig) []Reference {
+	var refs []Reference
+
+	hosts := make(map[string]string)
+	for name := range cfg.Services {
+		hosts[name] = "service/" + name
+	}
+	for name := range cfg.Databases {
+		hosts[name] = "database/" + name
+	}
+	for name := range cfg.Mcps {
+		hosts[name] = "mcp/" + name
+	}
+
+	envRefs := func(from string, env []deployment.EnvVar) {
+		for _, e := range env {
+			for host, to := range hosts {
+				if to != from && mentionsHost(e.Value, host) {
+					refs = append(refs, Reference{From: from, To: to, Via: "env " + e.Name})
+				}
+			}
+		}
+	}
+	secretRefs := func(from string, secretRefs []deployment.SecretRef) {
+		for _, ref := range secretRefs {
+			refs = append(refs, Reference{
+				From: from,
+				To:   "secret/" + ref.SecretName,
+				Via:  "secretRefs",
+			})
+		}
+	}
+
+	for name, svc := range cfg.Services {
+		from := "service/" + name
+		envRefs(from, svc.Env)
+		secretRefs(from, svc.SecretRefs)
+	}
+	for name, agent := range cfg.Agents {
+		from := "agent/" + name
+		envRefs(from, agent.Env)
+		secretRefs(from, agent.SecretRefs)
+		for _, mcp := range agentConfigMcps(agent.AgentConfig, cfg.Mcps) {
+			refs = append(refs, Reference{From: from, To: "mcp/" + mcp, Via: "agentConfig.mcps"})
+		}
+		refs = append(refs, agentConfigContext(from, agent.AgentConfig)...)
+	}
+	for name, mcp := range cfg.Mcps {
+		from := "mcp/" + name
+		envRefs(from, mcp.Env)
+		secretRefs(from, mcp.SecretRefs)
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].From != refs[j].From {
+			return refs[i].From < refs[j].From
+		}
+		if refs[i].To != refs[j].To {
+			return refs[i].To < refs[j].To
+		}
+		return refs[i].Via < refs[j].Via
+	})
+	return refs
+}
+
+// StackDependencies groups StackReferences by the referencing resource, for
+// ordering a sync. References to services are left out: services reach
+// each other at run time, whatever order they are synced in, and may
+// reference each other both ways.
+func StackDependencies(cfg *StackConfig) map[string][]string {
+	deps := make(map[string][]string)
+	for _, ref := range StackReferences(cfg) {
+		if strings.HasPrefix(ref.To, "service/") {
+			continue
+		}
+		deps[ref.From] = append(deps[ref.From], ref.To)
+	}
+	return deps
+}
+
+// agentConfigMcps returns the mcp names listed in an agentConfig's mcps
+// entries. An entry names an mcp by id; the hostname is used instead when
+// only it matches an mcp of the stack.
+func agentConfigMcps(agentConfig any, mcps map[string]McpConfig) []string {
+	m, ok := agentConfig.(map[string]any)
+	if !ok {
+		return nil
+	}
+	entries, ok := m["mcps"].([]any)
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for _, e := range entries {
+		entry, ok := e.(map[string]any)
+		if !ok {
+			continue
+		}
+		id, _ := entry["id"].(string)
+		hostname, _ := entry["hostname"].(string)
+		if _, declared := mcps[id]; !declared && hostname != "" {
+			if _, declared := mcps[hostname]; declared {
+				id = hostname
+			}
+		}
+		if id != "" {
+			names = append(names, id)
+		}
+	}
+	return names
+}
+
+// agentConfigContext returns the references from the agent from to the
+// context items its agentConfig names: the id of each entry of the context
+// routines, policies, glossaries, variables, and macros lists, and the
+// prompts of context.description.prompt_id and knowledge_base.prompt.id.
+func agentConfigContext(from string, agentConfig any) []Reference {
+	m, _ := agentConfig.(map[string]any)
+	context, _ := m["context"].(map[string]any)
+
+	var refs []Reference
+	for _, kind := range ContextKinds {
+		entries, _ := context[kind.Section].([]any)
+		for _, e := range entries {
+			entry, _ := e.(map[string]any)
+			if id, _ := entry["id"].(string); id != "" {
+				refs = append(refs, Reference{
+					From: from,
+					To:   kind.TypeName + "/" + id,
+					Via:  "agentConfig.context." + kind.Section,
+				})
+			}
+		}
+	}
+
+	description, _ := context["description"].(map[string]any)
+	if id, _ := description["prompt_id"].(string); id != "" {
+		refs = append(refs, Reference{
+			From: from,
+			To:   "prompt/" + id,
+			Via:  "agentConfig.context.description",
+		})
+	}
+	kb, _ := m["knowledge_base"].(map[string]any)
+	prompt, _ := kb["prompt"].(map[string]any)
+	if id, _ := prompt["id"].(string); id != "" {
+		refs = append(refs, Reference{
+			From: from,
+			To:   "prompt/" + id,
+			Via:  "agentConfig.knowledge_base",
+		})
+	}
+	return refs
+}
+
+// mentionsHost reports whether value contains host as a whole host name,
+// i.e. not as part of a longer name.
+func mentionsHost(value, host string) bool {
+	for i := 0; ; {
+		j := strings.Index(value[i:], host)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(host)
+		if (start == 0 || !isHostChar(value[start-1])) &&
+			(end == len(value) || !isHostChar(value[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isHostChar(c byte) bool {
+	return c == '-' || c == '_' ||
+		c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
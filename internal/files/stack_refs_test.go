@@ -0,0 +1,100 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStackReferences(t *testing.T) {
+	cfg := &StackConfig{
+		Services: map[string]ServiceConfig{
+			"api": {
+				Env: []deployment.EnvVar{
+					{Name: "DATABASE_URL", Value: "postgres://app@orders-db:5432/orders"},
+					{Name: "ARCHIVE_URL", Value: "postgres://orders-db-archive:5432/orders"},
+					{Name: "TOOLS", Value: "http://integration.internal:8080"},
+					{Name: "SELF_URL", Value: "http://api:8080"},
+				},
+				SecretRefs: []deployment.SecretRef{{SecretName: "api-keys"}},
+			},
+			"web": {
+				Env: []deployment.EnvVar{{Name: "API_URL", Value: "http://api:8080/v1"}},
+			},
+		},
+		Agents: map[string]AgentConfig{
+			"chat": {
+				AgentConfig: map[string]any{
+					"mcps": []any{
+						map[string]any{"id": "integration", "hostname": "integration"},
+						map[string]any{"id": "crm", "hostname": "crm-tools"},
+						map[string]any{"id": "external-search"},
+					},
+					"context": map[string]any{
+						"description": map[string]any{"prompt_id": "chat-system", "version": 1},
+						"routines":    []any{map[string]any{"id": "bonus", "version": 1}},
+						"policies":    []any{map[string]any{"id": "refunds", "version": 1}},
+					},
+					"knowledge_base": map[string]any{
+						"prompt": map[string]any{"id": "kb-rag", "version": 1},
+					},
+				},
+			},
+		},
+		Databases: map[string]DatabaseConfig{"orders-db": {}},
+		Mcps: map[string]McpConfig{
+			"integration": {
+				Env: []deployment.EnvVar{{Name: "DB_HOST", Value: "orders-db"}},
+			},
+			"crm-tools": {},
+		},
+	}
+
+	want := []Reference{
+		{From: "agent/chat", To: "mcp/crm-tools", Via: "agentConfig.mcps"},
+		{From: "agent/chat", To: "mcp/external-search", Via: "agentConfig.mcps"},
+		{From: "agent/chat", To: "mcp/integration", Via: "agentConfig.mcps"},
+		{From: "agent/chat", To: "policy/refunds", Via: "agentConfig.context.policies"},
+		{From: "agent/chat", To: "prompt/chat-system", Via: "agentConfig.context.description"},
+		{From: "agent/chat", To: "prompt/kb-rag", Via: "agentConfig.knowledge_base"},
+		{From: "agent/chat", To: "routine/bonus", Via: "agentConfig.context.routines"},
+		{From: "mcp/integration", To: "database/orders-db", Via: "env DB_HOST"},
+		{From: "service/api", To: "database/orders-db", Via: "env DATABASE_URL"},
+		{From: "service/api", To: "mcp/integration", Via: "env TOOLS"},
+		{From: "service/api", To: "secret/api-keys", Via: "secretRefs"},
+		{From: "service/web", To: "service/api", Via: "env API_URL"},
+	}
+	if diff := cmp.Diff(want, StackReferences(cfg)); diff != "" {
+		t.Errorf("StackReferences() mismatch (-want +got):\n%s", diff)
+	}
+
+	deps := StackDependencies(cfg)
+	if got := deps["service/web"]; len(got) != 0 {
+		t.Errorf("StackDependencies()[service/web] = %v, want no sync dependencies", got)
+	}
+}
+
+func TestMentionsHost(t *testing.T) {
+	tests := []struct {
+		value string
+		host  string
+		want  bool
+	}{
+		{"orders-db", "orders-db", true},
+		{"postgres://u@orders-db:5432/x", "orders-db", true},
+		{"http://orders-db.internal", "orders-db", true},
+		{"orders-db2", "orders-db", false},
+		{"my-orders-db", "orders-db", false},
+		{"orders-dbx orders-db", "orders-db", true},
+		{"", "orders-db", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := mentionsHost(tt.value, tt.host); got != tt.want {
+				t.Errorf("mentionsHost(%q, %q) = %v, want %v", tt.value, tt.host, got, tt.want)
+			}
+		})
+	}
+}
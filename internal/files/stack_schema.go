@@ -0,0 +1,106 @@
+package files
+
+import (
+	"maps"
+	"reflect"
+)
+
+// schemaHints adds constraints the Go types can't express, keyed by
+// "<Go type>.<yaml key>".
+var schemaHints = map[string]map[string]any{
+	"deployment.ImageSpec.type": {"enum": imageTypes},
+	"deployment.Resources.cpu": {
+		"pattern":     cpuPattern.String(),
+		"description": "CPU cores or millicores, e.g. 0.5, 2, or 500m",
+	},
+	"deployment.Resources.memory": {
+		"pattern":     sizePattern.String(),
+		"description": "Memory size, e.g. 512M or 1.5G",
+	},
+	"deployment.DatabaseStorageConfig.size": {
+		"pattern":     sizePattern.String(),
+		"description": "Storage size, e.g. 10G",
+	},
+	"deployment.McpAuthBody.type":  {"enum": mcpAuthTypes},
+	"files.McpConfig.type":         {"enum": mcpTypes},
+	"files.ContextItemConfig.type": {"enum": promptTypes},
+	"files.ServiceConfig.replicas": {
+		"description": "Fixed replica count; mutually exclusive with autoscaling",
+	},
+	"files.ServiceConfig.autoscaling": {
+		"description": "Autoscaling bounds; mutually exclusive with replicas",
+	},
+	"files.AgentConfig.agentConfig": {
+		"description": "Agent configuration; mcps entries name mcps of this file by id",
+	},
+}
+
+// StackSchema returns a JSON Schema (draft 2020-12) for stack files,
+// generated from the StackConfig types, for editor validation and
+// completion. Objects reject unknown keys, as 'iai stacks validate' does.
+// Numbers and booleans also accept a ${VAR} reference, which is expanded
+// before the file is decoded.
+func StackSchema() map[string]any {
+	schema := typeSchema(reflect.TypeOf(StackConfig{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "iai stack file"
+	return schema
+}
+
+func typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		props := make(map[string]any)
+		addStructProperties(t, props)
+		return map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+	case reflect.Map:
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem()),
+		}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return orVariable("boolean")
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return orVariable("integer")
+	case reflect.Float32, reflect.Float64:
+		return orVariable("number")
+	}
+	return map[string]any{}
+}
+
+func addStructProperties(t reflect.Type, props map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key, inline, ok := yamlKey(f)
+		switch {
+		case !ok:
+		case inline:
+			addStructProperties(f.Type, props)
+		default:
+			prop := typeSchema(f.Type)
+			maps.Copy(prop, schemaHints[t.String()+"."+key])
+			props[key] = prop
+		}
+	}
+}
+
+// orVariable accepts a value of typ or an unexpanded ${VAR} reference.
+func orVariable(typ string) map[string]any {
+	return map[string]any{
+		"anyOf": []any{
+			map[string]any{"type": typ},
+			map[string]any{"type": "string", "pattern": `^\$\{[A-Za-z_][A-Za-z0-9_]*(:-[^}]*)?\}$`},
+		},
+	}
+}
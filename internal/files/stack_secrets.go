@@ -0,0 +1,270 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/inputs"
+)
+
+// SecretConfig declares a secret in a stack file. Values never appear in the
+// stack file; they are resolved at sync and diff time from the sources below.
+// When several sources set the same key, later ones win: EnvFile, Command,
+// Keys, then Env.
+type SecretConfig struct {
+	// Keys lists keys whose values are read from environment variables of the
+	// same name. 'stacks get' exports secrets in this form.
+	Keys []string `yaml:"keys,omitempty"    json:"keys,omitempty"`
+	// Env maps keys to the environment variables holding their values.
+	Env map[string]string `yaml:"env,omitempty"     json:"env,omitempty"`
+	// EnvFile is a KEY=VALUE file, relative to the stack file.
+	EnvFile string `yaml:"envFile,omitempty" json:"envFile,omitempty"`
+	// Command runs with "sh -c" in the stack file's directory; its stdout is
+	// parsed as KEY=VALUE lines.
+	Command string `yaml:"command,omitempty" json:"command,omitempty"`
+
+	// Hashes holds a SHA-256 digest of each key's value once resolved or
+	// fetched, so diffs compare values without keeping or printing them.
+	Hashes map[string]string `yaml:"-" json:"-"`
+}
+
+func validateSecrets(cfg *StackConfig) error {
+	for name, secret := range cfg.Secrets {
+		if len(secret.Keys) == 0 && len(secret.Env) == 0 &&
+			secret.EnvFile == "" && secret.Command == "" {
+			return fmt.Errorf(
+				"secret %q has no value source; set keys, env, envFile, or command",
+				name,
+			)
+		}
+		for _, key := range secret.Keys {
+			if err := inputs.ValidateSecretKey(key); err != nil {
+				return fmt.Errorf("secret %q: %w", name, err)
+			}
+		}
+		for key := range secret.Env {
+			if err := inputs.ValidateSecretKey(key); err != nil {
+				return fmt.Errorf("secret %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveSecrets reads the value of every declared secret from its sources and
+// records the value hashes on cfg. baseDir is the stack file's directory.
+// Commands are run here, so only sync and diff call it.
+func ResolveSecrets(
+	ctx context.Context,
+	cfg *StackConfig,
+	baseDir string,
+) (map[string]map[string]string, error) {
+	names := make([]string, 0, len(cfg.Secrets))
+	for name := range cfg.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := make(map[string]map[string]string, len(names))
+	for _, name := range names {
+		secret := cfg.Secrets[name]
+		data, err := secret.resolve(ctx, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %w", name, err)
+		}
+		secret.Hashes = secretHashes(data)
+		cfg.Secrets[name] = secret
+		resolved[name] = data
+	}
+	return resolved, nil
+}
+
+func (s SecretConfig) resolve(ctx context.Context, baseDir string) (map[string]string, error) {
+	data := make(map[string]string)
+
+	if s.EnvFile != "" {
+		path := s.EnvFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		fileData, err := ParseEnvFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env file %s: %w", s.EnvFile, err)
+		}
+		maps.Copy(data, fileData)
+	}
+
+	if s.Command != "" {
+		var stdout, stderr bytes.Buffer
+		c := exec.CommandContext(ctx, "sh", "-c", s.Command)
+		c.Dir = baseDir
+		c.Stdout = &stdout
+		c.Stderr = &stderr
+		if err := c.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("command failed: %w: %s", err, msg)
+			}
+			return nil, fmt.Errorf("command failed: %w", err)
+		}
+		cmdData, err := ParseEnv(&stdout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse command output: %w", err)
+		}
+		maps.Copy(data, cmdData)
+	}
+
+	vars := make(map[string]string, len(s.Keys)+len(s.Env))
+	for _, key := range s.Keys {
+		vars[key] = key
+	}
+	maps.Copy(vars, s.Env)
+	for key, envVar := range vars {
+		value, ok := os.LookupEnv(envVar)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", envVar)
+		}
+		data[key] = value
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no keys resolved")
+	}
+	// Whatever their source, keys and values must be ones the API accepts.
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		if err := inputs.ValidateSecretKey(key); err != nil {
+			return nil, err
+		}
+		if err := inputs.ValidateSecretValue(key, data[key]); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func secretHashes(data map[string]string) map[string]string {
+	hashes := make(map[string]string, len(data))
+	for key, value := range data {
+		sum := sha256.Sum256([]byte(value))
+		hashes[key] = hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
+
+// SecretDataChanged reports whether live and local secret data differ in any
+// key or value. Values are compared by hash.
+func SecretDataChanged(live, local map[string]string) bool {
+	return len(secretChanges(
+		SecretConfig{Hashes: secretHashes(live)},
+		SecretConfig{Hashes: secretHashes(local)},
+	)) > 0
+}
+
+// secretChanges lists key-level changes between two secrets. Values are
+// never included, only whether a key was added, removed, or changed.
+func secretChanges(live, local SecretConfig) []fieldChange {
+	var changes []fieldChange
+	for key, hash := range local.Hashes {
+		liveHash, ok := live.Hashes[key]
+		switch {
+		case !ok:
+			changes = append(changes, fieldChange{path: key, new: "(set)"})
+		case liveHash != hash:
+			changes = append(changes, fieldChange{
+				path: key,
+				old:  "(old value)",
+				new:  "(new value)",
+			})
+		}
+	}
+	for key := range live.Hashes {
+		if _, ok := local.Hashes[key]; !ok {
+			changes = append(changes, fieldChange{path: key, old: "(set)"})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].path < changes[j].path
+	})
+	return changes
+}
+
+// ReferencedSecrets returns the sorted names of the secrets the config's
+// services and agents reference through secretRefs.
+func (c *StackConfig) ReferencedSecrets() []string {
+	set := make(map[string]bool)
+	for _, svc := range c.Services {
+		for _, ref := range svc.SecretRefs {
+			set[ref.SecretName] = true
+		}
+	}
+	for _, agent := range c.Agents {
+		for _, ref := range agent.SecretRefs {
+			set[ref.SecretName] = true
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FetchLiveSecrets fills live with the keys and value hashes of the named
+// secrets that exist. Secrets aren't tagged with a stack ID, so the caller
+// decides which ones belong to the stack; values are never kept.
+func FetchLiveSecrets(
+	ctx context.Context,
+	deployClient *deployment.DeploymentClient,
+	orgId, projectId string,
+	names []string,
+	live *StackConfig,
+) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	secrets, err := deployClient.ListSecrets(ctx, orgId, projectId)
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+	existing := make(map[string]bool, len(secrets))
+	for _, s := range secrets {
+		existing[s.Name] = true
+	}
+
+	if live.Secrets == nil {
+		live.Secrets = make(map[string]SecretConfig)
+	}
+	for _, name := range names {
+		if !existing[name] {
+			continue
+		}
+		secret, err := deployClient.GetSecret(ctx, orgId, projectId, name)
+		if err != nil {
+			return fmt.Errorf("failed to get secret %q: %w", name, err)
+		}
+		keys := append([]string(nil), secret.Keys...)
+		sort.Strings(keys)
+		// A key listed without a value can't be compared; an empty hash makes
+		// it show as changed rather than silently matching.
+		hashes := secretHashes(secret.Data)
+		for _, key := range keys {
+			if _, ok := hashes[key]; !ok {
+				hashes[key] = ""
+			}
+		}
+		live.Secrets[name] = SecretConfig{Keys: keys, Hashes: hashes}
+	}
+	return nil
+}
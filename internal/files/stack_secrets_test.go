@@ -0,0 +1,224 @@
+package files
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := os.WriteFile(
+		filepath.Join(tmpDir, "db.env"),
+		[]byte("DB_USER=app\nDB_PASSWORD=from-file\n"),
+		0o600,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DB_PASSWORD", "from-env")
+	t.Setenv("STRIPE_LIVE_KEY", "sk_live_123")
+
+	cfg := &StackConfig{
+		Secrets: map[string]SecretConfig{
+			"db": {
+				EnvFile: "db.env",
+				Keys:    []string{"DB_PASSWORD"},
+			},
+			"payments": {
+				Command: "echo TOKEN=from-command",
+				Env:     map[string]string{"STRIPE_KEY": "STRIPE_LIVE_KEY"},
+			},
+		},
+	}
+
+	got, err := ResolveSecrets(context.Background(), cfg, tmpDir)
+	if err != nil {
+		t.Fatalf("ResolveSecrets() error = %v", err)
+	}
+
+	want := map[string]map[string]string{
+		"db":       {"DB_USER": "app", "DB_PASSWORD": "from-env"},
+		"payments": {"TOKEN": "from-command", "STRIPE_KEY": "sk_live_123"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ResolveSecrets() mismatch (-want +got):\n%s", diff)
+	}
+	if hashes := cfg.Secrets["db"].Hashes; len(hashes) != 2 ||
+		hashes["DB_PASSWORD"] == "from-env" {
+		t.Errorf("Hashes = %v, want a digest per key", hashes)
+	}
+}
+
+func TestResolveSecretsErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		secret      SecretConfig
+		errContains string
+	}{
+		{
+			name:        "unset environment variable",
+			secret:      SecretConfig{Keys: []string{"IAI_TEST_UNSET_VAR"}},
+			errContains: `secret "s": environment variable IAI_TEST_UNSET_VAR is not set`,
+		},
+		{
+			name:        "failing command",
+			secret:      SecretConfig{Command: "echo boom >&2; exit 3"},
+			errContains: "command failed: exit status 3: boom",
+		},
+		{
+			name:        "invalid key",
+			secret:      SecretConfig{Env: map[string]string{"bad-key": "HOME"}},
+			errContains: `key name "bad-key" is not a valid environment variable name`,
+		},
+		{
+			name:        "empty value",
+			secret:      SecretConfig{Keys: []string{"IAI_TEST_EMPTY_VAR"}},
+			errContains: `value for key "IAI_TEST_EMPTY_VAR" cannot be empty`,
+		},
+		{
+			name:        "missing env file",
+			secret:      SecretConfig{EnvFile: "missing.env"},
+			errContains: "failed to read env file missing.env",
+		},
+	}
+
+	t.Setenv("IAI_TEST_EMPTY_VAR", "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &StackConfig{Secrets: map[string]SecretConfig{"s": tt.secret}}
+			_, err := ResolveSecrets(context.Background(), cfg, t.TempDir())
+			if err == nil {
+				t.Fatal("ResolveSecrets() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("expected error containing %q, got: %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestLoadStackConfigSecretValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		errContains string
+	}{
+		{
+			name: "no value source",
+			config: `secrets:
+  api-keys: {}
+`,
+			errContains: `secret "api-keys" has no value source`,
+		},
+		{
+			name: "invalid key",
+			config: `secrets:
+  api-keys:
+    keys: [not-valid]
+`,
+			errContains: `secret "api-keys": key name "not-valid" is not a valid environment variable name`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "stack.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadStackConfig(configFile)
+			if err == nil {
+				t.Fatal("LoadStackConfig() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("expected error containing %q, got: %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestDiffStackConfigsSecrets(t *testing.T) {
+	live := &StackConfig{
+		StackId: "t",
+		Secrets: map[string]SecretConfig{
+			"api-keys": {Hashes: secretHashes(map[string]string{
+				"KEEP":    "same",
+				"ROTATE":  "old-secret-value",
+				"REMOVED": "gone",
+			})},
+		},
+	}
+	local := &StackConfig{
+		StackId: "t",
+		Secrets: map[string]SecretConfig{
+			"api-keys": {Hashes: secretHashes(map[string]string{
+				"KEEP":   "same",
+				"ROTATE": "new-secret-value",
+				"ADDED":  "fresh",
+			})},
+			"db": {Hashes: secretHashes(map[string]string{"DB_PASSWORD": "pw"})},
+		},
+	}
+
+	d := DiffStackConfigs(local, live)
+
+	if diff := cmp.Diff([]string{"db"}, d.Secrets.Created); diff != "" {
+		t.Errorf("Created mismatch (-want +got):\n%s", diff)
+	}
+	if len(d.Secrets.Deleted) != 0 {
+		t.Errorf("Deleted = %v, want none", d.Secrets.Deleted)
+	}
+
+	var buf strings.Builder
+	if err := PrintStackDiffDetailed(&buf, local, live, d); err != nil {
+		t.Fatal(err)
+	}
+	want := `Stack: t
+
+  + secret db (create)
+
+  ~ secret api-keys (update)
+    + ADDED: (set)
+    - REMOVED
+    ROTATE: (old value) → (new value)
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSecretDataChanged(t *testing.T) {
+	live := map[string]string{"A": "1", "B": "2"}
+
+	if SecretDataChanged(live, map[string]string{"B": "2", "A": "1"}) {
+		t.Error("SecretDataChanged() = true for identical data, want false")
+	}
+	if !SecretDataChanged(live, map[string]string{"A": "1", "B": "3"}) {
+		t.Error("SecretDataChanged() = false for a changed value, want true")
+	}
+	if !SecretDataChanged(live, map[string]string{"A": "1"}) {
+		t.Error("SecretDataChanged() = false for a removed key, want true")
+	}
+}
+
+func TestReferencedSecrets(t *testing.T) {
+	cfg := &StackConfig{
+		Services: map[string]ServiceConfig{
+			"api": {SecretRefs: []deployment.SecretRef{{SecretName: "db"}, {SecretName: "api-keys"}}},
+		},
+		Agents: map[string]AgentConfig{
+			"bot": {SecretRefs: []deployment.SecretRef{{SecretName: "api-keys"}}},
+		},
+	}
+
+	if diff := cmp.Diff([]string{"api-keys", "db"}, cfg.ReferencedSecrets()); diff != "" {
+		t.Errorf("ReferencedSecrets() mismatch (-want +got):\n%s", diff)
+	}
+}
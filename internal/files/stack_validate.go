@@ -0,0 +1,597 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/inputs"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationIssue is a problem ValidateStackFile found in a stack file.
+type ValidationIssue struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Path    string `json:"path,omitempty"` // e.g. "services.api.autoscaling"
+	Message string `json:"message"`
+	// Warning marks issues that don't stop a sync but are likely mistakes.
+	Warning bool `json:"warning,omitempty"`
+}
+
+func (i ValidationIssue) String() string {
+	var b strings.Builder
+	if i.File != "" {
+		b.WriteString(i.File)
+		if i.Line > 0 {
+			fmt.Fprintf(&b, ":%d", i.Line)
+		}
+		b.WriteString(": ")
+	}
+	if i.Warning {
+		b.WriteString("warning: ")
+	}
+	if i.Path != "" {
+		b.WriteString(i.Path + ": ")
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// ValidateStackFile checks the stack file at path and its overlays without
+// contacting the server. Each file is decoded strictly: unknown keys and
+// values of the wrong type are reported with their file and line. The
+// merged config is then checked against the rules the server enforces
+// (e.g. replicas and autoscaling are mutually exclusive, quantities are
+// well-formed, images have a tag) and for references: agentConfig mcps must
+// name an mcp of the file, and secretRefs to secrets the file doesn't
+// declare are warned about. Secret sources are not resolved.
+//
+// The returned error is set only when a file can't be read or parsed at all.
+func ValidateStackFile(path string, overlays ...string) ([]ValidationIssue, error) {
+	var issues []ValidationIssue
+	origins := make(map[*yaml.Node]string)
+
+	var root *yaml.Node
+	for _, file := range append([]string{path}, overlays...) {
+		node, err := loadStackNode(file, origins)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, checkNode(node, origins)...)
+		if root == nil {
+			root = node
+		} else {
+			root = mergeNodes(root, node)
+		}
+	}
+	if len(issues) > 0 {
+		// The merged config can't be trusted until every file decodes.
+		return issues, nil
+	}
+
+	cfg, err := decodeStackConfig(root, StackBaseDir(path))
+	if err != nil {
+		return []ValidationIssue{{File: path, Message: err.Error()}}, nil
+	}
+
+	for _, p := range checkStackRules(cfg) {
+		issue := p.issue
+		if node := nodeAt(root, p.path); node != nil {
+			issue.File, issue.Line = origins[node], node.Line
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// recordOrigin records file as the origin of n and every node under it; a nil
+// origins map records nothing.
+func recordOrigin(n *yaml.Node, file string, origins map[*yaml.Node]string) {
+	if origins == nil {
+		return
+	}
+	origins[n] = file
+	for _, child := range n.Content {
+		recordOrigin(child, file, origins)
+	}
+}
+
+// checkNode reports the unknown keys and mistyped values of a stack file, or
+// of a stack directory, with the file each of them was read from.
+func checkNode(root *yaml.Node, origins map[*yaml.Node]string) []ValidationIssue {
+	c := &nodeChecker{origins: origins}
+	c.checkFields(root, reflect.TypeOf(StackConfig{}), nil, "")
+
+	// Decode the content of each file on its own, innermost first, so each
+	// type error is reported once, against the file it is in.
+	seen := make(map[string]bool)
+	for i := len(c.roots) - 1; i >= 0; i-- {
+		r := c.roots[i]
+		var typeErr *yaml.TypeError
+		err := r.node.Decode(reflect.New(r.typ).Interface())
+		if !errors.As(err, &typeErr) {
+			if err != nil {
+				c.issues = append(c.issues, ValidationIssue{File: r.file, Message: err.Error()})
+			}
+			continue
+		}
+		for _, e := range typeErr.Errors {
+			if seen[e] {
+				continue
+			}
+			seen[e] = true
+			issue := ValidationIssue{File: r.file, Message: e}
+			if rest, ok := strings.CutPrefix(e, "line "); ok {
+				if num, msg, ok := strings.Cut(rest, ": "); ok {
+					if line, err := strconv.Atoi(num); err == nil {
+						issue.Line, issue.Message = line, msg
+					}
+				}
+			}
+			c.issues = append(c.issues, issue)
+		}
+	}
+
+	issues := c.issues
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues
+}
+
+// nodeChecker collects the issues checkNode finds, and the nodes the content
+// of each file starts at, with the Go types that decode them.
+type nodeChecker struct {
+	origins map[*yaml.Node]string
+	issues  []ValidationIssue
+	roots   []fileRoot
+}
+
+type fileRoot struct {
+	node *yaml.Node
+	typ  reflect.Type
+	file string
+}
+
+func (c *nodeChecker) report(n *yaml.Node, path []string, msg string) {
+	c.issues = append(c.issues, ValidationIssue{
+		File: c.origins[n], Line: n.Line, Path: joinPath(path), Message: msg,
+	})
+}
+
+// checkFields walks n alongside the Go type t that decodes it and reports
+// every mapping key t has no field for. Values of the wrong kind are left to
+// the decoder's own errors. parentFile is the file n's parent was read from.
+func (c *nodeChecker) checkFields(n *yaml.Node, t reflect.Type, path []string, parentFile string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if file := c.origins[n]; file != parentFile {
+		c.roots = append(c.roots, fileRoot{node: n, typ: t, file: file})
+		parentFile = file
+	}
+	if n.ShortTag() == "!!null" {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			fieldPath := append(path[:len(path):len(path)], key.Value)
+			ft, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("unknown field %q", key.Value)
+				if s := suggestField(key.Value, fields); s != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", s)
+				}
+				c.report(key, fieldPath, msg)
+				continue
+			}
+			c.checkFields(value, ft, fieldPath, parentFile)
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			fieldPath := append(path[:len(path):len(path)], n.Content[i].Value)
+			c.checkFields(n.Content[i+1], t.Elem(), fieldPath, parentFile)
+		}
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range n.Content {
+			itemPath := append(path[:len(path):len(path)], fmt.Sprintf("[%d]", i))
+			c.checkFields(item, t.Elem(), itemPath, parentFile)
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of struct t to their field types.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key, inline, ok := yamlKey(f)
+		switch {
+		case !ok:
+		case inline:
+			maps.Copy(fields, yamlFields(f.Type))
+		default:
+			fields[key] = f.Type
+		}
+	}
+	return fields
+}
+
+// yamlKey returns the key yaml.v3 decodes field f from: its yaml tag name, or
+// the lowercased field name. inline reports an ",inline" field, and ok is
+// false for fields yaml.v3 ignores.
+func yamlKey(f reflect.StructField) (key string, inline, ok bool) {
+	if !f.IsExported() {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return "", false, false
+	}
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	return name, strings.Contains(opts, "inline"), true
+}
+
+// suggestField returns the known field closest to key, if it is close enough
+// to be a likely typo.
+func suggestField(key string, fields map[string]reflect.Type) string {
+	best, bestDist := "", 3
+	for name := range fields {
+		if strings.EqualFold(name, key) {
+			return name
+		}
+		if d := editDistance(strings.ToLower(key), strings.ToLower(name)); d < bestDist ||
+			(d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	if bestDist > 2 {
+		return ""
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// joinPath renders path segments as "services.api.env[0].name".
+func joinPath(path []string) string {
+	var b strings.Builder
+	for i, seg := range path {
+		if i > 0 && !strings.HasPrefix(seg, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(seg)
+	}
+	return b.String()
+}
+
+// nodeAt returns the node of root that path names, the key of a mapping
+// entry rather than its value, so an issue about a whole block points at its
+// first line. An issue about a missing field points at its parent.
+func nodeAt(root *yaml.Node, path []string) *yaml.Node {
+	n, at := root, root
+	for _, seg := range path {
+		switch {
+		case n.Kind == yaml.SequenceNode && strings.HasPrefix(seg, "["):
+			i, err := strconv.Atoi(strings.Trim(seg, "[]"))
+			if err != nil || i >= len(n.Content) {
+				return at
+			}
+			n, at = n.Content[i], n.Content[i]
+		case n.Kind == yaml.MappingNode:
+			i := mappingIndex(n, seg)
+			if i < 0 {
+				return at
+			}
+			n, at = n.Content[i+1], n.Content[i]
+		default:
+			return at
+		}
+	}
+	return at
+}
+
+type ruleIssue struct {
+	path  []string
+	issue ValidationIssue
+}
+
+// rules collects the issues of checkStackRules under a path prefix.
+type rules struct {
+	issues *[]ruleIssue
+	path   []string
+}
+
+func (r rules) at(segs ...string) rules {
+	return rules{issues: r.issues, path: append(r.path[:len(r.path):len(r.path)], segs...)}
+}
+
+func (r rules) errorf(format string, args ...any) {
+	*r.issues = append(*r.issues, ruleIssue{
+		path:  r.path,
+		issue: ValidationIssue{Path: joinPath(r.path), Message: fmt.Sprintf(format, args...)},
+	})
+}
+
+func (r rules) warnf(format string, args ...any) {
+	*r.issues = append(*r.issues, ruleIssue{
+		path: r.path,
+		issue: ValidationIssue{
+			Path: joinPath(r.path), Message: fmt.Sprintf(format, args...), Warning: true,
+		},
+	})
+}
+
+var (
+	// cpuPattern matches cores ("0.5", "2") or millicores ("500m").
+	cpuPattern = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?|[0-9]+m)$`)
+	// sizePattern matches memory and storage sizes, e.g. "512M", "1.5G", "10Gi".
+	sizePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([KMGT]i?)$`)
+)
+
+const maxPortNumber = 65535
+
+var (
+	imageTypes   = []string{"internal", "external", "platform"}
+	mcpTypes     = []string{"internal", "external"}
+	mcpAuthTypes = []string{"bearer", "api_key", "custom", "none"}
+	promptTypes  = []string{"text", "chat"}
+)
+
+// checkStackRules checks the merged config for mistakes the server would
+// reject, and for dangling references.
+func checkStackRules(cfg *StackConfig) []ruleIssue {
+	var issues []ruleIssue
+	r := rules{issues: &issues}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Services)) {
+		svc, sr := cfg.Services[name], r.at("services", name)
+		checkPort(sr.at("servicePort"), svc.ServicePort, true)
+		checkImage(sr.at("image"), svc.Image)
+		checkResources(sr.at("resources"), svc.Resources)
+		if svc.Replicas > 0 && svc.Autoscaling != nil {
+			sr.errorf("replicas and autoscaling are mutually exclusive; set one of them")
+		}
+		if svc.Replicas < 0 {
+			sr.at("replicas").errorf("must not be negative")
+		}
+		if as := svc.Autoscaling; as != nil {
+			ar := sr.at("autoscaling")
+			if as.MaxReplicas < 1 {
+				ar.errorf("maxReplicas is required and must be at least 1")
+			} else if as.MinReplicas > as.MaxReplicas {
+				ar.errorf("minReplicas (%d) is greater than maxReplicas (%d)",
+					as.MinReplicas, as.MaxReplicas)
+			}
+			checkPercentage(ar.at("cpuPercentage"), as.CPUPercentage)
+			checkPercentage(ar.at("memoryPercentage"), as.MemoryPercentage)
+		}
+		if hc := svc.Healthcheck; hc != nil && hc.Path != "" && !strings.HasPrefix(hc.Path, "/") {
+			sr.at("healthcheck", "path").errorf("must start with \"/\"")
+		}
+		checkSchedule(sr.at("schedule"), svc.Schedule)
+		checkEnv(sr.at("env"), svc.Env)
+		checkSecretRefs(sr.at("secretRefs"), svc.SecretRefs, cfg.Secrets)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Agents)) {
+		agent, ar := cfg.Agents[name], r.at("agents", name)
+		if agent.Id == "" {
+			ar.at("id").errorf("is required")
+		}
+		if agent.Version == "" {
+			ar.at("version").errorf("is required")
+		}
+		for _, mcp := range agentConfigMcps(agent.AgentConfig, cfg.Mcps) {
+			if _, ok := cfg.Mcps[mcp]; !ok {
+				ar.at("agentConfig", "mcps").errorf("mcp %q is not declared in mcps", mcp)
+			}
+		}
+		checkSchedule(ar.at("schedule"), agent.Schedule)
+		checkEnv(ar.at("env"), agent.Env)
+		checkSecretRefs(ar.at("secretRefs"), agent.SecretRefs, cfg.Secrets)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Databases)) {
+		db, dr := cfg.Databases[name], r.at("databases", name)
+		if db.Instances < 1 {
+			dr.at("instances").errorf("must be at least 1")
+		}
+		checkResources(dr.at("resources"), db.Resources)
+		switch {
+		case db.Storage.Size == "":
+			dr.at("storage", "size").errorf("is required")
+		case !sizePattern.MatchString(db.Storage.Size):
+			dr.at("storage", "size").errorf("invalid size %q (e.g. 10G or 10Gi)", db.Storage.Size)
+		}
+		if b := db.Backup; b != nil && (b.Schedule == "") != (b.RetentionPolicy == "") {
+			dr.at("backup").errorf("schedule and retentionpolicy must be set together")
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Mcps)) {
+		checkMcp(r.at("mcps", name), cfg.Mcps[name], cfg.Secrets)
+	}
+
+	for _, kind := range ContextKinds {
+		items := cfg.ContextItems(kind.Section)
+		for _, name := range slices.Sorted(maps.Keys(items)) {
+			item, ir := items[name], r.at(kind.Section, name)
+			if item.Content == "" {
+				ir.errorf("content is empty; set content or file")
+			}
+			if item.Type != "" && kind.Section != "prompts" {
+				ir.at("type").errorf("only applies to prompts")
+			}
+			checkOneOf(ir.at("type"), item.Type, promptTypes)
+		}
+	}
+
+	return issues
+}
+
+func checkMcp(r rules, mcp McpConfig, secrets map[string]SecretConfig) {
+	mcpType := mcp.Type
+	if mcpType == "" {
+		mcpType = "internal"
+		if mcp.CatalogID != "" || mcp.EndpointURL != "" {
+			mcpType = "external"
+		}
+	}
+	checkOneOf(r.at("type"), mcp.Type, mcpTypes)
+
+	switch mcpType {
+	case "internal":
+		checkPort(r.at("port"), mcp.Port, true)
+		checkImage(r.at("image"), mcp.Image)
+		checkResources(r.at("resources"), mcp.Resources)
+		if mcp.EndpointURL != "" || mcp.CatalogID != "" {
+			r.errorf("endpointUrl and catalogId only apply to external mcps")
+		}
+	case "external":
+		if mcp.EndpointURL == "" && mcp.CatalogID == "" {
+			r.errorf("external mcps need endpointUrl or catalogId")
+		}
+		if len(mcp.Env) > 0 || len(mcp.SecretRefs) > 0 || mcp.Path != "" || mcp.Image.Name != "" {
+			r.errorf("env, secretRefs, path, and image don't apply to external mcps")
+		}
+	}
+	checkEnv(r.at("env"), mcp.Env)
+	checkSecretRefs(r.at("secretRefs"), mcp.SecretRefs, secrets)
+
+	ar := r.at("auth")
+	checkOneOf(ar.at("type"), mcp.Auth.Type, mcpAuthTypes)
+	switch mcp.Auth.Type {
+	case "bearer", "api_key":
+		if mcp.Auth.Credential == "" {
+			ar.at("credential").errorf("is required for auth type %q", mcp.Auth.Type)
+		}
+	case "none":
+		if mcp.Auth.Credential != "" {
+			ar.at("credential").errorf("must not be set for auth type \"none\"")
+		}
+	}
+}
+
+func checkPort(r rules, port int, required bool) {
+	switch {
+	case port == 0 && required:
+		r.errorf("is required")
+	case port < 0 || port > maxPortNumber:
+		r.errorf("must be between 1 and %d", maxPortNumber)
+	}
+}
+
+func checkImage(r rules, image deployment.ImageSpec) {
+	if image.Name == "" {
+		r.at("name").errorf("is required")
+	}
+	if image.Tag == "" {
+		r.at("tag").errorf("is required")
+	}
+	checkOneOf(r.at("type"), image.Type, imageTypes)
+}
+
+func checkResources(r rules, res deployment.Resources) {
+	if res.CPU != "" && !cpuPattern.MatchString(res.CPU) {
+		r.at("cpu").errorf("invalid CPU quantity %q (e.g. 0.5, 2, or 500m)", res.CPU)
+	}
+	if res.Memory != "" && !sizePattern.MatchString(res.Memory) {
+		r.at("memory").errorf("invalid memory quantity %q (e.g. 512M or 1.5G)", res.Memory)
+	}
+}
+
+func checkPercentage(r rules, pct *int) {
+	if pct != nil && (*pct < 1 || *pct > 100) {
+		r.errorf("must be between 1 and 100")
+	}
+}
+
+func checkSchedule(r rules, s *deployment.Schedule) {
+	if s != nil && s.Uptime != "" && s.Downtime != "" {
+		r.errorf("uptime and downtime are mutually exclusive")
+	}
+}
+
+func checkEnv(r rules, env []deployment.EnvVar) {
+	seen := make(map[string]bool)
+	for i, e := range env {
+		er := r.at(fmt.Sprintf("[%d]", i), "name")
+		switch {
+		case e.Name == "":
+			er.errorf("is required")
+		case inputs.ValidateSecretKey(e.Name) != nil:
+			er.errorf("%q is not a valid environment variable name", e.Name)
+		case seen[e.Name]:
+			er.errorf("%s is set more than once", e.Name)
+		}
+		seen[e.Name] = true
+	}
+}
+
+func checkSecretRefs(r rules, refs []deployment.SecretRef, secrets map[string]SecretConfig) {
+	for i, ref := range refs {
+		rr := r.at(fmt.Sprintf("[%d]", i), "secretName")
+		if ref.SecretName == "" {
+			rr.errorf("is required")
+			continue
+		}
+		if _, ok := secrets[ref.SecretName]; !ok {
+			rr.warnf("secret %q is not declared in secrets; it must already exist", ref.SecretName)
+		}
+	}
+}
+
+func checkOneOf(r rules, value string, allowed []string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	if value != "" {
+		r.errorf("must be one of %s, got %q", strings.Join(allowed, ", "), value)
+	}
+}
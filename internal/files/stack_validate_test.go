@@ -0,0 +1,193 @@
+package files
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateStackFileReportsUnknownFieldsWithLines(t *testing.T) {
+	dir := t.TempDir()
+	base := writeStackFile(t, dir, "stack.yaml", `stack-id: shop
+services:
+  api:
+    servicePort: 8080
+    replica: 2
+    image:
+      name: api
+      tag: v1
+  worker:
+    servicePort: nine
+    image: {name: worker, tag: v1}
+`)
+	prod := writeStackFile(t, dir, "prod.yaml", `services:
+  api:
+    resources:
+      memroy: 1G
+`)
+
+	issues, err := ValidateStackFile(base, prod)
+	if err != nil {
+		t.Fatalf("ValidateStackFile() error = %v", err)
+	}
+
+	want := []string{
+		base + `:5: services.api.replica: unknown field "replica" (did you mean "replicas"?)`,
+		base + ":10: cannot unmarshal !!str `nine` into int",
+		prod + `:4: services.api.resources.memroy: unknown field "memroy" (did you mean "memory"?)`,
+	}
+	if diff := cmp.Diff(want, issueStrings(issues)); diff != "" {
+		t.Errorf("issues mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidateStackFileChecksRules(t *testing.T) {
+	dir := t.TempDir()
+	base := writeStackFile(t, dir, "stack.yaml", `stack-id: shop
+secrets:
+  db-creds:
+    keys: [DB_PASSWORD]
+services:
+  api:
+    servicePort: 8080
+    image: {type: internal, name: api}
+    resources: {cpu: 2 cores, memory: 512M}
+    replicas: 2
+    autoscaling: {minReplicas: 1, maxReplicas: 4}
+    env:
+      - {name: MODE, value: a}
+      - {name: MODE, value: b}
+    secretRefs:
+      - secretName: db-creds
+      - secretName: api-keys
+agents:
+  chat:
+    id: support
+    version: "1"
+    agentConfig:
+      mcps:
+        - id: search
+databases:
+  orders:
+    instances: 1
+    storage: {size: 10GB}
+mcps:
+  tools:
+    endpointUrl: https://tools.example.com/mcp
+    auth: {type: bearer}
+`)
+
+	issues, err := ValidateStackFile(base)
+	if err != nil {
+		t.Fatalf("ValidateStackFile() error = %v", err)
+	}
+
+	want := []string{
+		base + ":8: services.api.image.tag: is required",
+		base + `:9: services.api.resources.cpu: invalid CPU quantity "2 cores" (e.g. 0.5, 2, or 500m)`,
+		base + ":6: services.api: replicas and autoscaling are mutually exclusive; set one of them",
+		base + ":14: services.api.env[1].name: MODE is set more than once",
+		base + `:17: warning: services.api.secretRefs[1].secretName: secret "api-keys" is not declared in secrets; it must already exist`,
+		base + `:23: agents.chat.agentConfig.mcps: mcp "search" is not declared in mcps`,
+		base + `:28: databases.orders.storage.size: invalid size "10GB" (e.g. 10G or 10Gi)`,
+		base + `:32: mcps.tools.auth.credential: is required for auth type "bearer"`,
+	}
+	if diff := cmp.Diff(want, issueStrings(issues)); diff != "" {
+		t.Errorf("issues mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidateStackFileAcceptsValidFile(t *testing.T) {
+	dir := t.TempDir()
+	base := writeStackFile(t, dir, "stack.yaml", `stack-id: shop
+services:
+  api:
+    servicePort: ${PORT:-8080}
+    image: {type: internal, name: api, tag: v1}
+    resources: {cpu: 500m, memory: 1.5G}
+    autoscaling: {minReplicas: 1, maxReplicas: 3, cpuPercentage: 80}
+    healthcheck: {path: /healthz}
+routines:
+  greet:
+    content: Say hello.
+`)
+
+	issues, err := ValidateStackFile(base)
+	if err != nil {
+		t.Fatalf("ValidateStackFile() error = %v", err)
+	}
+	if len(issues) > 0 {
+		t.Errorf("ValidateStackFile() issues = %v, want none", issueStrings(issues))
+	}
+}
+
+func TestStackSchema(t *testing.T) {
+	data, err := json.Marshal(StackSchema())
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var schema struct {
+		Properties map[string]struct {
+			AdditionalProperties struct {
+				Properties           map[string]json.RawMessage `json:"properties"`
+				AdditionalProperties bool                       `json:"additionalProperties"`
+			} `json:"additionalProperties"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if _, ok := schema.Properties["stack-id"]; !ok {
+		t.Error("schema has no stack-id property")
+	}
+	service := schema.Properties["services"].AdditionalProperties
+	if service.AdditionalProperties {
+		t.Error("services allow unknown keys, want additionalProperties false")
+	}
+	for _, key := range []string{"servicePort", "image", "replicas", "autoscaling", "secretRefs"} {
+		if _, ok := service.Properties[key]; !ok {
+			t.Errorf("service schema has no %q property", key)
+		}
+	}
+	if got := string(service.Properties["image"]); !strings.Contains(got, `"enum":["internal","external","platform"]`) {
+		t.Errorf("image schema = %s, want an enum of image types", got)
+	}
+}
+
+func issueStrings(issues []ValidationIssue) []string {
+	var out []string
+	for _, issue := range issues {
+		out = append(out, issue.String())
+	}
+	return out
+}
+
+func TestValidateStackFileReportsDirectoryFiles(t *testing.T) {
+	dir := t.TempDir()
+	mkdirs(t, dir, "agents", "services")
+	writeStackFile(t, dir, "stack.yaml", "stack-id: shop\n")
+	agent := writeStackFile(t, dir, "agents/support.yaml", `id: support
+verison: "1"
+agentConfig: !include support.txt
+`)
+	writeStackFile(t, dir, "agents/support.txt", "Be helpful.\n")
+	svc := writeStackFile(t, dir, "services/api.yaml", `servicePort: http
+image: {name: api, tag: v1}
+`)
+
+	issues, err := ValidateStackFile(dir)
+	if err != nil {
+		t.Fatalf("ValidateStackFile() error = %v", err)
+	}
+
+	want := []string{
+		agent + `:2: agents.support.verison: unknown field "verison" (did you mean "version"?)`,
+		svc + ":1: cannot unmarshal !!str `http` into int",
+	}
+	if diff := cmp.Diff(want, issueStrings(issues)); diff != "" {
+		t.Errorf("issues mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
 	"gopkg.in/yaml.v3"
 	"znkr.io/diff/textdiff"
 )
@@ -53,8 +54,55 @@ func PrintYAMLDiff(out io.Writer, labelA string, a any, labelB string, b any) er
 		return nil
 	}
 
+	printUnifiedDiff(out, labelA, string(yamlA), labelB, string(yamlB), IsTerminal(out))
+	return nil
+}
+
+// PrintStackDiffUnified prints a unified YAML diff of each changed resource
+// of a stack, from its live document to its local one.
+func PrintStackDiffUnified(out io.Writer, stackID string, docs []files.ResourceDocuments) error {
+	if len(docs) == 0 {
+		fmt.Fprintln(out, "No differences found.")
+		return nil
+	}
+
+	color := IsTerminal(out)
+	fmt.Fprintf(out, "Stack: %s\n", stackID)
+	for _, doc := range docs {
+		path := doc.Type + "/" + doc.Name
+		labelA, labelB := "live/"+path, "local/"+path
+		yamlA, err := documentYAML(doc.Live)
+		if err != nil {
+			return fmt.Errorf("failed to marshal live %s %s: %w", doc.Type, doc.Name, err)
+		}
+		yamlB, err := documentYAML(doc.Local)
+		if err != nil {
+			return fmt.Errorf("failed to marshal local %s %s: %w", doc.Type, doc.Name, err)
+		}
+		switch doc.Action {
+		case "create":
+			labelA = "/dev/null"
+		case "delete":
+			labelB = "/dev/null"
+		}
+		fmt.Fprintln(out)
+		printUnifiedDiff(out, labelA, yamlA, labelB, yamlB, color)
+	}
+	return nil
+}
+
+// documentYAML marshals a resource document; a missing one is empty.
+func documentYAML(doc any) (string, error) {
+	if doc == nil {
+		return "", nil
+	}
+	data, err := yaml.Marshal(doc)
+	return string(data), err
+}
+
+func printUnifiedDiff(out io.Writer, labelA, a, labelB, b string, color bool) {
 	var opts []textdiff.Option
-	if IsTerminal(out) {
+	if color {
 		opts = append(opts, textdiff.TerminalColors())
 		fmt.Fprintf(out, "%s--- %s%s\n", colorRed, labelA, colorReset)
 		fmt.Fprintf(out, "%s+++ %s%s\n", colorGreen, labelB, colorReset)
@@ -63,7 +111,5 @@ func PrintYAMLDiff(out io.Writer, labelA string, a any, labelB string, b any) er
 		fmt.Fprintf(out, "+++ %s\n", labelB)
 	}
 
-	diff := textdiff.Unified(string(yamlA), string(yamlB), opts...)
-	fmt.Fprint(out, diff)
-	return nil
+	fmt.Fprint(out, textdiff.Unified(a, b, opts...))
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -201,3 +202,52 @@ func TestPrintYAMLDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintStackDiffUnified(t *testing.T) {
+	docs := []files.ResourceDocuments{
+		{
+			Type: "service", Name: "api", Action: "update",
+			Live:  map[string]any{"replicas": 1, "servicePort": 8080},
+			Local: map[string]any{"replicas": 2, "servicePort": 8080},
+		},
+		{
+			Type: "database", Name: "db", Action: "create",
+			Local: map[string]any{"instances": 1},
+		},
+		{
+			Type: "mcp", Name: "tools", Action: "delete",
+			Live: map[string]any{"type": "external"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := PrintStackDiffUnified(&buf, "shop", docs); err != nil {
+		t.Fatalf("PrintStackDiffUnified() error = %v", err)
+	}
+
+	want := "Stack: shop\n" +
+		"\n--- live/service/api\n+++ local/service/api\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		"-replicas: 1\n" +
+		"+replicas: 2\n" +
+		" servicePort: 8080\n" +
+		"\n--- /dev/null\n+++ local/database/db\n" +
+		"@@ -1,0 +1,1 @@\n" +
+		"+instances: 1\n" +
+		"\n--- live/mcp/tools\n+++ /dev/null\n" +
+		"@@ -1,1 +1,0 @@\n" +
+		"-type: external\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPrintStackDiffUnifiedNoChanges(t *testing.T) {
+	var buf bytes.Buffer
+	if err := PrintStackDiffUnified(&buf, "shop", nil); err != nil {
+		t.Fatalf("PrintStackDiffUnified() error = %v", err)
+	}
+	if got := buf.String(); got != "No differences found.\n" {
+		t.Errorf("got %q, want %q", got, "No differences found.\n")
+	}
+}
@@ -72,6 +72,8 @@ type LogFormatOptions struct {
 	AllFields  bool
 	CNPGFormat bool
 	Timestamps bool
+	// Filter drops the entries it doesn't keep; nil keeps all of them.
+	Filter *LogFilter
 }
 
 // Informational messages are written to stderr so they don't pollute
@@ -103,6 +105,9 @@ func PrintLogStream(
 		if len(line) == 0 {
 			continue
 		}
+		if opts.Filter != nil && !opts.Filter.keep(line, opts.CNPGFormat) {
+			continue
+		}
 
 		if opts.Raw {
 			rawLine := line
@@ -159,9 +164,49 @@ func formatLogLine(
 	useColor bool,
 	opts LogFormatOptions,
 ) (string, string) {
+	parsed, ok := parseStructuredLine(line, opts.CNPGFormat)
+	if !ok {
+		return line, ""
+	}
+
+	var extraKeys []string
+	for k := range parsed.fields {
+		if !parsed.excluded[k] && !standardFields[k] {
+			extraKeys = append(extraKeys, k)
+		}
+	}
+	sort.Strings(extraKeys)
+
+	var b strings.Builder
+	if parsed.level != "" {
+		b.WriteString(formatLevel(parsed.level, useColor))
+	}
+	b.WriteString(parsed.msg)
+
+	showFields := opts.Fields
+	if opts.AllFields && len(showFields) == 0 {
+		showFields = extraKeys
+	}
+	extras := formatExtras(parsed.fields, showFields, useColor)
+
+	return b.String(), extras
+}
+
+// structuredLine is a JSON log line with its level and message extracted.
+type structuredLine struct {
+	fields   map[string]any
+	level    string
+	msg      string
+	excluded map[string]bool
+}
+
+// parseStructuredLine extracts the level and message of a JSON log line,
+// from its CNPG record when cnpg is set. It fails for lines that aren't JSON
+// objects or have neither.
+func parseStructuredLine(line string, cnpg bool) (structuredLine, bool) {
 	var fields map[string]any
 	if err := json.Unmarshal([]byte(line), &fields); err != nil {
-		return line, ""
+		return structuredLine{}, false
 	}
 
 	level := extractString(fields, "level")
@@ -171,7 +216,7 @@ func formatLogLine(
 	}
 
 	excluded := standardFields
-	if opts.CNPGFormat {
+	if cnpg {
 		if rec, ok := fields["record"].(map[string]any); ok {
 			if m := extractString(rec, "message"); m != "" {
 				msg = m
@@ -184,30 +229,9 @@ func formatLogLine(
 	}
 
 	if level == "" && msg == "" {
-		return line, ""
+		return structuredLine{}, false
 	}
-
-	var extraKeys []string
-	for k := range fields {
-		if !excluded[k] && !standardFields[k] {
-			extraKeys = append(extraKeys, k)
-		}
-	}
-	sort.Strings(extraKeys)
-
-	var b strings.Builder
-	if level != "" {
-		b.WriteString(formatLevel(level, useColor))
-	}
-	b.WriteString(msg)
-
-	showFields := opts.Fields
-	if opts.AllFields && len(showFields) == 0 {
-		showFields = extraKeys
-	}
-	extras := formatExtras(fields, showFields, useColor)
-
-	return b.String(), extras
+	return structuredLine{fields: fields, level: level, msg: msg, excluded: excluded}, true
 }
 
 func replicaPrefix(
@@ -0,0 +1,159 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// logLevelRanks orders the levels services, agents, and CNPG databases log
+// at, from least to most severe.
+var logLevelRanks = map[string]int{
+	"trace":    0,
+	"debug":    1,
+	"info":     2,
+	"log":      2,
+	"notice":   2,
+	"warn":     3,
+	"warning":  3,
+	"error":    4,
+	"err":      4,
+	"fatal":    5,
+	"panic":    5,
+	"critical": 5,
+	"crit":     5,
+}
+
+// LogFilter selects the log entries PrintLogStream prints, by level,
+// message, structured fields, and replica. Entries must pass every filter
+// set.
+type LogFilter struct {
+	level      int
+	orAbove    bool
+	hasLevel   bool
+	grep       *regexp.Regexp
+	exclude    *regexp.Regexp
+	predicates []fieldPredicate
+	replicas   []string
+}
+
+type fieldPredicate struct {
+	key, value string
+}
+
+// NewLogFilter returns a filter keeping entries:
+//   - logged at level, or also above it when level ends in "+" (e.g. "warn+");
+//   - whose message matches grep and doesn't match exclude;
+//   - whose structured fields satisfy each key=value of where;
+//   - from one of replicas, by full name or the suffix logs show.
+//
+// Empty arguments don't filter. Messages are the message field of structured
+// entries and the whole line of others, which have no level or fields.
+func NewLogFilter(level, grep, exclude string, where, replicas []string) (*LogFilter, error) {
+	f := &LogFilter{replicas: replicas}
+
+	if level != "" {
+		name, orAbove := strings.CutSuffix(strings.ToLower(level), "+")
+		rank, ok := logLevelRank(name)
+		if !ok {
+			return nil, fmt.Errorf(
+				"unknown log level %q: use trace, debug, info, warn, error, or fatal, "+
+					"with a trailing + to include more severe levels",
+				level,
+			)
+		}
+		f.level, f.orAbove, f.hasLevel = rank, orAbove, true
+	}
+
+	var err error
+	if grep != "" {
+		if f.grep, err = regexp.Compile(grep); err != nil {
+			return nil, fmt.Errorf("invalid grep pattern: %w", err)
+		}
+	}
+	if exclude != "" {
+		if f.exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+		}
+	}
+
+	for _, w := range where {
+		key, value, ok := strings.Cut(w, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid field condition %q: use key=value", w)
+		}
+		f.predicates = append(f.predicates, fieldPredicate{key: key, value: value})
+	}
+
+	return f, nil
+}
+
+// logLevelRank ranks a level name, counting Postgres' DEBUG1 to DEBUG5 as debug.
+func logLevelRank(name string) (int, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if strings.HasPrefix(name, "debug") {
+		name = "debug"
+	}
+	rank, ok := logLevelRanks[name]
+	return rank, ok
+}
+
+// keep reports whether the stream line passes the filter.
+func (f *LogFilter) keep(line []byte, cnpg bool) bool {
+	var entry logEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		entry = logEntry{Line: string(line)}
+	}
+
+	if len(f.replicas) > 0 && !slices.ContainsFunc(f.replicas, func(r string) bool {
+		return entry.Replica != "" && (r == entry.Replica || r == trimReplicaSuffix(entry.Replica))
+	}) {
+		return false
+	}
+
+	level, msg := "", entry.Line
+	if parsed, ok := parseStructuredLine(entry.Line, cnpg); ok {
+		level, msg = parsed.level, parsed.msg
+	}
+
+	if f.hasLevel {
+		rank, ok := logLevelRank(level)
+		if !ok || rank < f.level || (!f.orAbove && rank != f.level) {
+			return false
+		}
+	}
+	if f.grep != nil && !f.grep.MatchString(msg) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(msg) {
+		return false
+	}
+
+	if len(f.predicates) > 0 {
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(entry.Line), &fields); err != nil {
+			return false
+		}
+		for _, p := range f.predicates {
+			v, ok := fields[p.key]
+			if !ok || fieldString(v) != p.value {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// fieldString renders a structured field's value for comparison: strings as
+// they are, anything else as JSON.
+func fieldString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	encoded, _ := json.Marshal(v)
+	return string(encoded)
+}
@@ -0,0 +1,146 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrintLogStreamFilter(t *testing.T) {
+	const input = `{"replica":"svc-7d9f-abc12","line":"{\"level\":\"info\",\"msg\":\"started\",\"logger\":\"http\"}"}
+{"replica":"svc-7d9f-def34","line":"{\"level\":\"warn\",\"msg\":\"slow request\",\"logger\":\"http\",\"status\":504}"}
+{"replica":"svc-7d9f-abc12","line":"{\"level\":\"error\",\"msg\":\"request timeout\",\"logger\":\"db\"}"}
+{"replica":"svc-7d9f-def34","line":"plain text timeout"}
+`
+
+	tests := []struct {
+		name     string
+		level    string
+		grep     string
+		exclude  string
+		where    []string
+		replicas []string
+		raw      bool
+		want     string
+	}{
+		{
+			name: "no filters keep everything",
+			want: "[abc12] INFO  started\n" +
+				"[def34] WARN  slow request\n" +
+				"[abc12] ERROR request timeout\n" +
+				"[def34] plain text timeout\n",
+		},
+		{
+			name:  "level with plus keeps more severe levels",
+			level: "warn+",
+			want:  "[def34] WARN  slow request\n[abc12] ERROR request timeout\n",
+		},
+		{
+			name:  "level without plus keeps only that level",
+			level: "WARN",
+			want:  "[def34] WARN  slow request\n",
+		},
+		{
+			name: "grep matches messages and plain lines",
+			grep: "time?out",
+			want: "[abc12] ERROR request timeout\n[def34] plain text timeout\n",
+		},
+		{
+			name:    "exclude drops matching messages",
+			exclude: "^(started|slow)",
+			want:    "[abc12] ERROR request timeout\n[def34] plain text timeout\n",
+		},
+		{
+			name:  "where compares strings and JSON values",
+			where: []string{"logger=http", "status=504"},
+			want:  "[def34] WARN  slow request\n",
+		},
+		{
+			name:     "replica matches the displayed suffix or the full name",
+			replicas: []string{"def34", "svc-7d9f-abc12"},
+			want: "[abc12] INFO  started\n" +
+				"[def34] WARN  slow request\n" +
+				"[abc12] ERROR request timeout\n" +
+				"[def34] plain text timeout\n",
+		},
+		{
+			name:     "replica drops other replicas",
+			replicas: []string{"abc12"},
+			want:     "[abc12] INFO  started\n[abc12] ERROR request timeout\n",
+		},
+		{
+			name:  "filters apply to raw output",
+			level: "error",
+			raw:   true,
+			want: `{"replica":"svc-7d9f-abc12","line":"{\"level\":\"error\",` +
+				`\"msg\":\"request timeout\",\"logger\":\"db\"}"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewLogFilter(tt.level, tt.grep, tt.exclude, tt.where, tt.replicas)
+			if err != nil {
+				t.Fatalf("NewLogFilter() error = %v", err)
+			}
+			var buf bytes.Buffer
+			opts := LogFormatOptions{Raw: tt.raw, Filter: filter}
+			if err := PrintLogStream(
+				&buf, strings.NewReader(input), true, LogsMeta{}, opts,
+			); err != nil {
+				t.Fatalf("PrintLogStream() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("output mismatch\ngot:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintLogStreamFilterCNPG(t *testing.T) {
+	input := `{"line":"{\"record\":{\"error_severity\":\"LOG\",\"message\":\"checkpoint starting\"}}"}
+{"line":"{\"record\":{\"error_severity\":\"DEBUG1\",\"message\":\"autovacuum\"}}"}
+{"line":"{\"record\":{\"error_severity\":\"FATAL\",\"message\":\"password authentication failed\"}}"}
+`
+	filter, err := NewLogFilter("info+", "", "", nil, nil)
+	if err != nil {
+		t.Fatalf("NewLogFilter() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	opts := LogFormatOptions{CNPGFormat: true, Filter: filter}
+	if err := PrintLogStream(&buf, strings.NewReader(input), false, LogsMeta{}, opts); err != nil {
+		t.Fatalf("PrintLogStream() error = %v", err)
+	}
+
+	want := "LOG   checkpoint starting\nFATAL password authentication failed\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestNewLogFilterErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		grep    string
+		exclude string
+		where   []string
+		wantErr string
+	}{
+		{name: "unknown level", level: "loud+", wantErr: `unknown log level "loud+"`},
+		{name: "invalid grep", grep: "(", wantErr: "invalid grep pattern"},
+		{name: "invalid exclude", exclude: "[", wantErr: "invalid exclude pattern"},
+		{name: "where without value", where: []string{"logger"}, wantErr: `"logger"`},
+		{name: "where without key", where: []string{"=http"}, wantErr: `"=http"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLogFilter(tt.level, tt.grep, tt.exclude, tt.where, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewLogFilter() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -115,3 +115,28 @@ func PrintReplicaDescribe(out io.Writer, status *deployment.ReplicaStatus) error
 
 	return nil
 }
+
+// PrintReplicaFailure prints a compact account of why a replica isn't ready:
+// its status and restarts, how its container last terminated, and its events.
+func PrintReplicaFailure(out io.Writer, status *deployment.ReplicaStatus) {
+	fmt.Fprintf(out, "replica %s: %s", status.Name, status.Status)
+	if status.RestartCount > 0 {
+		fmt.Fprintf(out, ", restarted %d times", status.RestartCount)
+	}
+	fmt.Fprintln(out)
+
+	if last := status.LastTerminationState; last != nil {
+		fmt.Fprintf(out, "  last terminated: %s (exit code %d)", last.Reason, last.ExitCode)
+		if last.FinishedAt != "" {
+			fmt.Fprintf(out, " at %s", LocalTime(last.FinishedAt))
+		}
+		fmt.Fprintln(out)
+	}
+	for _, e := range status.Events {
+		fmt.Fprintf(out, "  %s %s: %s", e.Type, e.Reason, e.Message)
+		if e.Count > 1 {
+			fmt.Fprintf(out, " (x%d)", e.Count)
+		}
+		fmt.Fprintln(out)
+	}
+}
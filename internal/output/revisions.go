@@ -33,8 +33,8 @@ func PrintRevisions(out io.Writer, revisions []deployment.RevisionMeta) error {
 			marker,
 			fmt.Sprintf("%d", revision.Revision),
 			LocalTime(revision.Updated),
-			formatRevisionActor(revision.Actor),
-			formatRevisionSource(revision.Source),
+			FormatRevisionActor(revision.Actor),
+			FormatRevisionSource(revision.Source),
 		}
 	}
 
@@ -42,11 +42,12 @@ func PrintRevisions(out io.Writer, revisions []deployment.RevisionMeta) error {
 }
 
 func printRevisionAttribution(out io.Writer, revision deployment.RevisionMeta) {
-	fmt.Fprintf(out, "By:\t%s\n", formatRevisionActor(revision.Actor))
-	fmt.Fprintf(out, "Source:\t%s\n", formatRevisionSource(revision.Source))
+	fmt.Fprintf(out, "By:\t%s\n", FormatRevisionActor(revision.Actor))
+	fmt.Fprintf(out, "Source:\t%s\n", FormatRevisionSource(revision.Source))
 }
 
-func formatRevisionActor(actor *deployment.RevisionActor) string {
+// FormatRevisionActor renders who made a revision, e.g. "ci-bot (API key)".
+func FormatRevisionActor(actor *deployment.RevisionActor) string {
 	if actor == nil {
 		return missingRevisionMetadata
 	}
@@ -78,7 +79,8 @@ func formatRevisionActor(actor *deployment.RevisionActor) string {
 	}
 }
 
-func formatRevisionSource(source *deployment.RevisionSource) string {
+// FormatRevisionSource renders what made a revision, e.g. "iai 1.4.0".
+func FormatRevisionSource(source *deployment.RevisionSource) string {
 	if source == nil {
 		return missingRevisionMetadata
 	}
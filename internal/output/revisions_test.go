@@ -68,8 +68,8 @@ func TestFormatRevisionActor(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := formatRevisionActor(tt.actor); got != tt.want {
-				t.Errorf("formatRevisionActor() = %q, want %q", got, tt.want)
+			if got := FormatRevisionActor(tt.actor); got != tt.want {
+				t.Errorf("FormatRevisionActor() = %q, want %q", got, tt.want)
 			}
 		})
 	}
@@ -206,7 +206,7 @@ func PrintServiceRevision(out io.Writer, rev *deployment.ServiceRevisionResponse
 	return w.Flush()
 }
 
-func PrintSyncResult(out io.Writer, label string, created, updated, deleted []string) {
+func PrintSyncResult(out io.Writer, label string, created, updated, deleted, unchanged []string) {
 	if len(created) > 0 {
 		fmt.Fprintf(out, "Created %s: %s\n", label, strings.Join(created, ", "))
 	}
@@ -216,6 +216,9 @@ func PrintSyncResult(out io.Writer, label string, created, updated, deleted []st
 	if len(deleted) > 0 {
 		fmt.Fprintf(out, "Deleted %s: %s\n", label, strings.Join(deleted, ", "))
 	}
+	if len(unchanged) > 0 {
+		fmt.Fprintf(out, "Unchanged %s: %s\n", label, strings.Join(unchanged, ", "))
+	}
 	if len(created) == 0 && len(updated) == 0 && len(deleted) == 0 {
 		fmt.Fprintf(out, "No changes required; %s already match config.\n", label)
 	}
@@ -422,12 +422,13 @@ func TestPrintServiceRevision(t *testing.T) {
 
 func TestPrintSyncResult(t *testing.T) {
 	tests := []struct {
-		name    string
-		label   string
-		created []string
-		updated []string
-		deleted []string
-		want    string
+		name      string
+		label     string
+		created   []string
+		updated   []string
+		deleted   []string
+		unchanged []string
+		want      string
 	}{
 		{
 			name:  "no changes",
@@ -470,12 +471,19 @@ func TestPrintSyncResult(t *testing.T) {
 				"Updated services: api\n" +
 				"Deleted services: legacy\n",
 		},
+		{
+			name:      "unchanged only",
+			label:     "services",
+			unchanged: []string{"api", "web"},
+			want: "Unchanged services: api, web\n" +
+				"No changes required; services already match config.\n",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var buf bytes.Buffer
-			PrintSyncResult(&buf, tt.label, tt.created, tt.updated, tt.deleted)
+			PrintSyncResult(&buf, tt.label, tt.created, tt.updated, tt.deleted, tt.unchanged)
 			if got := buf.String(); got != tt.want {
 				t.Errorf("output mismatch\ngot:\n%q\nwant:\n%q", got, tt.want)
 			}
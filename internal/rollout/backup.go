@@ -0,0 +1,134 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+)
+
+// DefaultBackupTimeout bounds WaitForBackups; a backup of a large database
+// takes much longer than a rollout.
+const DefaultBackupTimeout = 30 * time.Minute
+
+// WaitForBackups polls the backups of each database, given as database name
+// to backup name, until every one of them has completed, printing each phase
+// change to out. It returns an error as soon as a backup fails, or when the
+// timeout expires first.
+func WaitForBackups(
+	ctx context.Context,
+	out io.Writer,
+	client *deployment.DeploymentClient,
+	orgId, projectId string,
+	backups map[string]string,
+	opts Options,
+) error {
+	if len(backups) == 0 {
+		return nil
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultBackupTimeout
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	fmt.Fprintf(out, "\nWaiting up to %s for %d backups to complete...\n", timeout, len(backups))
+
+	databases := slices.Sorted(maps.Keys(backups))
+	lastPhase := make(map[string]string)
+	done := make(map[string]bool)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, db := range databases {
+			if done[db] {
+				continue
+			}
+			backup, err := findBackup(waitCtx, client, orgId, projectId, db, backups[db])
+			if waitCtx.Err() != nil {
+				break
+			}
+			phase := "pending"
+			switch {
+			case err != nil:
+				phase = "list failed: " + err.Error()
+			case backup != nil && backup.Phase != "":
+				phase = backup.Phase
+			}
+			if phase != lastPhase[db] {
+				fmt.Fprintf(out, "database %s: backup %s %s\n", db, backups[db], phase)
+				lastPhase[db] = phase
+			}
+			if backup == nil {
+				continue
+			}
+
+			status := normalizeStatus(backup.Phase)
+			switch {
+			case backup.Error != "" || failedStatuses[status]:
+				reason := backup.Error
+				if reason == "" {
+					reason = backup.Phase
+				}
+				return fmt.Errorf("backup %s of database %q failed: %s", backup.Name, db, reason)
+			case status == "completed":
+				done[db] = true
+			}
+		}
+		if len(done) == len(backups) {
+			fmt.Fprintln(out, "All backups completed.")
+			return nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return ctx.Err()
+			}
+			var waiting []string
+			for _, db := range databases {
+				if !done[db] {
+					waiting = append(waiting, fmt.Sprintf("database %q (%s)", db, lastPhase[db]))
+				}
+			}
+			return fmt.Errorf(
+				"timed out after %s waiting for backups of %s",
+				timeout, strings.Join(waiting, ", "),
+			)
+		case <-ticker.C:
+		}
+	}
+}
+
+// findBackup returns the backup of db with the given name, or nil while it
+// isn't listed yet.
+func findBackup(
+	ctx context.Context,
+	client *deployment.DeploymentClient,
+	orgId, projectId, db, name string,
+) (*deployment.BackupOutput, error) {
+	backups, err := client.ListDatabaseBackups(ctx, orgId, projectId, db)
+	if err != nil {
+		return nil, err
+	}
+	for i := range backups {
+		if backups[i].Name == name {
+			return &backups[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,67 @@
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+)
+
+func TestWaitForBackupsUntilCompleted(t *testing.T) {
+	const path = "/v1/organizations/o1/projects/p1/databases/orders/backups"
+	var mu gosync.Mutex
+	polls := 0
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != path {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		polls++
+		switch {
+		case polls == 1:
+			fmt.Fprint(w, `{"backups":[{"name":"old","phase":"completed"}]}`)
+		case polls <= 3:
+			fmt.Fprint(w, `{"backups":[{"name":"final","phase":"running"}]}`)
+		default:
+			fmt.Fprint(w, `{"backups":[{"name":"final","phase":"completed"}]}`)
+		}
+	})
+
+	var out bytes.Buffer
+	err := WaitForBackups(context.Background(), &out, client, "o1", "p1",
+		map[string]string{"orders": "final"},
+		Options{Timeout: 5 * time.Second, Interval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("WaitForBackups() error = %v", err)
+	}
+
+	want := "\nWaiting up to 5s for 1 backups to complete...\n" +
+		"database orders: backup final pending\n" +
+		"database orders: backup final running\n" +
+		"database orders: backup final completed\n" +
+		"All backups completed.\n"
+	if got := out.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWaitForBackupsReportsFailure(t *testing.T) {
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"backups":[{"name":"final","phase":"failed","error":"disk full"}]}`)
+	})
+
+	var out bytes.Buffer
+	err := WaitForBackups(context.Background(), &out, client, "o1", "p1",
+		map[string]string{"orders": "final"},
+		Options{Timeout: 5 * time.Second, Interval: time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), `backup final of database "orders" failed: disk full`) {
+		t.Errorf("WaitForBackups() error = %v, want the backup failure", err)
+	}
+}
@@ -0,0 +1,307 @@
+// Package rollout waits for services, agents, databases, and mcps to finish
+// rolling out after they are created or updated.
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
+)
+
+const (
+	DefaultTimeout  = 5 * time.Minute
+	defaultInterval = 2 * time.Second
+)
+
+// Target is a resource to wait for.
+type Target struct {
+	Kind     string // "service", "agent", "database", or "mcp"
+	Name     string
+	Revision int // revision the rollout must reach; 0 accepts the live one
+}
+
+func (t Target) String() string {
+	return fmt.Sprintf("%s %q", t.Kind, t.Name)
+}
+
+type Options struct {
+	Timeout  time.Duration // 0 uses DefaultTimeout
+	Interval time.Duration // time between polls; 0 uses 2s
+}
+
+// Statuses are compared case-insensitively, ignoring spaces, dashes, and
+// underscores. Anything not listed is still in progress.
+var (
+	readyStatuses = map[string]bool{
+		"ready": true, "running": true, "available": true, "healthy": true,
+		"active": true, "deployed": true, "succeeded": true,
+	}
+	failedStatuses = map[string]bool{
+		"failed": true, "error": true, "degraded": true, "unhealthy": true,
+		"crashloopbackoff": true, "imagepullbackoff": true, "errimagepull": true,
+		"createcontainerconfigerror": true, "invalidimagename": true, "oomkilled": true,
+	}
+)
+
+func normalizeStatus(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(s))
+}
+
+type phase int
+
+const (
+	pending phase = iota
+	ready
+	failed
+)
+
+// state is one observation of a target.
+type state struct {
+	revision int
+	status   string
+	message  string
+	replicas []deployment.ReplicaInfo // services only
+	err      error                    // the describe failed; retried on the next poll
+}
+
+// summary is what a transition line prints; a new line is printed whenever
+// it changes.
+func (s state) summary() string {
+	if s.err != nil {
+		return "describe failed: " + s.err.Error()
+	}
+	status := s.status
+	if status == "" {
+		status = "unknown"
+	}
+	parts := []string{fmt.Sprintf("revision %d", s.revision)}
+	if len(s.replicas) > 0 {
+		readyCount := 0
+		for _, r := range s.replicas {
+			if r.Ready {
+				readyCount++
+			}
+		}
+		parts = append(
+			parts, fmt.Sprintf("%d/%d replicas ready", readyCount, len(s.replicas)),
+		)
+	}
+	return fmt.Sprintf("%s (%s)", status, strings.Join(parts, ", "))
+}
+
+// failureReason names what made classify report failed: the resource's
+// status, or else the first failing replica.
+func (s state) failureReason() string {
+	if failedStatuses[normalizeStatus(s.status)] {
+		if s.message != "" {
+			return s.status + ": " + s.message
+		}
+		return s.status
+	}
+	for _, r := range s.replicas {
+		if failedStatuses[normalizeStatus(r.Status)] {
+			return fmt.Sprintf("replica %s is %s", r.Name, r.Status)
+		}
+	}
+	return s.status
+}
+
+func classify(t Target, s state) phase {
+	if s.err != nil || s.revision < t.Revision {
+		return pending
+	}
+	status := normalizeStatus(s.status)
+	if failedStatuses[status] {
+		return failed
+	}
+	for _, r := range s.replicas {
+		if failedStatuses[normalizeStatus(r.Status)] {
+			return failed
+		}
+	}
+	// External mcps run nowhere and report no status.
+	if status == "" && t.Kind == "mcp" {
+		return ready
+	}
+	if !readyStatuses[status] {
+		return pending
+	}
+	for _, r := range s.replicas {
+		if !r.Ready {
+			return pending
+		}
+	}
+	return ready
+}
+
+func fetch(
+	ctx context.Context,
+	client *deployment.DeploymentClient,
+	orgId, projectId string,
+	t Target,
+) state {
+	var s state
+	switch t.Kind {
+	case "service":
+		desc, err := client.DescribeService(ctx, orgId, projectId, t.Name)
+		if err != nil {
+			return state{err: err}
+		}
+		s = state{revision: desc.Revision, status: desc.Status, message: desc.Message}
+		s.replicas, s.err = client.ListReplicas(ctx, orgId, projectId, t.Name)
+	case "agent":
+		desc, err := client.DescribeAgent(ctx, orgId, projectId, t.Name)
+		if err != nil {
+			return state{err: err}
+		}
+		s = state{revision: desc.Revision, status: desc.Status, message: desc.Message}
+	case "database":
+		desc, err := client.DescribeDatabase(ctx, orgId, projectId, t.Name)
+		if err != nil {
+			return state{err: err}
+		}
+		s = state{revision: desc.Revision, status: desc.Status, message: desc.Message}
+	case "mcp":
+		desc, err := client.DescribeMcp(ctx, orgId, projectId, t.Name)
+		if err != nil {
+			return state{err: err}
+		}
+		s = state{revision: desc.Revision, status: desc.Status}
+		if desc.Verify.Error != "" {
+			s.message = desc.Verify.Error
+		}
+	default:
+		s.err = fmt.Errorf("unsupported resource type %q", t.Kind)
+	}
+	return s
+}
+
+// Wait polls every target until all of them are ready, printing each status
+// change to out. It returns an error as soon as a target fails, or when the
+// timeout expires first; either way the replicas that aren't ready are
+// described (last termination state and events) before returning.
+func Wait(
+	ctx context.Context,
+	out io.Writer,
+	client *deployment.DeploymentClient,
+	orgId, projectId string,
+	targets []Target,
+	opts Options,
+) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	fmt.Fprintf(
+		out,
+		"\nWaiting up to %s for %d resources to become ready...\n",
+		timeout, len(targets),
+	)
+
+	last := make([]state, len(targets))
+	lastSummary := make([]string, len(targets))
+	done := make([]bool, len(targets))
+	remaining := len(targets)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for i, t := range targets {
+			if done[i] {
+				continue
+			}
+			s := fetch(waitCtx, client, orgId, projectId, t)
+			if waitCtx.Err() != nil {
+				break
+			}
+			last[i] = s
+			if summary := s.summary(); summary != lastSummary[i] {
+				fmt.Fprintf(out, "%s %s: %s\n", t.Kind, t.Name, summary)
+				lastSummary[i] = summary
+			}
+
+			switch classify(t, s) {
+			case ready:
+				done[i] = true
+				remaining--
+			case failed:
+				printFailure(ctx, out, client, orgId, projectId, t, s)
+				return fmt.Errorf("%s failed to roll out: %s", t, s.failureReason())
+			}
+		}
+		if remaining == 0 {
+			fmt.Fprintln(out, "All resources are ready.")
+			return nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return ctx.Err()
+			}
+			var waiting []string
+			for i, t := range targets {
+				if done[i] {
+					continue
+				}
+				printFailure(ctx, out, client, orgId, projectId, t, last[i])
+				waiting = append(waiting, fmt.Sprintf("%s (%s)", t, last[i].summary()))
+			}
+			return fmt.Errorf(
+				"timed out after %s waiting for %s",
+				timeout, strings.Join(waiting, ", "),
+			)
+		case <-ticker.C:
+		}
+	}
+}
+
+// printFailure explains why t isn't ready: the resource's own message and,
+// for services, the state and events of every replica that isn't ready.
+func printFailure(
+	ctx context.Context,
+	out io.Writer,
+	client *deployment.DeploymentClient,
+	orgId, projectId string,
+	t Target,
+	s state,
+) {
+	if s.message != "" {
+		fmt.Fprintf(out, "%s %s: %s\n", t.Kind, t.Name, s.message)
+	}
+	for _, r := range s.replicas {
+		if r.Ready {
+			continue
+		}
+		status, err := client.DescribeReplica(ctx, orgId, projectId, r.Name)
+		if err != nil {
+			fmt.Fprintf(out, "replica %s: %s (could not describe: %v)\n", r.Name, r.Status, err)
+			continue
+		}
+		output.PrintReplicaFailure(out, status)
+	}
+}
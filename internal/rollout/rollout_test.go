@@ -0,0 +1,204 @@
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+)
+
+func newTestDeployClient(t *testing.T, handler http.HandlerFunc) *deployment.DeploymentClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := deployment.NewDeploymentClient(server.URL, 5*time.Second, "test-token", "", nil)
+	if err != nil {
+		t.Fatalf("NewDeploymentClient() error = %v", err)
+	}
+	return client
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		target Target
+		state  state
+		want   phase
+	}{
+		{
+			name:   "ready",
+			target: Target{Kind: "agent"},
+			state:  state{revision: 1, status: "Ready"},
+			want:   ready,
+		},
+		{
+			name:   "older revision still rolling out",
+			target: Target{Kind: "agent", Revision: 4},
+			state:  state{revision: 3, status: "ready"},
+			want:   pending,
+		},
+		{
+			name:   "failed status",
+			target: Target{Kind: "database"},
+			state:  state{revision: 1, status: "Failed"},
+			want:   failed,
+		},
+		{
+			name:   "unknown status in progress",
+			target: Target{Kind: "service"},
+			state:  state{revision: 1, status: "deploying"},
+			want:   pending,
+		},
+		{
+			name:   "replica not ready",
+			target: Target{Kind: "service"},
+			state: state{revision: 1, status: "running", replicas: []deployment.ReplicaInfo{
+				{Name: "a", Ready: true}, {Name: "b", Status: "ContainerCreating"},
+			}},
+			want: pending,
+		},
+		{
+			name:   "crashing replica fails the service",
+			target: Target{Kind: "service"},
+			state: state{revision: 1, status: "deploying", replicas: []deployment.ReplicaInfo{
+				{Name: "a", Status: "CrashLoopBackOff"},
+			}},
+			want: failed,
+		},
+		{
+			name:   "external mcp without status",
+			target: Target{Kind: "mcp"},
+			state:  state{revision: 2},
+			want:   ready,
+		},
+		{
+			name:   "describe error retried",
+			target: Target{Kind: "agent"},
+			state:  state{err: fmt.Errorf("not found")},
+			want:   pending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classify(tt.target, tt.state); got != tt.want {
+				t.Errorf("classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitStreamsTransitionsUntilReady(t *testing.T) {
+	const base = "/v1/organizations/o1/projects/p1/services/api"
+	var mu gosync.Mutex
+	polls := 0
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case base:
+			polls++
+			switch {
+			case polls == 1:
+				fmt.Fprint(w, `{"name":"api","revision":3,"status":"ready"}`)
+			case polls <= 3:
+				fmt.Fprint(w, `{"name":"api","revision":4,"status":"deploying"}`)
+			default:
+				fmt.Fprint(w, `{"name":"api","revision":4,"status":"ready"}`)
+			}
+		case base + "/replicas":
+			readyReplica := polls > 2
+			fmt.Fprintf(w, `{"replicas":[{"name":"api-1","status":"Running","ready":%t}]}`, readyReplica)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	var out bytes.Buffer
+	err := Wait(context.Background(), &out, client, "o1", "p1",
+		[]Target{{Kind: "service", Name: "api", Revision: 4}},
+		Options{Timeout: 5 * time.Second, Interval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	want := "\nWaiting up to 5s for 1 resources to become ready...\n" +
+		"service api: ready (revision 3, 0/1 replicas ready)\n" +
+		"service api: deploying (revision 4, 0/1 replicas ready)\n" +
+		"service api: deploying (revision 4, 1/1 replicas ready)\n" +
+		"service api: ready (revision 4, 1/1 replicas ready)\n" +
+		"All resources are ready.\n"
+	if got := out.String(); got != want {
+		t.Errorf("output mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWaitReportsFailedReplicas(t *testing.T) {
+	const base = "/v1/organizations/o1/projects/p1/services"
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case base + "/api":
+			fmt.Fprint(w, `{"name":"api","revision":2,"status":"deploying"}`)
+		case base + "/api/replicas":
+			fmt.Fprint(w, `{"replicas":[{"name":"api-1","status":"CrashLoopBackOff","ready":false}]}`)
+		case base + "/replicas/api-1":
+			fmt.Fprint(w, `{
+				"name":"api-1","status":"CrashLoopBackOff","restartCount":4,
+				"lastTerminationState":{"reason":"Error","exitCode":1},
+				"events":[{"type":"Warning","reason":"BackOff","message":"Back-off restarting failed container","count":12}]
+			}`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	var out bytes.Buffer
+	err := Wait(context.Background(), &out, client, "o1", "p1",
+		[]Target{{Kind: "service", Name: "api", Revision: 2}},
+		Options{Interval: time.Millisecond})
+
+	wantErr := `service "api" failed to roll out: replica api-1 is CrashLoopBackOff`
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("Wait() error = %v, want %q", err, wantErr)
+	}
+	for _, want := range []string{
+		"replica api-1: CrashLoopBackOff, restarted 4 times\n",
+		"  last terminated: Error (exit code 1)\n",
+		"  Warning BackOff: Back-off restarting failed container (x12)\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWaitTimesOut(t *testing.T) {
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/organizations/o1/projects/p1/agents/chat":
+			fmt.Fprint(w, `{"name":"chat","revision":2,"status":"deploying"}`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	var out bytes.Buffer
+	err := Wait(context.Background(), &out, client, "o1", "p1",
+		[]Target{{Kind: "agent", Name: "chat"}},
+		Options{Timeout: 200 * time.Millisecond, Interval: time.Millisecond})
+
+	wantErr := `timed out after 200ms waiting for agent "chat" (deploying (revision 2))`
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("Wait() error = %v, want %q", err, wantErr)
+	}
+}
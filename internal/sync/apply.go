@@ -0,0 +1,177 @@
+package sync
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// deleteOrder ranks resource types for deletion: a type is deleted only after
+// the lower-ranked types that may still use it.
+var deleteOrder = map[string]int{"service": 0, "agent": 0, "mcp": 1, "database": 2}
+
+// ApplyPlans applies the steps of several plans together, running up to
+// parallelism steps at a time.
+//
+// deps maps a resource ID (e.g. "service/api") to the IDs of the resources it
+// references; its create or update starts only after theirs succeed.
+// References that form a cycle are broken in plan order. Deletes start once
+// every create and update is done: services and agents first, then mcps, then
+// databases. After a step fails no new step starts, and the steps already
+// running finish.
+//
+// Results and errors are returned per plan, in plan order. Each result lists
+// what was actually done, in the same order as a sequential apply, so output
+// doesn't depend on scheduling.
+func ApplyPlans(plans []*Plan, deps map[string][]string, parallelism int) ([]*Result, []error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	type node struct {
+		plan       int
+		step       *Step
+		waiting    int
+		dependents []int
+		started    bool
+		done       bool
+		err        error
+	}
+
+	var nodes []*node
+	for i, p := range plans {
+		if p.dryRun {
+			continue
+		}
+		for _, step := range p.Steps {
+			nodes = append(nodes, &node{plan: i, step: step})
+		}
+	}
+
+	edges := make(map[[2]int]bool)
+	addEdge := func(from, to int) {
+		if from == to || edges[[2]int{from, to}] {
+			return
+		}
+		edges[[2]int{from, to}] = true
+		nodes[from].dependents = append(nodes[from].dependents, to)
+		nodes[to].waiting++
+	}
+
+	applyByID := make(map[string]int)
+	for i, n := range nodes {
+		if n.step.Action != "delete" {
+			applyByID[n.step.ID()] = i
+		}
+	}
+	for i, n := range nodes {
+		if n.step.Action == "delete" {
+			for j, other := range nodes {
+				if other.step.Action != "delete" ||
+					deleteOrder[other.step.Resource] < deleteOrder[n.step.Resource] {
+					addEdge(j, i)
+				}
+			}
+			continue
+		}
+		for _, dep := range deps[n.step.ID()] {
+			if j, ok := applyByID[dep]; ok {
+				addEdge(j, i)
+			}
+		}
+	}
+
+	var ready []int
+	for i, n := range nodes {
+		if n.waiting == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	type completion struct {
+		index int
+		err   error
+	}
+	completed := make(chan completion)
+	running, remaining := 0, len(nodes)
+	failed := false
+
+	for remaining > 0 {
+		if !failed && running == 0 && len(ready) == 0 {
+			// Every remaining step waits on another: a reference cycle.
+			for i, n := range nodes {
+				if !n.started {
+					ready = append(ready, i)
+					break
+				}
+			}
+		}
+		for !failed && running < parallelism && len(ready) > 0 {
+			i := ready[0]
+			ready = ready[1:]
+			nodes[i].started = true
+			running++
+			go func() {
+				completed <- completion{index: i, err: nodes[i].step.run()}
+			}()
+		}
+		if running == 0 {
+			break
+		}
+
+		c := <-completed
+		running--
+		remaining--
+		n := nodes[c.index]
+		if c.err != nil {
+			n.err = c.err
+			failed = true
+			continue
+		}
+		n.done = true
+		for _, d := range n.dependents {
+			nodes[d].waiting--
+			if nodes[d].waiting == 0 && !nodes[d].started {
+				ready = append(ready, d)
+			}
+		}
+		sort.Ints(ready)
+	}
+
+	results := make([]*Result, len(plans))
+	errs := make([]error, len(plans))
+	done := make([][]bool, len(plans))
+	skipped := make([][]string, len(plans))
+	for i, p := range plans {
+		done[i] = make([]bool, len(p.Steps))
+	}
+	stepIndex := make([]int, len(plans))
+	for _, n := range nodes {
+		k := stepIndex[n.plan]
+		stepIndex[n.plan]++
+		switch {
+		case n.done:
+			done[n.plan][k] = true
+		case n.err != nil:
+			if errs[n.plan] == nil {
+				errs[n.plan] = n.err
+			}
+		default:
+			skipped[n.plan] = append(skipped[n.plan], n.step.Name)
+		}
+	}
+	for i, p := range plans {
+		if p.dryRun {
+			results[i] = p.Result
+			continue
+		}
+		results[i] = p.outcome(done[i])
+		if errs[i] == nil && len(skipped[i]) > 0 {
+			errs[i] = fmt.Errorf(
+				"not synced after an earlier failure: %s",
+				strings.Join(skipped[i], ", "),
+			)
+		}
+	}
+	return results, errs
+}
@@ -0,0 +1,184 @@
+package sync
+
+import (
+	"errors"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// stepLog records the order in which test steps start and finish.
+type stepLog struct {
+	mu     gosync.Mutex
+	events []string
+}
+
+func (l *stepLog) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *stepLog) index(event string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, e := range l.events {
+		if e == event {
+			return i
+		}
+	}
+	return -1
+}
+
+func testStep(log *stepLog, resource, name, action string, err error) *Step {
+	s := &Step{Resource: resource, Name: name, Action: action}
+	s.run = func() error {
+		log.add("start " + s.ID())
+		time.Sleep(5 * time.Millisecond)
+		log.add("end " + s.ID())
+		return err
+	}
+	return s
+}
+
+func testPlan(label string, steps ...*Step) *Plan {
+	p := &Plan{Label: label, Result: &Result{}, Steps: steps}
+	for _, s := range steps {
+		switch s.Action {
+		case "create":
+			p.Result.Created = append(p.Result.Created, s.Name)
+		case "update":
+			p.Result.Updated = append(p.Result.Updated, s.Name)
+		case "delete":
+			p.Result.Deleted = append(p.Result.Deleted, s.Name)
+		}
+	}
+	return p
+}
+
+func TestApplyPlansOrdersDependencies(t *testing.T) {
+	log := &stepLog{}
+	plans := []*Plan{
+		testPlan("databases",
+			testStep(log, "database", "orders-db", "create", nil),
+			testStep(log, "database", "old-db", "delete", nil)),
+		testPlan("mcps", testStep(log, "mcp", "tools", "update", nil)),
+		testPlan("services",
+			testStep(log, "service", "api", "create", nil),
+			testStep(log, "service", "web", "update", nil),
+			testStep(log, "service", "legacy", "delete", nil)),
+		testPlan("agents", testStep(log, "agent", "chat", "create", nil)),
+	}
+	deps := map[string][]string{
+		"service/api": {"database/orders-db", "secret/not-in-plans"},
+		"agent/chat":  {"mcp/tools"},
+	}
+
+	results, errs := ApplyPlans(plans, deps, 4)
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("errs[%d] = %v", i, err)
+		}
+	}
+	before := func(a, b string) {
+		t.Helper()
+		if log.index(a) < 0 || log.index(b) < 0 || log.index(a) > log.index(b) {
+			t.Errorf("%q did not happen before %q; events: %v", a, b, log.events)
+		}
+	}
+	before("end database/orders-db", "start service/api")
+	before("end mcp/tools", "start agent/chat")
+	applies := []string{
+		"database/orders-db", "mcp/tools", "service/api", "service/web", "agent/chat",
+	}
+	for _, apply := range applies {
+		before("end "+apply, "start service/legacy")
+	}
+	before("end service/legacy", "start database/old-db")
+	// Independent steps overlap: web starts before the first step ends.
+	before("start service/web", "end database/orders-db")
+
+	want := []*Result{
+		{Created: []string{"orders-db"}, Deleted: []string{"old-db"}},
+		{Updated: []string{"tools"}},
+		{Created: []string{"api"}, Updated: []string{"web"}, Deleted: []string{"legacy"}},
+		{Created: []string{"chat"}},
+	}
+	if diff := cmp.Diff(want, results); diff != "" {
+		t.Errorf("results mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplyPlansStopsAfterFailure(t *testing.T) {
+	log := &stepLog{}
+	boom := errors.New("failed to create database \"orders-db\": quota exceeded")
+	plans := []*Plan{
+		testPlan("databases", testStep(log, "database", "orders-db", "create", boom)),
+		testPlan("services",
+			testStep(log, "service", "api", "create", nil),
+			testStep(log, "service", "legacy", "delete", nil)),
+	}
+	deps := map[string][]string{"service/api": {"database/orders-db"}}
+
+	results, errs := ApplyPlans(plans, deps, 1)
+
+	if !errors.Is(errs[0], boom) {
+		t.Errorf("errs[0] = %v, want %v", errs[0], boom)
+	}
+	wantErr := "not synced after an earlier failure: api, legacy"
+	if errs[1] == nil || errs[1].Error() != wantErr {
+		t.Errorf("errs[1] = %v, want %q", errs[1], wantErr)
+	}
+	if log.index("start service/api") >= 0 || log.index("start service/legacy") >= 0 {
+		t.Errorf("steps started after a failure; events: %v", log.events)
+	}
+	if diff := cmp.Diff([]*Result{{}, {}}, results); diff != "" {
+		t.Errorf("results mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplyPlansBreaksReferenceCycles(t *testing.T) {
+	log := &stepLog{}
+	plans := []*Plan{
+		testPlan("mcps",
+			testStep(log, "mcp", "a", "create", nil),
+			testStep(log, "mcp", "b", "create", nil)),
+	}
+	deps := map[string][]string{
+		"mcp/a": {"mcp/b"},
+		"mcp/b": {"mcp/a"},
+	}
+
+	results, errs := ApplyPlans(plans, deps, 2)
+
+	if errs[0] != nil {
+		t.Fatalf("errs[0] = %v", errs[0])
+	}
+	if diff := cmp.Diff([]string{"a", "b"}, results[0].Created); diff != "" {
+		t.Errorf("Created mismatch (-want +got):\n%s", diff)
+	}
+	if log.index("end mcp/a") > log.index("start mcp/b") {
+		t.Errorf("cycle not broken in plan order; events: %v", log.events)
+	}
+}
+
+func TestApplyPlansDryRun(t *testing.T) {
+	log := &stepLog{}
+	plan := testPlan("services", testStep(log, "service", "api", "create", nil))
+	plan.dryRun = true
+
+	results, errs := ApplyPlans([]*Plan{plan}, nil, 4)
+
+	if errs[0] != nil {
+		t.Errorf("errs[0] = %v", errs[0])
+	}
+	if len(log.events) != 0 {
+		t.Errorf("dry run ran steps: %v", log.events)
+	}
+	if diff := cmp.Diff([]string{"api"}, results[0].Created); diff != "" {
+		t.Errorf("Created mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,290 @@
+package sync
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	gosync "sync"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/preflight"
+)
+
+// Plan is the work a sync does for one resource type: the planned outcome and
+// one step per create, update, or delete. Planning only reads live state.
+type Plan struct {
+	Label  string  // resource type as used by --allow-delete, e.g. "services"
+	Result *Result // planned outcome; Unchanged and Protected are final
+	Steps  []*Step // creates and updates by name, then deletes by name
+	dryRun bool
+}
+
+// Step is a single create, update, or delete of one resource.
+type Step struct {
+	Resource string // e.g. "service"
+	Name     string
+	Action   string // "create", "update", or "delete"
+	Revision int    // for updates, the revision the update creates; 0 if unknown
+	// LiveRevision is the revision of the live resource the step was
+	// planned against; 0 for creates and resources without revisions.
+	LiveRevision int
+	run          func() error
+}
+
+// ID identifies the step's resource as "resource/name", e.g. "service/api".
+func (s *Step) ID() string {
+	return s.Resource + "/" + s.Name
+}
+
+// Empty reports whether the plan has nothing to do or report: no resources
+// are desired and none exist in the stack.
+func (p *Plan) Empty() bool {
+	return len(p.Steps) == 0 && len(p.Result.Unchanged) == 0 && len(p.Result.Protected) == 0 &&
+		len(p.Result.Skipped) == 0
+}
+
+// Apply runs the plan's steps in order, stopping at the first failure. The
+// returned result lists what was actually done. A dry-run plan returns its
+// planned result without running anything.
+func (p *Plan) Apply() (*Result, error) {
+	if p.dryRun {
+		return p.Result, nil
+	}
+	done := make([]bool, len(p.Steps))
+	for i, step := range p.Steps {
+		if err := step.run(); err != nil {
+			return p.outcome(done), err
+		}
+		done[i] = true
+	}
+	return p.outcome(done), nil
+}
+
+// outcome builds the result of applying the steps marked done.
+func (p *Plan) outcome(done []bool) *Result {
+	result := &Result{
+		Unchanged: p.Result.Unchanged,
+		Protected: p.Result.Protected,
+		Skipped:   p.Result.Skipped,
+	}
+	for i, step := range p.Steps {
+		if !done[i] {
+			continue
+		}
+		switch step.Action {
+		case "create":
+			result.Created = append(result.Created, step.Name)
+		case "update":
+			result.Updated = append(result.Updated, step.Name)
+		case "delete":
+			result.Deleted = append(result.Deleted, step.Name)
+		}
+	}
+	return result
+}
+
+type resourceOps[E, B any] struct {
+	resource  string
+	allowFlag string
+	create    func(name string, body B) error
+	unchanged func(name string, body B) (bool, error)
+	update    func(name string, body B) error
+	delete    func(name string) error
+	banner    func(w io.Writer, existing E)
+	revision  func(existing E) int
+	// snapshot records the spec an update is about to replace, for
+	// Options.Journal. It returns a func restoring that spec and a
+	// description of the restore, e.g. "restored revision 3".
+	snapshot func(name string, existing E, body B) (restore func() error, detail string, err error)
+}
+
+// planResources compares desired against the existing resources and returns
+// the plan. Deletion warnings and update banners are printed to warnW here,
+// before any step runs, so they read the same however the steps are
+// scheduled. Unchanged checks run up to opts.Parallelism at a time.
+func planResources[E, B any](
+	warnW io.Writer,
+	existingByName map[string]E,
+	desired map[string]B,
+	opts Options,
+	ops resourceOps[E, B],
+) (*Plan, error) {
+	plan := &Plan{Label: ops.allowFlag, Result: &Result{}, dryRun: opts.DryRun}
+	existingByName, desired, plan.Result.Skipped = selectTargets(
+		existingByName, desired, opts.Targets, ops.resource,
+	)
+
+	var toDelete []string
+	for name := range existingByName {
+		if _, ok := desired[name]; !ok {
+			toDelete = append(toDelete, name)
+		}
+	}
+	sort.Strings(toDelete)
+	if !opts.DryRun {
+		preflight.PrintSyncDeletions(warnW, ops.resource, ops.allowFlag, toDelete, opts.AllowDelete)
+	}
+	if !opts.AllowDelete {
+		plan.Result.Protected = toDelete
+		toDelete = nil
+	}
+
+	desiredNames := make([]string, 0, len(desired))
+	for name := range desired {
+		desiredNames = append(desiredNames, name)
+	}
+	sort.Strings(desiredNames)
+
+	same, err := checkUnchanged(existingByName, desired, desiredNames, opts.Parallelism, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, name := range desiredNames {
+		body := desired[name]
+		existing, exists := existingByName[name]
+		switch {
+		case !exists:
+			plan.Result.Created = append(plan.Result.Created, name)
+			step := &Step{Resource: ops.resource, Name: name, Action: "create"}
+			step.run = func() error {
+				if err := ops.create(name, body); err != nil {
+					return fmt.Errorf("failed to create %s %q: %w", ops.resource, name, err)
+				}
+				opts.Journal.record(step, func() error { return ops.delete(name) }, "deleted")
+				return nil
+			}
+			plan.Steps = append(plan.Steps, step)
+		case same[i]:
+			plan.Result.Unchanged = append(plan.Result.Unchanged, name)
+		default:
+			plan.Result.Updated = append(plan.Result.Updated, name)
+			if !opts.DryRun && ops.banner != nil {
+				ops.banner(warnW, existing)
+			}
+			step := &Step{Resource: ops.resource, Name: name, Action: "update"}
+			if ops.revision != nil {
+				step.LiveRevision = ops.revision(existing)
+				step.Revision = step.LiveRevision + 1
+			}
+			step.run = func() error {
+				var restore func() error
+				var detail string
+				if opts.Journal != nil && ops.snapshot != nil {
+					var err error
+					restore, detail, err = ops.snapshot(name, existing, body)
+					if err != nil {
+						return fmt.Errorf(
+							"failed to record previous %s %q: %w", ops.resource, name, err,
+						)
+					}
+				}
+				if err := ops.update(name, body); err != nil {
+					return fmt.Errorf("failed to update %s %q: %w", ops.resource, name, err)
+				}
+				opts.Journal.record(step, restore, detail)
+				return nil
+			}
+			plan.Steps = append(plan.Steps, step)
+		}
+	}
+
+	for _, name := range toDelete {
+		plan.Result.Deleted = append(plan.Result.Deleted, name)
+		step := &Step{Resource: ops.resource, Name: name, Action: "delete"}
+		if ops.revision != nil {
+			step.LiveRevision = ops.revision(existingByName[name])
+		}
+		step.run = func() error {
+			if err := ops.delete(name); err != nil {
+				return fmt.Errorf("failed to delete %s %q: %w", ops.resource, name, err)
+			}
+			opts.Journal.record(step, nil, "")
+			return nil
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	return plan, nil
+}
+
+// selectTargets drops the resources targets doesn't select from existing
+// and desired, and returns the names it dropped. An existing resource that
+// isn't targeted is dropped before deletions are planned, so leaving a
+// resource out of the targets never deletes it.
+func selectTargets[E, B any](
+	existing map[string]E,
+	desired map[string]B,
+	targets *Targets,
+	resource string,
+) (map[string]E, map[string]B, []string) {
+	if targets == nil {
+		return existing, desired, nil
+	}
+	skipped := make(map[string]bool)
+	keptExisting := make(map[string]E, len(existing))
+	for name, e := range existing {
+		if targets.Match(resource, name) {
+			keptExisting[name] = e
+		} else {
+			skipped[name] = true
+		}
+	}
+	keptDesired := make(map[string]B, len(desired))
+	for name, b := range desired {
+		if targets.Match(resource, name) {
+			keptDesired[name] = b
+		} else {
+			skipped[name] = true
+		}
+	}
+	names := make([]string, 0, len(skipped))
+	for name := range skipped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return keptExisting, keptDesired, names
+}
+
+// checkUnchanged runs ops.unchanged for every desired resource that already
+// exists, up to parallelism at a time. same[i] reports desiredNames[i].
+func checkUnchanged[E, B any](
+	existingByName map[string]E,
+	desired map[string]B,
+	desiredNames []string,
+	parallelism int,
+	ops resourceOps[E, B],
+) ([]bool, error) {
+	same := make([]bool, len(desiredNames))
+	if ops.unchanged == nil {
+		return same, nil
+	}
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	errs := make([]error, len(desiredNames))
+	sem := make(chan struct{}, parallelism)
+	var wg gosync.WaitGroup
+	for i, name := range desiredNames {
+		if _, exists := existingByName[name]; !exists {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			same[i], errs[i] = ops.unchanged(name, desired[name])
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to describe %s %q: %w", ops.resource, desiredNames[i], err,
+			)
+		}
+	}
+	return same, nil
+}
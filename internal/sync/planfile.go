@@ -0,0 +1,269 @@
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+)
+
+// PlanFileVersion is the plan file format written by WritePlanFile.
+const PlanFileVersion = 1
+
+// PlanFile is a reviewed sync plan: the stack file and overlays it was made
+// from, a digest of the config they loaded to, the organization and project
+// it was planned in, and the changes it would make.
+// The config itself is not written, so neither are the values variables
+// expand to, MCP credentials, or secret values; they are loaded again from
+// their sources when the plan is applied.
+type PlanFile struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// File and Overlays are relative to the plan file's directory, so a
+	// committed plan can be applied from another checkout.
+	File         string   `json:"file"`
+	Overlays     []string `json:"overlays,omitempty"`
+	ConfigDigest string   `json:"configDigest"`
+	// Organization and Project are the ones the plan was made in, however
+	// they were resolved, so the apply acts on the same project.
+	Organization string   `json:"organization"`
+	Project      string   `json:"project"`
+	AllowDelete  []string `json:"allowDelete,omitempty"`
+	Targets      []string `json:"targets,omitempty"` // --target patterns
+	Changes      []Change `json:"changes"`
+}
+
+// Change is one planned create, update, or delete.
+type Change struct {
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	Action   string `json:"action"`
+	// Revision is the live revision the change was planned against; 0 for
+	// creates and for resources without revisions (secrets, context items).
+	Revision int `json:"revision,omitempty"`
+}
+
+func (c Change) id() string {
+	return c.Resource + "/" + c.Name
+}
+
+// Changes lists the steps of plans as changes, in plan order.
+func Changes(plans []*Plan) []Change {
+	changes := []Change{}
+	for _, p := range plans {
+		for _, step := range p.Steps {
+			changes = append(changes, Change{
+				Resource: step.Resource,
+				Name:     step.Name,
+				Action:   step.Action,
+				Revision: step.LiveRevision,
+			})
+		}
+	}
+	return changes
+}
+
+// WritePlanFile writes plan to path as indented JSON, with its stack file
+// and overlay paths made relative to the directory of path.
+func WritePlanFile(path string, plan *PlanFile) error {
+	dir := filepath.Dir(path)
+	written := *plan
+	written.File = relativePath(dir, plan.File)
+	written.Overlays = make([]string, len(plan.Overlays))
+	for i, overlay := range plan.Overlays {
+		written.Overlays[i] = relativePath(dir, overlay)
+	}
+	if len(written.Overlays) == 0 {
+		written.Overlays = nil
+	}
+
+	data, err := json.MarshalIndent(&written, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write plan file: %w", err)
+	}
+	return nil
+}
+
+// ReadPlanFile reads a plan written by WritePlanFile, resolving its stack
+// file and overlay paths against the directory of path.
+func ReadPlanFile(path string) (*PlanFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+	var plan PlanFile
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file %s: %w", filepath.Base(path), err)
+	}
+	if plan.Version != PlanFileVersion {
+		return nil, fmt.Errorf(
+			"unsupported plan file version %d (expected %d)", plan.Version, PlanFileVersion,
+		)
+	}
+	if plan.File == "" {
+		return nil, fmt.Errorf("plan file %s has no stack file", filepath.Base(path))
+	}
+
+	dir := filepath.Dir(path)
+	plan.File = resolvePath(dir, plan.File)
+	for i, overlay := range plan.Overlays {
+		plan.Overlays[i] = resolvePath(dir, overlay)
+	}
+	return &plan, nil
+}
+
+// LoadConfig loads the plan's stack file and overlays again, expanding
+// variables from the current environment, and fails when the config no
+// longer matches the one planned.
+func (p *PlanFile) LoadConfig() (*files.StackConfig, error) {
+	cfg, err := files.LoadStackConfig(p.File, p.Overlays...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack config: %w", err)
+	}
+	digest, err := ConfigDigest(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if digest != p.ConfigDigest {
+		return nil, fmt.Errorf(
+			"stack config %s changed since planning; run 'iai stacks plan' again",
+			p.File,
+		)
+	}
+	return cfg, nil
+}
+
+// ConfigDigest returns the SHA-256 of cfg, as loaded, that plan files
+// record. MCP credentials are left out: like secret values, they are
+// resolved again at apply time, and may be rotated in between.
+func ConfigDigest(cfg *files.StackConfig) (string, error) {
+	data, err := json.Marshal(cfg.WithoutCredentials())
+	if err != nil {
+		return "", fmt.Errorf("failed to encode stack config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// relativePath returns path relative to dir, or path itself, made absolute,
+// when it can't be.
+func relativePath(dir, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return abs
+	}
+	rel, err := filepath.Rel(absDir, abs)
+	if err != nil {
+		return abs
+	}
+	return filepath.ToSlash(rel)
+}
+
+func resolvePath(dir, path string) string {
+	path = filepath.FromSlash(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// Refusal is a step that was dropped because it no longer matches the plan
+// file it is applied from.
+type Refusal struct {
+	Step   *Step
+	Reason string
+}
+
+// Restrict drops every step of plans that doesn't match one of the planned
+// changes, together with the steps that depend on a dropped one (see deps in
+// ApplyPlans), and returns a refusal for each. A step matches when the
+// planned change has the same resource, action, and live revision: a live
+// revision that moved since planning means someone else changed the
+// resource, and the reviewed plan no longer describes what would happen.
+func Restrict(plans []*Plan, planned []Change, deps map[string][]string) []Refusal {
+	byID := make(map[string]Change, len(planned))
+	for _, c := range planned {
+		byID[c.id()] = c
+	}
+
+	reasons := make(map[string]string)
+	for _, p := range plans {
+		for _, step := range p.Steps {
+			if reason := refuseReason(step, byID); reason != "" {
+				reasons[step.ID()] = reason
+			}
+		}
+	}
+	// Refuse dependents until nothing changes, so chains are followed
+	// whatever order the steps are in.
+	for changed := true; changed; {
+		changed = false
+		for _, p := range plans {
+			for _, step := range p.Steps {
+				if _, ok := reasons[step.ID()]; ok || step.Action == "delete" {
+					continue
+				}
+				for _, dep := range deps[step.ID()] {
+					if _, ok := reasons[dep]; ok {
+						reasons[step.ID()] = "depends on refused " + dep
+						changed = true
+						break
+					}
+				}
+			}
+		}
+	}
+
+	var refusals []Refusal
+	for _, p := range plans {
+		kept := p.Steps[:0:0]
+		for _, step := range p.Steps {
+			if reason, ok := reasons[step.ID()]; ok {
+				refusals = append(refusals, Refusal{Step: step, Reason: reason})
+				continue
+			}
+			kept = append(kept, step)
+		}
+		p.Steps = kept
+	}
+	return refusals
+}
+
+func refuseReason(step *Step, planned map[string]Change) string {
+	c, ok := planned[step.ID()]
+	switch {
+	case !ok:
+		return "not in the plan; the live resource changed since planning"
+	case c.Action != step.Action:
+		return fmt.Sprintf("planned to %s, but would now %s", c.Action, step.Action)
+	case c.Revision != step.LiveRevision:
+		return fmt.Sprintf(
+			"live revision moved from %d to %d since planning", c.Revision, step.LiveRevision,
+		)
+	}
+	return ""
+}
+
+// PrintRefusals reports each refused step.
+func PrintRefusals(out io.Writer, refusals []Refusal) {
+	for _, r := range refusals {
+		fmt.Fprintf(
+			out,
+			"Refused to %s %s %q: %s\n",
+			r.Step.Action, r.Step.Resource, r.Step.Name, r.Reason,
+		)
+	}
+}
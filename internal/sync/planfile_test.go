@@ -0,0 +1,264 @@
+package sync
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRestrictRefusesStepsThatMovedSincePlanning(t *testing.T) {
+	var log stepLog
+	step := func(resource, name, action string, live int) *Step {
+		s := testStep(&log, resource, name, action, nil)
+		s.LiveRevision = live
+		return s
+	}
+	databases := testPlan("databases",
+		step("database", "orders-db", "update", 4),
+		step("database", "old-db", "delete", 2),
+	)
+	services := testPlan("services",
+		step("service", "orders", "update", 7),
+		step("service", "web", "create", 0),
+		step("service", "worker", "update", 3),
+	)
+	agents := testPlan("agents", step("agent", "chat", "update", 2))
+
+	planned := []Change{
+		{Resource: "database", Name: "orders-db", Action: "update", Revision: 3},
+		{Resource: "database", Name: "old-db", Action: "delete", Revision: 2},
+		{Resource: "service", Name: "orders", Action: "update", Revision: 7},
+		{Resource: "service", Name: "web", Action: "create"},
+		{Resource: "agent", Name: "chat", Action: "create"},
+	}
+	deps := map[string][]string{"service/orders": {"database/orders-db"}}
+
+	refusals := Restrict([]*Plan{databases, services, agents}, planned, deps)
+
+	var out bytes.Buffer
+	PrintRefusals(&out, refusals)
+	want := `Refused to update database "orders-db": live revision moved from 3 to 4 since planning
+Refused to update service "orders": depends on refused database/orders-db
+Refused to update service "worker": not in the plan; the live resource changed since planning
+Refused to update agent "chat": planned to create, but would now update
+`
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("refusals mismatch (-want +got):\n%s", diff)
+	}
+
+	var kept []string
+	for _, p := range []*Plan{databases, services, agents} {
+		for _, s := range p.Steps {
+			kept = append(kept, s.Action+" "+s.ID())
+		}
+	}
+	wantKept := []string{"delete database/old-db", "create service/web"}
+	if diff := cmp.Diff(wantKept, kept); diff != "" {
+		t.Errorf("kept steps mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlanFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plans", "plan.json")
+	if err := os.Mkdir(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	plan := &PlanFile{
+		Version:      PlanFileVersion,
+		CreatedAt:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		File:         filepath.Join(dir, "stack.yaml"),
+		Overlays:     []string{filepath.Join(dir, "prod.yaml")},
+		ConfigDigest: "abc123",
+		AllowDelete:  []string{"services"},
+		Changes: Changes([]*Plan{testPlan("services",
+			&Step{Resource: "service", Name: "web", Action: "update", LiveRevision: 3},
+		)}),
+	}
+	if err := WritePlanFile(path, plan); err != nil {
+		t.Fatalf("WritePlanFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"file": "../stack.yaml"`)) {
+		t.Errorf("plan file doesn't record the stack file relative to it:\n%s", data)
+	}
+
+	got, err := ReadPlanFile(path)
+	if err != nil {
+		t.Fatalf("ReadPlanFile() error = %v", err)
+	}
+	if diff := cmp.Diff(plan, got); diff != "" {
+		t.Errorf("plan mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlanFileOmitsCredentials(t *testing.T) {
+	t.Setenv("TOOLS_TOKEN", "tok-from-env")
+	t.Setenv("API_KEY", "key-from-env")
+	dir := t.TempDir()
+	stackPath := filepath.Join(dir, "stack.yaml")
+	writeFile(t, stackPath, `stack-id: shop
+services:
+  api:
+    servicePort: 8080
+    image: {name: api, tag: "1"}
+    env:
+      - name: API_KEY
+        value: ${API_KEY}
+mcps:
+  tools:
+    endpointUrl: https://tools.example.com/mcp
+    auth: {type: bearer, credential: "${TOOLS_TOKEN}"}
+`)
+	cfg, err := files.LoadStackConfig(stackPath)
+	if err != nil {
+		t.Fatalf("LoadStackConfig() error = %v", err)
+	}
+	digest, err := ConfigDigest(cfg)
+	if err != nil {
+		t.Fatalf("ConfigDigest() error = %v", err)
+	}
+
+	path := filepath.Join(dir, "plan.json")
+	err = WritePlanFile(path, &PlanFile{
+		Version:      PlanFileVersion,
+		File:         stackPath,
+		ConfigDigest: digest,
+		Changes:      []Change{},
+	})
+	if err != nil {
+		t.Fatalf("WritePlanFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"tok-from-env", "key-from-env"} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("plan file contains %q:\n%s", secret, data)
+		}
+	}
+
+	// Apply loads the config again, with the credential, and a rotated
+	// credential doesn't invalidate the plan.
+	t.Setenv("TOOLS_TOKEN", "rotated")
+	plan, err := ReadPlanFile(path)
+	if err != nil {
+		t.Fatalf("ReadPlanFile() error = %v", err)
+	}
+	got, err := plan.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cred := got.Mcps["tools"].Auth.Credential; cred != "rotated" {
+		t.Errorf("credential = %q, want %q", cred, "rotated")
+	}
+}
+
+func TestPlanFileLoadConfigRejectsChangedConfig(t *testing.T) {
+	t.Setenv("API_KEY", "before")
+	dir := t.TempDir()
+	stackPath := filepath.Join(dir, "stack.yaml")
+	writeFile(t, stackPath, `stack-id: shop
+services:
+  api:
+    servicePort: 8080
+    image: {name: api, tag: "1"}
+    env:
+      - name: API_KEY
+        value: ${API_KEY}
+`)
+	cfg, err := files.LoadStackConfig(stackPath)
+	if err != nil {
+		t.Fatalf("LoadStackConfig() error = %v", err)
+	}
+	digest, err := ConfigDigest(cfg)
+	if err != nil {
+		t.Fatalf("ConfigDigest() error = %v", err)
+	}
+	plan := &PlanFile{Version: PlanFileVersion, File: stackPath, ConfigDigest: digest}
+
+	t.Setenv("API_KEY", "after")
+	_, err = plan.LoadConfig()
+	wantErr := "stack config " + stackPath +
+		" changed since planning; run 'iai stacks plan' again"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("LoadConfig() error = %v, want %q", err, wantErr)
+	}
+}
+
+func TestPlanFileKeepsResolvedProject(t *testing.T) {
+	dir := t.TempDir()
+	stackPath := filepath.Join(dir, "stack.yaml")
+	writeFile(t, stackPath, `stack-id: shop
+services:
+  api:
+    servicePort: 8080
+    image: {name: api, tag: "1"}
+`)
+	cfg, err := files.LoadStackConfig(stackPath)
+	if err != nil {
+		t.Fatalf("LoadStackConfig() error = %v", err)
+	}
+	digest, err := ConfigDigest(cfg)
+	if err != nil {
+		t.Fatalf("ConfigDigest() error = %v", err)
+	}
+	// Planning resolves them from flags or the selected project.
+	cfg.Organization, cfg.Project = "my-org", "dev"
+
+	path := filepath.Join(dir, "plan.json")
+	err = WritePlanFile(path, &PlanFile{
+		Version:      PlanFileVersion,
+		File:         stackPath,
+		ConfigDigest: digest,
+		Organization: cfg.Organization,
+		Project:      cfg.Project,
+		Changes:      []Change{},
+	})
+	if err != nil {
+		t.Fatalf("WritePlanFile() error = %v", err)
+	}
+
+	plan, err := ReadPlanFile(path)
+	if err != nil {
+		t.Fatalf("ReadPlanFile() error = %v", err)
+	}
+	if _, err := plan.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if plan.Organization != "my-org" || plan.Project != "dev" {
+		t.Errorf("Organization, Project = %q, %q, want my-org, dev", plan.Organization, plan.Project)
+	}
+}
+
+func TestReadPlanFileRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	err := WritePlanFile(path, &PlanFile{Version: 2, File: "stack.yaml"})
+	if err != nil {
+		t.Fatalf("WritePlanFile() error = %v", err)
+	}
+
+	_, err = ReadPlanFile(path)
+	wantErr := "unsupported plan file version 2 (expected 1)"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("ReadPlanFile() error = %v, want %q", err, wantErr)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"fmt"
+	"io"
+	gosync "sync"
+)
+
+// Journal records the changes a sync applies so they can be reverted if a
+// later step fails. It is safe for concurrent use; a nil Journal records
+// nothing.
+type Journal struct {
+	mu      gosync.Mutex
+	entries []journalEntry
+}
+
+type journalEntry struct {
+	step   *Step
+	undo   func() error // nil when the change can't be reverted
+	detail string       // what undo does, e.g. "restored revision 3"
+}
+
+// Reversion is the outcome of reverting one recorded change.
+type Reversion struct {
+	Step   *Step
+	Detail string // what reverting did, e.g. "deleted" or "restored revision 3"
+	Err    error  // set when the change could not be reverted
+}
+
+func (j *Journal) record(step *Step, undo func() error, detail string) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = append(j.entries, journalEntry{step: step, undo: undo, detail: detail})
+}
+
+// Len returns the number of changes recorded.
+func (j *Journal) Len() int {
+	if j == nil {
+		return 0
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.entries)
+}
+
+// Rollback reverts the recorded changes one at a time, newest first, so
+// resources are reverted before the ones they depend on. Created resources
+// are deleted and updated ones restored to their previous spec; deletions
+// can't be reverted. A failed revert doesn't stop the others. The journal is
+// empty afterwards.
+func (j *Journal) Rollback() []Reversion {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	entries := j.entries
+	j.entries = nil
+	j.mu.Unlock()
+
+	reversions := make([]Reversion, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		r := Reversion{Step: e.step, Detail: e.detail}
+		switch {
+		case e.step.Action == "delete":
+			r.Err = fmt.Errorf("deleted resources can't be restored")
+		case e.undo == nil:
+			r.Err = fmt.Errorf("no previous spec was recorded")
+		default:
+			r.Err = e.undo()
+		}
+		reversions = append(reversions, r)
+	}
+	return reversions
+}
+
+// PrintRollback reports each reverted change and returns an error if any
+// change could not be reverted.
+func PrintRollback(out io.Writer, reversions []Reversion) error {
+	failed := 0
+	for _, r := range reversions {
+		pastTense := r.Step.Action + "d"
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(
+				out,
+				"Could not revert %s %s %q: %v\n",
+				pastTense, r.Step.Resource, r.Step.Name, r.Err,
+			)
+			continue
+		}
+		fmt.Fprintf(
+			out,
+			"Reverted %s %s %q: %s\n",
+			pastTense, r.Step.Resource, r.Step.Name, r.Detail,
+		)
+	}
+	if failed > 0 {
+		return fmt.Errorf(
+			"rollback incomplete: %d of %d changes could not be reverted",
+			failed, len(reversions),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJournalRollsBackAppliedChanges(t *testing.T) {
+	const base = "/v1/organizations/o1/projects/p1"
+	var writes []string
+	var restored deployment.CreateServiceBody
+
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == base+"/services":
+			fmt.Fprint(w, `{"services":[{"name":"svc-a","projectId":"p1","revision":3,"status":"ready"}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == base+"/services/svc-a":
+			fmt.Fprint(w, `{"name":"svc-a","servicePort":8080}`)
+		case r.Method == http.MethodGet && r.URL.Path == base+"/services/svc-a/revisions/3":
+			fmt.Fprint(
+				w,
+				`{"revision":3,"servicePort":8080,"image":{"name":"web","tag":"v1"},"replicas":2,"stackId":"stack-1"}`,
+			)
+		case r.Method == http.MethodGet && r.URL.Path == base+"/agents":
+			fmt.Fprint(w, `{"agents":[]}`)
+		case r.Method == http.MethodPost && r.URL.Path == base+"/agents/chat":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message":"invalid agentConfig"}`)
+		case r.Method == http.MethodPut && r.URL.Path == base+"/services/svc-a":
+			writes = append(writes, r.Method+" "+strings.TrimPrefix(r.URL.Path, base+"/"))
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &restored); err != nil {
+				t.Errorf("decode PUT body: %v", err)
+			}
+			fmt.Fprint(w, `{}`)
+		case r.Method == http.MethodPost || r.Method == http.MethodDelete:
+			writes = append(writes, r.Method+" "+strings.TrimPrefix(r.URL.Path, base+"/"))
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+	var warn bytes.Buffer
+	journal := &Journal{}
+	opts := Options{Journal: journal}
+	services, err := PlanServices(ctx, &warn, client, "o1", "p1", "stack-1",
+		map[string]deployment.CreateServiceBody{
+			"svc-a":   {ServicePort: 9090},
+			"svc-new": {ServicePort: 8080},
+		}, opts)
+	if err != nil {
+		t.Fatalf("PlanServices() error = %v", err)
+	}
+	agents, err := PlanAgents(ctx, &warn, client, "o1", "p1", "stack-1",
+		map[string]deployment.CreateAgentBody{"chat": {Id: "support"}}, opts)
+	if err != nil {
+		t.Fatalf("PlanAgents() error = %v", err)
+	}
+
+	_, errs := ApplyPlans([]*Plan{services, agents}, map[string][]string{
+		"agent/chat": {"service/svc-a", "service/svc-new"},
+	}, 1)
+	if errs[0] != nil || errs[1] == nil {
+		t.Fatalf("ApplyPlans() errs = %v, want only the agents plan to fail", errs)
+	}
+	if got := journal.Len(); got != 2 {
+		t.Fatalf("journal.Len() = %d, want 2", got)
+	}
+	writes = nil
+
+	var out bytes.Buffer
+	if err := PrintRollback(&out, journal.Rollback()); err != nil {
+		t.Fatalf("PrintRollback() error = %v", err)
+	}
+
+	wantOut := "Reverted created service \"svc-new\": deleted\n" +
+		"Reverted updated service \"svc-a\": restored revision 3\n"
+	if got := out.String(); got != wantOut {
+		t.Errorf("output = %q, want %q", got, wantOut)
+	}
+	if diff := cmp.Diff([]string{"DELETE services/svc-new", "PUT services/svc-a"}, writes); diff != "" {
+		t.Errorf("writes mismatch (-want +got):\n%s", diff)
+	}
+	wantRestored := deployment.CreateServiceBody{
+		ServicePort: 8080,
+		Image:       deployment.ImageSpec{Name: "web", Tag: "v1"},
+		Replicas:    2,
+		StackId:     "stack-1",
+	}
+	if diff := cmp.Diff(wantRestored, restored); diff != "" {
+		t.Errorf("restored spec mismatch (-want +got):\n%s", diff)
+	}
+	if got := journal.Len(); got != 0 {
+		t.Errorf("journal.Len() after Rollback = %d, want 0", got)
+	}
+}
+
+func TestPrintRollbackReportsChangesThatCannotBeReverted(t *testing.T) {
+	journal := &Journal{}
+	journal.record(
+		&Step{Resource: "database", Name: "old-db", Action: "delete"}, nil, "",
+	)
+	journal.record(
+		&Step{Resource: "mcp", Name: "tools", Action: "create"},
+		func() error { return errors.New("mcp is attached to agents") },
+		"deleted",
+	)
+
+	var out bytes.Buffer
+	err := PrintRollback(&out, journal.Rollback())
+
+	wantOut := "Could not revert created mcp \"tools\": mcp is attached to agents\n" +
+		"Could not revert deleted database \"old-db\": deleted resources can't be restored\n"
+	if got := out.String(); got != wantOut {
+		t.Errorf("output = %q, want %q", got, wantOut)
+	}
+	wantErr := "rollback incomplete: 2 of 2 changes could not be reverted"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("error = %v, want %q", err, wantErr)
+	}
+}
+
+func TestNilJournalRecordsNothing(t *testing.T) {
+	var journal *Journal
+	journal.record(&Step{Resource: "service", Name: "api", Action: "create"}, nil, "deleted")
+	if got := journal.Len(); got != 0 {
+		t.Errorf("Len() = %d, want 0", got)
+	}
+	if got := journal.Rollback(); got != nil {
+		t.Errorf("Rollback() = %v, want nil", got)
+	}
+}
+
+func TestJournalRollbackKeepsMcpCredential(t *testing.T) {
+	const path = "/v1/organizations/o1/projects/p1/mcps/tools"
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/organizations/o1/projects/p1/mcps":
+			fmt.Fprint(w, `{"mcps":[{"name":"tools","projectId":"p1","revision":1,"type":"external","auth":{"type":"bearer"}}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == path:
+			fmt.Fprint(w, `{"name":"tools","revision":1,"type":"external","endpointUrl":"https://mcp.example.com/mcp","auth":{"type":"bearer"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == path+"/revisions/1":
+			fmt.Fprint(w, `{"revision":1,"type":"external","endpointUrl":"https://mcp.example.com/mcp","auth":{"type":"bearer"},"stackId":"stack-1"}`)
+		case r.Method == http.MethodPut && r.URL.Path == path:
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	journal := &Journal{}
+	_, err := Mcps(context.Background(), io.Discard, client, "o1", "p1", "stack-1",
+		map[string]deployment.CreateMcpBody{"tools": {
+			Type:        "external",
+			EndpointURL: "https://mcp.example.com/mcp",
+			Auth:        deployment.McpAuthBody{Type: "bearer", Credential: "rotated"},
+			StackId:     "stack-1",
+		}}, Options{Journal: journal})
+	if err != nil {
+		t.Fatalf("Mcps() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := PrintRollback(&out, journal.Rollback()); err != nil {
+		t.Fatalf("PrintRollback() error = %v", err)
+	}
+	want := "Reverted updated mcp \"tools\": restored revision 1; credential not reverted\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"sort"
 	"strings"
 
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/platform"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/output"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/preflight"
 )
@@ -25,12 +26,21 @@ type Result struct {
 	Created   []string
 	Updated   []string
 	Deleted   []string
+	Unchanged []string // exist and already match the desired spec; not updated
 	Protected []string // would be deleted but deletion was not allowed
+	Skipped   []string // not selected by Options.Targets; left alone
 }
 
 type Options struct {
 	AllowDelete bool
 	DryRun      bool
+	Parallelism int // concurrent live-state checks while planning; <= 1 runs them one at a time
+	// Journal, when set, records every applied change and the spec it
+	// replaced, so a failed sync can be rolled back.
+	Journal *Journal
+	// Targets, when set, restricts the sync to the resources it selects.
+	// Every other resource is skipped: neither changed nor deleted.
+	Targets *Targets
 }
 
 func HasServices(
@@ -107,6 +117,7 @@ func PrintResult(
 				result.Created,
 				result.Updated,
 				result.Deleted,
+				result.Unchanged,
 			)
 		}
 		return err
@@ -117,6 +128,7 @@ func PrintResult(
 		result.Created,
 		result.Updated,
 		result.Deleted,
+		result.Unchanged,
 	)
 	if len(result.Protected) > 0 {
 		fmt.Fprintf(
@@ -128,6 +140,14 @@ func PrintResult(
 			label,
 		)
 	}
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(
+			out,
+			"Skipped %s (not targeted): %s\n",
+			label,
+			strings.Join(result.Skipped, ", "),
+		)
+	}
 	return nil
 }
 
@@ -141,6 +161,9 @@ func PrintPlan(out io.Writer, label string, result *Result) {
 	if len(result.Deleted) > 0 {
 		fmt.Fprintf(out, "Would delete %s: %s\n", label, strings.Join(result.Deleted, ", "))
 	}
+	if len(result.Unchanged) > 0 {
+		fmt.Fprintf(out, "Unchanged %s: %s\n", label, strings.Join(result.Unchanged, ", "))
+	}
 	if len(result.Protected) > 0 {
 		fmt.Fprintf(
 			out,
@@ -150,8 +173,17 @@ func PrintPlan(out io.Writer, label string, result *Result) {
 			label,
 		)
 	}
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(
+			out,
+			"Skipped %s (not targeted): %s\n",
+			label,
+			strings.Join(result.Skipped, ", "),
+		)
+	}
 	if len(result.Created) == 0 && len(result.Updated) == 0 &&
-		len(result.Deleted) == 0 && len(result.Protected) == 0 {
+		len(result.Deleted) == 0 && len(result.Protected) == 0 &&
+		(len(result.Unchanged) > 0 || len(result.Skipped) == 0) {
 		fmt.Fprintf(out, "No changes required; %s already match config.\n", label)
 	}
 }
@@ -166,6 +198,25 @@ func Services(
 	desired map[string]deployment.CreateServiceBody,
 	opts Options,
 ) (*Result, error) {
+	plan, err := PlanServices(ctx, warnW, deployClient, orgId, projectId, stackId, desired, opts)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Apply()
+}
+
+// PlanServices lists the stack's services and plans the changes that make them
+// match desired, without applying anything.
+func PlanServices(
+	ctx context.Context,
+	warnW io.Writer,
+	deployClient *deployment.DeploymentClient,
+	orgId,
+	projectId,
+	stackId string,
+	desired map[string]deployment.CreateServiceBody,
+	opts Options,
+) (*Plan, error) {
 	existing, err := deployClient.ListServices(
 		ctx, orgId, projectId, stackId,
 	)
@@ -178,7 +229,7 @@ func Services(
 		existingByName[svc.Name] = svc
 	}
 
-	return syncResources(
+	return planResources(
 		warnW,
 		existingByName,
 		desired,
@@ -186,10 +237,19 @@ func Services(
 		resourceOps[deployment.ServiceOutput, deployment.CreateServiceBody]{
 			resource:  "service",
 			allowFlag: "services",
+			revision:  func(svc deployment.ServiceOutput) int { return svc.Revision },
 			create: func(name string, body deployment.CreateServiceBody) error {
 				_, err := deployClient.CreateService(ctx, orgId, projectId, name, body)
 				return err
 			},
+			unchanged: func(name string, body deployment.CreateServiceBody) (bool, error) {
+				desc, err := deployClient.DescribeService(ctx, orgId, projectId, name)
+				if err != nil {
+					return false, err
+				}
+				live := files.ServiceConfigFromDescribe(desc).ToCreateRequest(stackId)
+				return !files.SpecChanged(live, body), nil
+			},
 			update: func(name string, body deployment.CreateServiceBody) error {
 				_, err := deployClient.PutService(ctx, orgId, projectId, name, body)
 				return err
@@ -201,6 +261,23 @@ func Services(
 			banner: func(w io.Writer, svc deployment.ServiceOutput) {
 				preflight.PrintUpdateBanner(w, "service "+svc.Name, svc.Revision, svc.Updated)
 			},
+			snapshot: func(
+				name string,
+				svc deployment.ServiceOutput,
+				_ deployment.CreateServiceBody,
+			) (func() error, string, error) {
+				rev, err := deployClient.DescribeServiceRevision(
+					ctx, orgId, projectId, name, svc.Revision,
+				)
+				if err != nil {
+					return nil, "", err
+				}
+				prev := files.ServiceConfigFromRevision(rev).ToCreateRequest(stackId)
+				return func() error {
+					_, err := deployClient.PutService(ctx, orgId, projectId, name, prev)
+					return err
+				}, fmt.Sprintf("restored revision %d", svc.Revision), nil
+			},
 		},
 	)
 }
@@ -215,6 +292,25 @@ func Agents(
 	desired map[string]deployment.CreateAgentBody,
 	opts Options,
 ) (*Result, error) {
+	plan, err := PlanAgents(ctx, warnW, deployClient, orgId, projectId, stackId, desired, opts)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Apply()
+}
+
+// PlanAgents lists the stack's agents and plans the changes that make them
+// match desired, without applying anything.
+func PlanAgents(
+	ctx context.Context,
+	warnW io.Writer,
+	deployClient *deployment.DeploymentClient,
+	orgId,
+	projectId,
+	stackId string,
+	desired map[string]deployment.CreateAgentBody,
+	opts Options,
+) (*Plan, error) {
 	existing, err := deployClient.ListAgents(
 		ctx, orgId, projectId, stackId,
 	)
@@ -227,7 +323,7 @@ func Agents(
 		existingByName[a.Name] = a
 	}
 
-	return syncResources(
+	return planResources(
 		warnW,
 		existingByName,
 		desired,
@@ -235,10 +331,19 @@ func Agents(
 		resourceOps[deployment.AgentOutput, deployment.CreateAgentBody]{
 			resource:  "agent",
 			allowFlag: "agents",
+			revision:  func(a deployment.AgentOutput) int { return a.Revision },
 			create: func(name string, body deployment.CreateAgentBody) error {
 				_, err := deployClient.CreateAgent(ctx, orgId, projectId, name, body)
 				return err
 			},
+			unchanged: func(name string, body deployment.CreateAgentBody) (bool, error) {
+				desc, err := deployClient.DescribeAgent(ctx, orgId, projectId, name)
+				if err != nil {
+					return false, err
+				}
+				live := files.AgentConfigFromDescribe(desc).ToCreateRequest(stackId)
+				return !files.SpecChanged(live, body), nil
+			},
 			update: func(name string, body deployment.CreateAgentBody) error {
 				_, err := deployClient.PutAgent(ctx, orgId, projectId, name, body)
 				return err
@@ -250,6 +355,23 @@ func Agents(
 			banner: func(w io.Writer, a deployment.AgentOutput) {
 				preflight.PrintUpdateBanner(w, "agent "+a.Name, a.Revision, a.Updated)
 			},
+			snapshot: func(
+				name string,
+				a deployment.AgentOutput,
+				_ deployment.CreateAgentBody,
+			) (func() error, string, error) {
+				rev, err := deployClient.DescribeAgentRevision(
+					ctx, orgId, projectId, name, a.Revision,
+				)
+				if err != nil {
+					return nil, "", err
+				}
+				prev := files.AgentConfigFromRevision(rev).ToCreateRequest(stackId)
+				return func() error {
+					_, err := deployClient.PutAgent(ctx, orgId, projectId, name, prev)
+					return err
+				}, fmt.Sprintf("restored revision %d", a.Revision), nil
+			},
 		},
 	)
 }
@@ -264,6 +386,25 @@ func Databases(
 	desired map[string]deployment.CreateDatabaseBody,
 	opts Options,
 ) (*Result, error) {
+	plan, err := PlanDatabases(ctx, warnW, deployClient, orgId, projectId, stackId, desired, opts)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Apply()
+}
+
+// PlanDatabases lists the stack's databases and plans the changes that make them
+// match desired, without applying anything.
+func PlanDatabases(
+	ctx context.Context,
+	warnW io.Writer,
+	deployClient *deployment.DeploymentClient,
+	orgId,
+	projectId,
+	stackId string,
+	desired map[string]deployment.CreateDatabaseBody,
+	opts Options,
+) (*Plan, error) {
 	existing, err := deployClient.ListDatabases(
 		ctx, orgId, projectId, stackId,
 	)
@@ -276,7 +417,7 @@ func Databases(
 		existingByName[db.Name] = db
 	}
 
-	return syncResources(
+	return planResources(
 		warnW,
 		existingByName,
 		desired,
@@ -284,10 +425,19 @@ func Databases(
 		resourceOps[deployment.DatabaseOutput, deployment.CreateDatabaseBody]{
 			resource:  "database",
 			allowFlag: "databases",
+			revision:  func(db deployment.DatabaseOutput) int { return db.Revision },
 			create: func(name string, body deployment.CreateDatabaseBody) error {
 				_, err := deployClient.CreateDatabase(ctx, orgId, projectId, name, body)
 				return err
 			},
+			unchanged: func(name string, body deployment.CreateDatabaseBody) (bool, error) {
+				desc, err := deployClient.DescribeDatabase(ctx, orgId, projectId, name)
+				if err != nil {
+					return false, err
+				}
+				live := files.DatabaseConfigFromDescribe(desc).ToCreateRequest(stackId)
+				return !files.SpecChanged(live, body), nil
+			},
 			update: func(name string, body deployment.CreateDatabaseBody) error {
 				_, err := deployClient.PutDatabase(ctx, orgId, projectId, name, body)
 				return err
@@ -296,6 +446,23 @@ func Databases(
 				_, err := deployClient.DeleteDatabase(ctx, orgId, projectId, name)
 				return err
 			},
+			// Databases keep no revision history; restore the live spec
+			// read just before the update.
+			snapshot: func(
+				name string,
+				_ deployment.DatabaseOutput,
+				_ deployment.CreateDatabaseBody,
+			) (func() error, string, error) {
+				desc, err := deployClient.DescribeDatabase(ctx, orgId, projectId, name)
+				if err != nil {
+					return nil, "", err
+				}
+				prev := files.DatabaseConfigFromDescribe(desc).ToCreateRequest(stackId)
+				return func() error {
+					_, err := deployClient.PutDatabase(ctx, orgId, projectId, name, prev)
+					return err
+				}, "restored previous spec", nil
+			},
 		},
 	)
 }
@@ -310,6 +477,25 @@ func Mcps(
 	desired map[string]deployment.CreateMcpBody,
 	opts Options,
 ) (*Result, error) {
+	plan, err := PlanMcps(ctx, warnW, deployClient, orgId, projectId, stackId, desired, opts)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Apply()
+}
+
+// PlanMcps lists the stack's mcps and plans the changes that make them
+// match desired, without applying anything.
+func PlanMcps(
+	ctx context.Context,
+	warnW io.Writer,
+	deployClient *deployment.DeploymentClient,
+	orgId,
+	projectId,
+	stackId string,
+	desired map[string]deployment.CreateMcpBody,
+	opts Options,
+) (*Plan, error) {
 	existing, err := deployClient.ListMcps(
 		ctx, orgId, projectId, stackId,
 	)
@@ -322,7 +508,7 @@ func Mcps(
 		existingByName[mcp.Name] = mcp
 	}
 
-	return syncResources(
+	return planResources(
 		warnW,
 		existingByName,
 		desired,
@@ -330,10 +516,25 @@ func Mcps(
 		resourceOps[deployment.McpOutput, deployment.CreateMcpBody]{
 			resource:  "mcp",
 			allowFlag: "mcps",
+			revision:  func(mcp deployment.McpOutput) int { return mcp.Revision },
 			create: func(name string, body deployment.CreateMcpBody) error {
 				_, err := deployClient.CreateMcp(ctx, orgId, projectId, name, body)
 				return err
 			},
+			unchanged: func(name string, body deployment.CreateMcpBody) (bool, error) {
+				desc, err := deployClient.DescribeMcp(ctx, orgId, projectId, name)
+				if err != nil {
+					return false, err
+				}
+				// The live spec never carries the credential, so whether it
+				// changed can't be told: a config that sets one always sends
+				// it, so rotating it in the stack file takes effect.
+				if body.Auth.Credential != "" {
+					return false, nil
+				}
+				live := files.McpConfigFromDescribe(desc).ToCreateRequest(stackId)
+				return !files.SpecChanged(live, body), nil
+			},
 			update: func(name string, body deployment.CreateMcpBody) error {
 				authType := body.Auth.Type
 				if authType == "" {
@@ -359,85 +560,216 @@ func Mcps(
 			banner: func(w io.Writer, mcp deployment.McpOutput) {
 				preflight.PrintUpdateBanner(w, "mcp "+mcp.Name, mcp.Revision, mcp.Updated)
 			},
+			snapshot: func(
+				name string,
+				mcp deployment.McpOutput,
+				body deployment.CreateMcpBody,
+			) (func() error, string, error) {
+				rev, err := deployClient.DescribeMcpRevision(
+					ctx, orgId, projectId, name, mcp.Revision,
+				)
+				if err != nil {
+					return nil, "", err
+				}
+				prevCfg, err := files.McpConfigFromRevision(rev)
+				if err != nil {
+					return nil, "", err
+				}
+				// Revisions never carry the credential; restore with the
+				// one from the config, which the update is about to set.
+				prev := prevCfg.ToCreateRequest(stackId)
+				prev.Auth.Credential = body.Auth.Credential
+				detail := fmt.Sprintf("restored revision %d", mcp.Revision)
+				if prev.Auth.Credential != "" {
+					detail += "; credential not reverted"
+				}
+				return func() error {
+					_, err := deployClient.PutMcp(ctx, orgId, projectId, name, prev)
+					return err
+				}, detail, nil
+			},
 		},
 	)
 }
 
-type resourceOps[E, B any] struct {
-	resource  string
-	allowFlag string
-	create    func(name string, body B) error
-	update    func(name string, body B) error
-	delete    func(name string) error
-	banner    func(w io.Writer, existing E)
+// Secrets creates or replaces the stack file's secrets. Secrets are not
+// tagged with a stack ID, so secrets the config omits are never deleted.
+// desired maps each secret name to its resolved data.
+func Secrets(
+	ctx context.Context,
+	warnW io.Writer,
+	deployClient *deployment.DeploymentClient,
+	orgId, projectId string,
+	desired map[string]map[string]string,
+	opts Options,
+) (*Result, error) {
+	plan, err := PlanSecrets(ctx, warnW, deployClient, orgId, projectId, desired, opts)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Apply()
 }
 
-func syncResources[E, B any](
+// PlanSecrets plans the creates and replacements Secrets would make.
+func PlanSecrets(
+	ctx context.Context,
 	warnW io.Writer,
-	existingByName map[string]E,
-	desired map[string]B,
+	deployClient *deployment.DeploymentClient,
+	orgId, projectId string,
+	desired map[string]map[string]string,
 	opts Options,
-	ops resourceOps[E, B],
-) (*Result, error) {
-	result := &Result{}
+) (*Plan, error) {
+	secrets, err := deployClient.ListSecrets(ctx, orgId, projectId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
 
-	var toDelete []string
-	for name := range existingByName {
-		if _, ok := desired[name]; !ok {
-			toDelete = append(toDelete, name)
+	existingByName := make(map[string]deployment.SecretInfo)
+	for _, s := range secrets {
+		if _, ok := desired[s.Name]; ok {
+			existingByName[s.Name] = s
 		}
 	}
-	sort.Strings(toDelete)
-	if !opts.DryRun {
-		preflight.PrintSyncDeletions(warnW, ops.resource, ops.allowFlag, toDelete, opts.AllowDelete)
-	}
-	if !opts.AllowDelete {
-		result.Protected = toDelete
-		toDelete = nil
+
+	return planResources(
+		warnW,
+		existingByName,
+		desired,
+		opts,
+		resourceOps[deployment.SecretInfo, map[string]string]{
+			resource:  "secret",
+			allowFlag: "secrets",
+			create: func(name string, data map[string]string) error {
+				_, err := deployClient.CreateSecret(ctx, orgId, projectId, name, data)
+				return err
+			},
+			unchanged: func(name string, data map[string]string) (bool, error) {
+				live, err := deployClient.GetSecret(ctx, orgId, projectId, name)
+				if err != nil {
+					return false, err
+				}
+				return !files.SecretDataChanged(live.Data, data), nil
+			},
+			update: func(name string, data map[string]string) error {
+				_, err := deployClient.ReplaceSecret(ctx, orgId, projectId, name, data)
+				return err
+			},
+			// Only reached when rolling back a secret this sync created:
+			// existingByName holds declared secrets only, so no delete is
+			// ever planned.
+			delete: func(name string) error {
+				_, err := deployClient.DeleteSecret(ctx, orgId, projectId, name)
+				return err
+			},
+			snapshot: func(
+				name string,
+				_ deployment.SecretInfo,
+				_ map[string]string,
+			) (func() error, string, error) {
+				live, err := deployClient.GetSecret(ctx, orgId, projectId, name)
+				if err != nil {
+					return nil, "", err
+				}
+				return func() error {
+					_, err := deployClient.ReplaceSecret(ctx, orgId, projectId, name, live.Data)
+					return err
+				}, "restored previous values", nil
+			},
+		},
+	)
+}
+
+// ContextItems syncs one kind of context item (routines, policies, ...).
+// Context items are versioned rather than replaced: an item whose latest
+// version differs from the config gets a new version, and matching items are
+// left alone. They are not tagged with a stack ID, so items the config omits
+// are never deleted.
+func ContextItems(
+	ctx context.Context,
+	warnW io.Writer,
+	apiClient *platform.APIClient,
+	projectId string,
+	kind files.ContextKind,
+	desired map[string]files.ContextItemConfig,
+	opts Options,
+) (*Result, error) {
+	plan, err := PlanContextItems(ctx, warnW, apiClient, projectId, kind, desired, opts)
+	if err != nil {
+		return nil, err
 	}
+	return plan.Apply()
+}
 
-	desiredNames := make([]string, 0, len(desired))
-	for name := range desired {
-		desiredNames = append(desiredNames, name)
+// PlanContextItems plans the new versions ContextItems would create.
+func PlanContextItems(
+	ctx context.Context,
+	warnW io.Writer,
+	apiClient *platform.APIClient,
+	projectId string,
+	kind files.ContextKind,
+	desired map[string]files.ContextItemConfig,
+	opts Options,
+) (*Plan, error) {
+	names, err := files.ListContextItemNames(ctx, apiClient, projectId, kind)
+	if err != nil {
+		return nil, err
 	}
-	sort.Strings(desiredNames)
 
-	for _, name := range desiredNames {
-		body := desired[name]
-		if existing, exists := existingByName[name]; !exists {
-			if !opts.DryRun {
-				if err := ops.create(name, body); err != nil {
-					return result, fmt.Errorf(
-						"failed to create %s %q: %w", ops.resource, name, err,
-					)
-				}
-			}
-			result.Created = append(result.Created, name)
-		} else {
-			if !opts.DryRun {
-				if ops.banner != nil {
-					ops.banner(warnW, existing)
-				}
-				if err := ops.update(name, body); err != nil {
-					return result, fmt.Errorf(
-						"failed to update %s %q: %w", ops.resource, name, err,
-					)
-				}
-			}
-			result.Updated = append(result.Updated, name)
+	existingByName := make(map[string]struct{})
+	for name := range desired {
+		if names[name] {
+			existingByName[name] = struct{}{}
 		}
 	}
 
-	for _, name := range toDelete {
-		if !opts.DryRun {
-			if err := ops.delete(name); err != nil {
-				return result, fmt.Errorf(
-					"failed to delete %s %q: %w", ops.resource, name, err,
-				)
-			}
-		}
-		result.Deleted = append(result.Deleted, name)
+	create := func(name string, item files.ContextItemConfig) error {
+		_, err := apiClient.CreatePrompt(
+			ctx, projectId, kind.RouteSegment, item.ToCreateRequest(name),
+		)
+		return err
 	}
 
-	return result, nil
+	return planResources(
+		warnW,
+		existingByName,
+		desired,
+		opts,
+		resourceOps[struct{}, files.ContextItemConfig]{
+			resource:  kind.TypeName,
+			allowFlag: kind.Section,
+			create:    create,
+			unchanged: func(name string, item files.ContextItemConfig) (bool, error) {
+				p, err := apiClient.GetPrompt(
+					ctx, projectId, kind.RouteSegment, name, 0, "latest",
+				)
+				if err != nil {
+					return false, err
+				}
+				return !files.ContextItemChanged(files.ContextItemFromPrompt(p, kind), item), nil
+			},
+			// CreatePrompt on an existing name adds a new version.
+			update: create,
+			// Only reached when rolling back an item this sync created:
+			// existingByName holds declared items only, so no delete is
+			// ever planned.
+			delete: func(name string) error {
+				return apiClient.DeletePromptByName(ctx, projectId, kind.RouteSegment, name)
+			},
+			// Items are versioned, so restoring adds a version with the
+			// previous content rather than removing the new one.
+			snapshot: func(
+				name string,
+				_ struct{},
+				_ files.ContextItemConfig,
+			) (func() error, string, error) {
+				p, err := apiClient.GetPrompt(ctx, projectId, kind.RouteSegment, name, 0, "latest")
+				if err != nil {
+					return nil, "", err
+				}
+				prev := files.ContextItemFromPrompt(p, kind)
+				restore := func() error { return create(name, prev) }
+				return restore, fmt.Sprintf("restored version %d as a new version", p.Version), nil
+			},
+		},
+	)
 }
@@ -3,7 +3,9 @@ package sync
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -11,6 +13,9 @@ import (
 	"time"
 
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/platform"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestAllowDeleteResource(t *testing.T) {
@@ -104,6 +109,11 @@ func TestMcpsRejectsCredentialedUpdateWithoutCredential(t *testing.T) {
 						w,
 						`{"mcps":[{"name":"tools","projectId":"p1","revision":1,"type":"external","auth":{"type":"bearer"}}]}`,
 					)
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/organizations/o1/projects/p1/mcps/tools":
+					fmt.Fprint(
+						w,
+						`{"name":"tools","revision":1,"type":"external","endpointUrl":"https://mcp.example.com/mcp","auth":{"type":"bearer"}}`,
+					)
 				case r.Method == http.MethodPut:
 					t.Errorf("credentialed mcp update reached PUT without auth.credential")
 					fmt.Fprint(w, `{}`)
@@ -184,6 +194,23 @@ func TestPrintResult(t *testing.T) {
 				"\nProtected databases (not deleted): old-db\n" +
 				"Use --allow-delete=databases to delete them.\n",
 		},
+		{
+			name:  "unchanged items listed alongside changes",
+			label: "services",
+			result: &Result{
+				Updated:   []string{"svc-a"},
+				Unchanged: []string{"svc-b", "svc-c"},
+			},
+			want: "Updated services: svc-a\n" +
+				"Unchanged services: svc-b, svc-c\n",
+		},
+		{
+			name:   "only unchanged items",
+			label:  "agents",
+			result: &Result{Unchanged: []string{"support"}},
+			want: "Unchanged agents: support\n" +
+				"No changes required; agents already match config.\n",
+		},
 		{
 			name:    "error with partial result",
 			label:   "services",
@@ -245,6 +272,16 @@ func TestPrintPlan(t *testing.T) {
 			result: &Result{},
 			want:   "No changes required; agents already match config.\n",
 		},
+		{
+			name:  "unchanged resources are reported",
+			label: "services",
+			result: &Result{
+				Updated:   []string{"svc-a"},
+				Unchanged: []string{"svc-b"},
+			},
+			want: "Would update services: svc-a\n" +
+				"Unchanged services: svc-b\n",
+		},
 		{
 			name:  "only refused deletions",
 			label: "databases",
@@ -253,6 +290,22 @@ func TestPrintPlan(t *testing.T) {
 			},
 			want: "Would refuse to delete databases: old-db (a config that omits a resource looks identical to a stale one — pass --allow-delete=databases to delete)\n",
 		},
+		{
+			name:  "untargeted resources are skipped",
+			label: "services",
+			result: &Result{
+				Updated: []string{"api"},
+				Skipped: []string{"web", "worker"},
+			},
+			want: "Would update services: api\n" +
+				"Skipped services (not targeted): web, worker\n",
+		},
+		{
+			name:   "only skipped resources",
+			label:  "agents",
+			result: &Result{Skipped: []string{"support"}},
+			want:   "Skipped agents (not targeted): support\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -285,6 +338,8 @@ func TestServicesPrintsUpdateBanner(t *testing.T) {
 				w,
 				`{"services":[{"name":"svc-a","projectId":"p1","revision":3,"status":"ready","updated":"2026-07-24T11:20:00Z"}]}`,
 			)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/organizations/o1/projects/p1/services/svc-a":
+			fmt.Fprint(w, `{"name":"svc-a","servicePort":8080}`)
 		case r.Method == http.MethodPut && r.URL.Path == "/v1/organizations/o1/projects/p1/services/svc-a":
 			fmt.Fprint(w, `{}`)
 		case r.Method == http.MethodPost && r.URL.Path == "/v1/organizations/o1/projects/p1/services/svc-new":
@@ -388,6 +443,8 @@ func TestSyncRefusesDeletionsByDefault(t *testing.T) {
 				switch {
 				case r.Method == http.MethodGet && r.URL.Path == tt.listPath:
 					fmt.Fprint(w, tt.listBody)
+				case tt.putPath != "" && r.Method == http.MethodGet && r.URL.Path == tt.putPath:
+					fmt.Fprint(w, `{"servicePort":8080}`)
 				case tt.putPath != "" && r.Method == http.MethodPut && r.URL.Path == tt.putPath:
 					fmt.Fprint(w, `{}`)
 				case r.Method == http.MethodDelete && r.URL.Path == tt.deletePath:
@@ -493,6 +550,8 @@ func TestSyncDeletesWithAllowDelete(t *testing.T) {
 				switch {
 				case r.Method == http.MethodGet && r.URL.Path == tt.listPath:
 					fmt.Fprint(w, tt.listBody)
+				case tt.putPath != "" && r.Method == http.MethodGet && r.URL.Path == tt.putPath:
+					fmt.Fprint(w, `{"servicePort":8080}`)
 				case tt.putPath != "" && r.Method == http.MethodPut && r.URL.Path == tt.putPath:
 					fmt.Fprint(w, `{}`)
 				case r.Method == http.MethodDelete && r.URL.Path == tt.deletePath:
@@ -534,6 +593,8 @@ func TestServicesDryRunPlansWithoutWriting(t *testing.T) {
 				w,
 				`{"services":[{"name":"svc-a","projectId":"p1","revision":3,"status":"ready","updated":"2026-07-24T11:20:00Z"},{"name":"svc-old","projectId":"p1","revision":9,"status":"ready"}]}`,
 			)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/organizations/o1/projects/p1/services/svc-a":
+			fmt.Fprint(w, `{"name":"svc-a","servicePort":8080}`)
 		default:
 			t.Errorf("dry run made a write: %s %s", r.Method, r.URL.Path)
 			w.WriteHeader(http.StatusNotFound)
@@ -578,6 +639,8 @@ func TestAgentsPrintsUpdateBanner(t *testing.T) {
 				w,
 				`{"agents":[{"name":"agent-a","projectId":"p1","revision":13,"status":"ready","updated":"2026-07-24T11:20:00Z"}]}`,
 			)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/organizations/o1/projects/p1/agents/agent-a":
+			fmt.Fprint(w, `{"name":"agent-a","id":"support","version":"3"}`)
 		case r.Method == http.MethodPut && r.URL.Path == "/v1/organizations/o1/projects/p1/agents/agent-a":
 			fmt.Fprint(w, `{}`)
 		default:
@@ -610,3 +673,257 @@ func TestAgentsPrintsUpdateBanner(t *testing.T) {
 		t.Errorf("Updated = %v, want [agent-a]", result.Updated)
 	}
 }
+
+func TestSyncSkipsUnchangedResources(t *testing.T) {
+	tests := []struct {
+		name         string
+		listPath     string
+		listBody     string
+		describePath string
+		describeBody string
+		run          func(context.Context, *bytes.Buffer, *deployment.DeploymentClient) (*Result, error)
+	}{
+		{
+			name:         "services",
+			listPath:     "/v1/organizations/o1/projects/p1/services",
+			listBody:     `{"services":[{"name":"svc-a","projectId":"p1","revision":3,"status":"ready"}]}`,
+			describePath: "/v1/organizations/o1/projects/p1/services/svc-a",
+			describeBody: `{"name":"svc-a","revision":3,"servicePort":8080,"image":{"type":"external","repository":"nginx","name":"nginx","tag":"alpine"},"resources":{"memory":"128M","cpu":"1"},"replicas":2,"stackId":"stack-1"}`,
+			run: func(ctx context.Context, warn *bytes.Buffer, client *deployment.DeploymentClient) (*Result, error) {
+				return Services(ctx, warn, client, "o1", "p1", "stack-1",
+					map[string]deployment.CreateServiceBody{"svc-a": {
+						ServicePort: 8080,
+						Image: deployment.ImageSpec{
+							Type:       "external",
+							Repository: "nginx",
+							Name:       "nginx",
+							Tag:        "alpine",
+						},
+						Resources: deployment.Resources{Memory: "128M", CPU: "1"},
+						Replicas:  2,
+						StackId:   "stack-1",
+					}}, Options{})
+			},
+		},
+		{
+			name:         "agents",
+			listPath:     "/v1/organizations/o1/projects/p1/agents",
+			listBody:     `{"agents":[{"name":"agent-a","projectId":"p1","revision":13,"status":"ready"}]}`,
+			describePath: "/v1/organizations/o1/projects/p1/agents/agent-a",
+			describeBody: `{"name":"agent-a","revision":13,"id":"support","version":"3","agentConfig":{"routines":["greet"],"maxTurns":5}}`,
+			run: func(ctx context.Context, warn *bytes.Buffer, client *deployment.DeploymentClient) (*Result, error) {
+				return Agents(ctx, warn, client, "o1", "p1", "stack-1",
+					map[string]deployment.CreateAgentBody{"agent-a": {
+						Id:      "support",
+						Version: "3",
+						AgentConfig: map[string]any{
+							"routines": []any{"greet"},
+							"maxTurns": 5,
+						},
+						StackId: "stack-1",
+					}}, Options{})
+			},
+		},
+		{
+			name:         "mcps",
+			listPath:     "/v1/organizations/o1/projects/p1/mcps",
+			listBody:     `{"mcps":[{"name":"tools","projectId":"p1","revision":1,"type":"external","auth":{"type":"none"}}]}`,
+			describePath: "/v1/organizations/o1/projects/p1/mcps/tools",
+			describeBody: `{"name":"tools","revision":1,"type":"external","endpointUrl":"https://mcp.example.com/mcp","auth":{"type":"none"}}`,
+			run: func(ctx context.Context, warn *bytes.Buffer, client *deployment.DeploymentClient) (*Result, error) {
+				return Mcps(ctx, warn, client, "o1", "p1", "stack-1",
+					map[string]deployment.CreateMcpBody{"tools": {
+						Type:        "external",
+						EndpointURL: "https://mcp.example.com/mcp",
+						Auth:        deployment.McpAuthBody{Type: "none"},
+						StackId:     "stack-1",
+					}}, Options{})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == tt.listPath:
+					fmt.Fprint(w, tt.listBody)
+				case r.Method == http.MethodGet && r.URL.Path == tt.describePath:
+					fmt.Fprint(w, tt.describeBody)
+				default:
+					t.Errorf("unchanged resource was written: %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+
+			var warn bytes.Buffer
+			result, err := tt.run(context.Background(), &warn, client)
+			if err != nil {
+				t.Fatalf("sync error = %v", err)
+			}
+			if got := warn.String(); got != "" {
+				t.Errorf("warnings = %q, want none (no revision is created)", got)
+			}
+			if len(result.Updated) != 0 {
+				t.Errorf("Updated = %v, want []", result.Updated)
+			}
+			if len(result.Unchanged) != 1 {
+				t.Errorf("Unchanged = %v, want one resource", result.Unchanged)
+			}
+		})
+	}
+}
+
+func TestMcpsAlwaysSendsCredentials(t *testing.T) {
+	const path = "/v1/organizations/o1/projects/p1/mcps/tools"
+	var sent string
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/organizations/o1/projects/p1/mcps":
+			fmt.Fprint(w, `{"mcps":[{"name":"tools","projectId":"p1","revision":1,"type":"external","auth":{"type":"bearer"}}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == path:
+			fmt.Fprint(w, `{"name":"tools","revision":1,"type":"external","endpointUrl":"https://mcp.example.com/mcp","auth":{"type":"bearer"}}`)
+		case r.Method == http.MethodPut && r.URL.Path == path:
+			var body deployment.CreateMcpBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decode body: %v", err)
+			}
+			sent = body.Auth.Credential
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	// The spec matches the live one; only the credential may have changed.
+	result, err := Mcps(context.Background(), io.Discard, client, "o1", "p1", "stack-1",
+		map[string]deployment.CreateMcpBody{"tools": {
+			Type:        "external",
+			EndpointURL: "https://mcp.example.com/mcp",
+			Auth:        deployment.McpAuthBody{Type: "bearer", Credential: "rotated"},
+			StackId:     "stack-1",
+		}}, Options{})
+	if err != nil {
+		t.Fatalf("Mcps() error = %v", err)
+	}
+	if len(result.Updated) != 1 || len(result.Unchanged) != 0 {
+		t.Errorf("Updated = %v, Unchanged = %v, want the mcp updated", result.Updated, result.Unchanged)
+	}
+	if sent != "rotated" {
+		t.Errorf("sent credential = %q, want %q", sent, "rotated")
+	}
+}
+
+func TestContextItemsVersionsOnlyChangedItems(t *testing.T) {
+	const base = "/api/platform/v1/projects/p1/prompts/routines"
+	var created []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == base:
+			fmt.Fprint(w, `{"success":true,"data":{"prompts":[`+
+				`{"name":"greet","row_type":"prompt"},`+
+				`{"name":"escalate","row_type":"prompt"},`+
+				`{"name":"legacy","row_type":"prompt"},`+
+				`{"name":"onboarding","row_type":"folder"}]}}`)
+		case r.Method == http.MethodGet && r.URL.Path == base+"/greet":
+			if got := r.URL.Query().Get("label"); got != "latest" {
+				t.Errorf("label = %q, want latest", got)
+			}
+			fmt.Fprint(w, `{"success":true,"data":{"name":"greet","version":4,"prompt":"Say hello.","labels":["latest","production"]}}`)
+		case r.Method == http.MethodGet && r.URL.Path == base+"/escalate":
+			fmt.Fprint(w, `{"success":true,"data":{"name":"escalate","version":2,"prompt":"Hand off politely.","labels":["latest"]}}`)
+		case r.Method == http.MethodPost && r.URL.Path == base:
+			var body platform.CreatePromptBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decode body: %v", err)
+			}
+			created = append(created, body.Name+":"+strings.Join(body.Labels, ","))
+			fmt.Fprint(w, `{"success":true,"data":{}}`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	apiClient, err := platform.NewAPIClient(server.URL, 5*time.Second, "test-token", "", nil)
+	if err != nil {
+		t.Fatalf("NewAPIClient() error = %v", err)
+	}
+
+	kind := files.ContextKinds[0]
+	var warn bytes.Buffer
+	result, err := ContextItems(context.Background(), &warn, apiClient, "p1", kind,
+		map[string]files.ContextItemConfig{
+			"greet":    {Content: "Say hello.\n", Labels: []string{"production"}},
+			"escalate": {Content: "Hand off to a human.", Labels: []string{"production"}},
+			"farewell": {Content: "Say goodbye."},
+		}, Options{AllowDelete: true})
+	if err != nil {
+		t.Fatalf("ContextItems() error = %v", err)
+	}
+
+	want := &Result{
+		Created:   []string{"farewell"},
+		Updated:   []string{"escalate"},
+		Unchanged: []string{"greet"},
+	}
+	if diff := cmp.Diff(want, result); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"escalate:production", "farewell:"}, created); diff != "" {
+		t.Errorf("created versions mismatch (-want +got):\n%s", diff)
+	}
+	if got := warn.String(); got != "" {
+		t.Errorf("warnings = %q, want none (undeclared items are never deleted)", got)
+	}
+}
+
+func TestSecretsCreatesAndReplacesChangedSecrets(t *testing.T) {
+	const base = "/v1/organizations/o1/projects/p1/secrets"
+	var writes []string
+
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == base:
+			fmt.Fprint(w, `{"secrets":[{"name":"same"},{"name":"rotated"},{"name":"unmanaged"}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == base+"/same":
+			fmt.Fprint(w, `{"name":"same","data":{"A":"1"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == base+"/rotated":
+			fmt.Fprint(w, `{"name":"rotated","data":{"A":"old"}}`)
+		case r.Method == http.MethodPost || r.Method == http.MethodPut:
+			writes = append(writes, r.Method+" "+strings.TrimPrefix(r.URL.Path, base+"/"))
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	var warn bytes.Buffer
+	result, err := Secrets(context.Background(), &warn, client, "o1", "p1",
+		map[string]map[string]string{
+			"same":    {"A": "1"},
+			"rotated": {"A": "new"},
+			"fresh":   {"B": "2"},
+		}, Options{AllowDelete: true})
+	if err != nil {
+		t.Fatalf("Secrets() error = %v", err)
+	}
+
+	want := &Result{
+		Created:   []string{"fresh"},
+		Updated:   []string{"rotated"},
+		Unchanged: []string{"same"},
+	}
+	if diff := cmp.Diff(want, result); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"POST fresh", "PUT rotated"}, writes); diff != "" {
+		t.Errorf("writes mismatch (-want +got):\n%s", diff)
+	}
+	if got := warn.String(); got != "" {
+		t.Errorf("warnings = %q, want none (undeclared secrets are never deleted)", got)
+	}
+}
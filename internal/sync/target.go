@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	gosync "sync"
+)
+
+// Targets selects the resources a sync acts on with "type/name" patterns,
+// e.g. service/api or agent/support-*, matched with path.Match. A nil
+// *Targets selects every resource.
+type Targets struct {
+	patterns []string
+
+	mu      gosync.Mutex
+	matched map[string]bool
+}
+
+// NewTargets checks each pattern and returns the targets they select, or nil
+// when there are none.
+func NewTargets(patterns []string) (*Targets, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	for _, p := range patterns {
+		kind, name, ok := strings.Cut(p, "/")
+		if !ok || kind == "" || name == "" {
+			return nil, fmt.Errorf("invalid target %q; use type/name, e.g. service/api", p)
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid target %q: %w", p, err)
+		}
+	}
+	return &Targets{patterns: patterns, matched: make(map[string]bool)}, nil
+}
+
+// Patterns returns the patterns t was made from.
+func (t *Targets) Patterns() []string {
+	if t == nil {
+		return nil
+	}
+	return t.patterns
+}
+
+// Match reports whether the resource, e.g. "service", named name is
+// targeted.
+func (t *Targets) Match(resource, name string) bool {
+	if t == nil {
+		return true
+	}
+	id := resource + "/" + name
+	found := false
+	for _, p := range t.patterns {
+		if ok, _ := path.Match(p, id); ok {
+			t.mu.Lock()
+			t.matched[p] = true
+			t.mu.Unlock()
+			found = true
+		}
+	}
+	return found
+}
+
+// Unmatched returns the patterns that matched no resource so far, usually
+// a typo.
+func (t *Targets) Unmatched() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var unmatched []string
+	for _, p := range t.patterns {
+		if !t.matched[p] {
+			unmatched = append(unmatched, p)
+		}
+	}
+	return unmatched
+}
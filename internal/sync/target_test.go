@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewTargets(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		wantErr  bool
+	}{
+		{patterns: []string{"service/api", "agent/support-*"}},
+		{patterns: []string{"api"}, wantErr: true},
+		{patterns: []string{"service/"}, wantErr: true},
+		{patterns: []string{"service/[api"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.patterns), func(t *testing.T) {
+			_, err := NewTargets(tt.patterns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if targets, err := NewTargets(nil); targets != nil || err != nil {
+		t.Errorf("NewTargets(nil) = %v, %v, want nil, nil", targets, err)
+	}
+}
+
+func TestTargetsMatch(t *testing.T) {
+	targets, err := NewTargets([]string{"service/api", "agent/support-*", "mcp/typo"})
+	if err != nil {
+		t.Fatalf("NewTargets() error = %v", err)
+	}
+
+	tests := []struct {
+		resource, name string
+		want           bool
+	}{
+		{"service", "api", true},
+		{"service", "api-2", false},
+		{"agent", "support-eu", true},
+		{"agent", "sales", false},
+		{"database", "api", false},
+	}
+	for _, tt := range tests {
+		if got := targets.Match(tt.resource, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.resource, tt.name, got, tt.want)
+		}
+	}
+	if diff := cmp.Diff([]string{"mcp/typo"}, targets.Unmatched()); diff != "" {
+		t.Errorf("Unmatched() mismatch (-want +got):\n%s", diff)
+	}
+
+	var none *Targets
+	if !none.Match("service", "anything") {
+		t.Errorf("nil Targets should match every resource")
+	}
+}
+
+func TestServicesSyncsOnlyTargets(t *testing.T) {
+	var writes []string
+	client := newTestDeployClient(t, func(w http.ResponseWriter, r *http.Request) {
+		const base = "/v1/organizations/o1/projects/p1/services"
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == base:
+			fmt.Fprint(w, `{"services":[{"name":"api","revision":3},{"name":"old","revision":1}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == base+"/api":
+			fmt.Fprint(w, `{"servicePort":8080}`)
+		case r.Method == http.MethodGet:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			writes = append(writes, r.Method+" "+r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		}
+	})
+
+	targets, err := NewTargets([]string{"service/api"})
+	if err != nil {
+		t.Fatalf("NewTargets() error = %v", err)
+	}
+	var warn bytes.Buffer
+	result, err := Services(
+		context.Background(),
+		&warn,
+		client,
+		"o1",
+		"p1",
+		"stack-1",
+		map[string]deployment.CreateServiceBody{
+			"api": {ServicePort: 9090},
+			"web": {ServicePort: 8080},
+		},
+		Options{AllowDelete: true, Targets: targets},
+	)
+	if err != nil {
+		t.Fatalf("Services() error = %v", err)
+	}
+
+	want := &Result{Updated: []string{"api"}, Skipped: []string{"old", "web"}}
+	if diff := cmp.Diff(want, result); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+	wantWrites := []string{"PUT /v1/organizations/o1/projects/p1/services/api"}
+	if diff := cmp.Diff(wantWrites, writes); diff != "" {
+		t.Errorf("writes mismatch (-want +got):\n%s", diff)
+	}
+	if bytes.Contains(warn.Bytes(), []byte("DELETE")) {
+		t.Errorf("untargeted service announced for deletion: %q", warn.String())
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients"
 	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
 )
 
@@ -31,7 +32,7 @@ func FetchLatestVersion(timeout time.Duration) (string, error) {
 		timeout = fetchTimeout
 	}
 
-	client := &http.Client{Timeout: timeout}
+	client := &http.Client{Transport: clients.DefaultTransport, Timeout: timeout}
 	resp, err := client.Get(goProxyURL)
 	if err != nil {
 		if os.IsTimeout(err) {
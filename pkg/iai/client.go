@@ -0,0 +1,160 @@
+package iai
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/clients/platform"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/files"
+	"github.com/Interactive-AI-Labs/interactive-cli/internal/session"
+)
+
+const (
+	DefaultHostname           = "https://app.interactive.ai"
+	DefaultDeploymentHostname = "https://deployment.interactive.ai"
+	// DefaultTimeout limits each attempt of a request.
+	DefaultTimeout = 30 * time.Second
+
+	// ConfigDir is the directory, under the user's home, where the CLI keeps
+	// its session and selections.
+	ConfigDir = ".interactiveai"
+	// SessionFile is the file in ConfigDir 'iai login' saves the session to.
+	SessionFile = "session_cookies.json"
+)
+
+type (
+	// APIError is a response with a non-2xx status.
+	APIError = clients.APIError
+	// ErrorDetail is one of an APIError's validation errors.
+	ErrorDetail = clients.ErrorDetail
+)
+
+// ErrNoCredentials is returned by New when there is nothing to authenticate
+// with.
+var ErrNoCredentials = clients.ErrNoCredentials
+
+// Credentials are what a Client authenticates with. Token is used first,
+// then APIKey, then Cookies.
+type Credentials struct {
+	Token   string
+	APIKey  string
+	Cookies []*http.Cookie
+}
+
+// CredentialsFromEnv returns the credentials the CLI uses: INTERACTIVE_TOKEN,
+// INTERACTIVE_API_KEY, and the session 'iai login' saved.
+func CredentialsFromEnv() (Credentials, error) {
+	cookies, err := files.LoadSessionCookies(ConfigDir, SessionFile)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to load session: %w", err)
+	}
+	return Credentials{
+		Token:   os.Getenv("INTERACTIVE_TOKEN"),
+		APIKey:  os.Getenv("INTERACTIVE_API_KEY"),
+		Cookies: cookies,
+	}, nil
+}
+
+// Config configures a Client. Zero fields take the CLI's defaults.
+type Config struct {
+	// Hostname is the platform API's; it defaults to INTERACTIVE_HOSTNAME,
+	// then DefaultHostname.
+	Hostname string
+	// DeploymentHostname is the deployment API's; it defaults to
+	// INTERACTIVE_DEPLOYMENT_HOSTNAME, then DefaultDeploymentHostname.
+	DeploymentHostname string
+	// Credentials default to CredentialsFromEnv.
+	Credentials *Credentials
+	// Timeout limits each attempt of a request; it defaults to
+	// DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Client calls both APIs.
+type Client struct {
+	Platform   *APIClient
+	Deployment *DeploymentClient
+}
+
+// New returns a Client for cfg. ctx is only used to validate an API key,
+// which takes a request; the Client's methods take their own.
+func New(ctx context.Context, cfg Config) (*Client, error) {
+	creds := cfg.Credentials
+	if creds == nil {
+		fromEnv, err := CredentialsFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		creds = &fromEnv
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	hostname := withScheme(cmp.Or(
+		cfg.Hostname, os.Getenv("INTERACTIVE_HOSTNAME"), DefaultHostname,
+	))
+	deploymentHostname := withScheme(cmp.Or(
+		cfg.DeploymentHostname,
+		os.Getenv("INTERACTIVE_DEPLOYMENT_HOSTNAME"),
+		DefaultDeploymentHostname,
+	))
+
+	apiClient, err := platform.NewAPIClientWithContext(
+		ctx, hostname, timeout, creds.Token, creds.APIKey, creds.Cookies,
+	)
+	if err != nil {
+		return nil, err
+	}
+	deployClient, err := deployment.NewDeploymentClient(
+		deploymentHostname, timeout, creds.Token, creds.APIKey, creds.Cookies,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Platform: apiClient, Deployment: deployClient}, nil
+}
+
+// ProjectRef identifies a project by the IDs the clients' methods take.
+type ProjectRef struct {
+	OrgID   string
+	OrgName string
+	ID      string
+	Name    string
+}
+
+// ResolveProject looks up the IDs of project in org, both by name. Empty
+// names fall back to the organization and project selected with 'iai
+// organizations select' and 'iai projects select'; API keys are scoped to
+// one project, which they resolve to.
+func (c *Client) ResolveProject(ctx context.Context, org, project string) (ProjectRef, error) {
+	sess := session.NewSession(ConfigDir)
+	orgName, err := sess.ResolveOrganization("", org)
+	if err != nil {
+		return ProjectRef{}, err
+	}
+	projectName, err := sess.ResolveProject("", project)
+	if err != nil {
+		return ProjectRef{}, err
+	}
+	orgID, projectID, err := c.Platform.GetProjectId(ctx, orgName, projectName)
+	if err != nil {
+		return ProjectRef{}, fmt.Errorf("failed to resolve project %q: %w", projectName, err)
+	}
+	return ProjectRef{OrgID: orgID, OrgName: orgName, ID: projectID, Name: projectName}, nil
+}
+
+// withScheme adds https:// to hostnames without a scheme, as the CLI does.
+func withScheme(hostname string) string {
+	if strings.HasPrefix(hostname, "http://") || strings.HasPrefix(hostname, "https://") {
+		return hostname
+	}
+	return "https://" + hostname
+}
@@ -0,0 +1,93 @@
+package iai
+
+import "github.com/Interactive-AI-Labs/interactive-cli/internal/clients/deployment"
+
+// Types of the deployment API: the requests DeploymentClient sends and the
+// resources it returns.
+type (
+	// Collections.
+	CollectionSummary          = deployment.CollectionSummary
+	CollectionSlot             = deployment.CollectionSlot
+	CollectionIndex            = deployment.CollectionIndex
+	CollectionFullText         = deployment.CollectionFullText
+	CollectionConfig           = deployment.CollectionConfig
+	DescribeCollectionResponse = deployment.DescribeCollectionResponse
+	CollectionStats            = deployment.CollectionStats
+
+	// Collection chunks.
+	Chunk             = deployment.Chunk
+	ChunkList         = deployment.ChunkList
+	ChunkResult       = deployment.ChunkResult
+	ChunkUpsertResult = deployment.ChunkUpsertResult
+	BulkDeleteResult  = deployment.BulkDeleteResult
+	ListChunksOpts    = deployment.ListChunksOpts
+
+	// Collection documents.
+	DocumentSummary      = deployment.DocumentSummary
+	DocumentList         = deployment.DocumentList
+	DocumentChunks       = deployment.DocumentChunks
+	DeleteDocumentResult = deployment.DeleteDocumentResult
+
+	// Collection search.
+	SearchHit           = deployment.SearchHit
+	SearchResponse      = deployment.SearchResponse
+	BatchSearchResponse = deployment.BatchSearchResponse
+
+	// Collection slots.
+	SlotAddResult     = deployment.SlotAddResult
+	SlotIndexProgress = deployment.SlotIndexProgress
+	SlotOpResult      = deployment.SlotOpResult
+
+	// Services, agents, secrets, images, replicas, databases, and their logs.
+	DeploymentClient         = deployment.DeploymentClient
+	SecretInfo               = deployment.SecretInfo
+	ImageInfo                = deployment.ImageInfo
+	ReplicaInfo              = deployment.ReplicaInfo
+	ReplicaStatus            = deployment.ReplicaStatus
+	ReplicaLastTermination   = deployment.ReplicaLastTermination
+	ReplicaResources         = deployment.ReplicaResources
+	ReplicaHealthcheck       = deployment.ReplicaHealthcheck
+	ReplicaEvent             = deployment.ReplicaEvent
+	CreateServiceBody        = deployment.CreateServiceBody
+	Resources                = deployment.Resources
+	Autoscaling              = deployment.Autoscaling
+	Healthcheck              = deployment.Healthcheck
+	Schedule                 = deployment.Schedule
+	ImageSpec                = deployment.ImageSpec
+	EnvVar                   = deployment.EnvVar
+	SecretRef                = deployment.SecretRef
+	ServiceOutput            = deployment.ServiceOutput
+	DescribeServiceResponse  = deployment.DescribeServiceResponse
+	UpdatePatch              = deployment.UpdatePatch
+	LogsOptions              = deployment.LogsOptions
+	LogsResponse             = deployment.LogsResponse
+	RevisionActor            = deployment.RevisionActor
+	RevisionSource           = deployment.RevisionSource
+	RevisionMeta             = deployment.RevisionMeta
+	AgentRevisionResponse    = deployment.AgentRevisionResponse
+	ServiceRevisionResponse  = deployment.ServiceRevisionResponse
+	CreateAgentBody          = deployment.CreateAgentBody
+	AgentOutput              = deployment.AgentOutput
+	DescribeAgentResponse    = deployment.DescribeAgentResponse
+	CatalogAgent             = deployment.CatalogAgent
+	DatabaseOutput           = deployment.DatabaseOutput
+	DatabaseStorageConfig    = deployment.DatabaseStorageConfig
+	DatabaseBackupConfig     = deployment.DatabaseBackupConfig
+	DatabaseBackupStatus     = deployment.DatabaseBackupStatus
+	CreateDatabaseBody       = deployment.CreateDatabaseBody
+	RestoreDatabaseBody      = deployment.RestoreDatabaseBody
+	DescribeDatabaseResponse = deployment.DescribeDatabaseResponse
+	BackupOutput             = deployment.BackupOutput
+
+	// MCPs.
+	CreateMcpBody       = deployment.CreateMcpBody
+	McpAuthBody         = deployment.McpAuthBody
+	McpAuthInfo         = deployment.McpAuthInfo
+	McpVerifyState      = deployment.McpVerifyState
+	McpOutput           = deployment.McpOutput
+	DescribeMcpResponse = deployment.DescribeMcpResponse
+	McpToolsResponse    = deployment.McpToolsResponse
+	RunMcpToolResult    = deployment.RunMcpToolResult
+	McpToolError        = deployment.McpToolError
+	VerifyMcpResponse   = deployment.VerifyMcpResponse
+)
@@ -0,0 +1,22 @@
+// Package iai is a Go SDK for the InteractiveAI platform and deployment APIs,
+// the same clients the iai CLI uses.
+//
+// New authenticates like the CLI: with INTERACTIVE_TOKEN, else
+// INTERACTIVE_API_KEY, else the session 'iai login' saved, unless Config
+// names other Credentials. Requests go through the proxy HTTPS_PROXY and
+// NO_PROXY name, and idempotent ones are retried after transient failures.
+//
+//	client, err := iai.New(ctx, iai.Config{})
+//	if err != nil {
+//		return err
+//	}
+//	project, err := client.ResolveProject(ctx, "acme", "support")
+//	if err != nil {
+//		return err
+//	}
+//	services, err := client.Deployment.ListServices(ctx, project.OrgID, project.ID, "")
+//
+// Failed requests return an *APIError carrying the response's status, the
+// server's message, and any validation details; test for it with errors.As.
+// List endpoints that page have iterators on Client, like Traces and Chunks.
+package iai
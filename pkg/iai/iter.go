@@ -0,0 +1,325 @@
+package iai
+
+import (
+	"context"
+	"iter"
+)
+
+// Iterators over the list endpoints that page. Each fetches pages as the loop
+// asks for items, starting from the page or cursor in opts, and stops at the
+// first error, which it yields with a zero item:
+//
+//	for trace, err := range client.Traces(ctx, orgID, projectID, iai.TraceListOptions{}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(trace.ID)
+//	}
+
+// Traces iterates over the traces matching opts.
+func (c *Client) Traces(
+	ctx context.Context,
+	orgID, projectID string,
+	opts TraceListOptions,
+) iter.Seq2[TraceInfo, error] {
+	return pages(ctx, opts.Page, func(page int) ([]TraceInfo, int, error) {
+		opts.Page = page
+		items, meta, _, err := c.Platform.ListTraces(ctx, orgID, projectID, opts)
+		return items, meta.TotalPages, err
+	})
+}
+
+// Observations iterates over the observations matching opts, across traces.
+func (c *Client) Observations(
+	ctx context.Context,
+	orgID, projectID string,
+	opts ObservationSearchOptions,
+) iter.Seq2[StandaloneObservationInfo, error] {
+	type page = []StandaloneObservationInfo
+	return cursors(ctx, opts.Cursor, func(cursor string) (page, string, error) {
+		opts.Cursor = cursor
+		items, meta, _, err := c.Platform.SearchObservations(ctx, orgID, projectID, opts)
+		return items, meta.NextCursor, err
+	})
+}
+
+// Sessions iterates over the sessions matching opts.
+func (c *Client) Sessions(
+	ctx context.Context,
+	orgID, projectID string,
+	opts SessionListOptions,
+) iter.Seq2[SessionInfo, error] {
+	return pages(ctx, opts.Page, func(page int) ([]SessionInfo, int, error) {
+		opts.Page = page
+		items, meta, _, err := c.Platform.ListSessions(ctx, orgID, projectID, opts)
+		return items, meta.TotalPages, err
+	})
+}
+
+// Scores iterates over the scores matching opts.
+func (c *Client) Scores(
+	ctx context.Context,
+	orgID, projectID string,
+	opts ScoreListOptions,
+) iter.Seq2[ScoreInfo, error] {
+	return cursors(ctx, opts.Cursor, func(cursor string) ([]ScoreInfo, string, error) {
+		opts.Cursor = cursor
+		items, meta, _, err := c.Platform.ListScores(ctx, orgID, projectID, opts)
+		return items, meta.NextCursor, err
+	})
+}
+
+// MetricsDaily iterates over the daily metrics matching opts.
+func (c *Client) MetricsDaily(
+	ctx context.Context,
+	orgID, projectID string,
+	opts MetricsDailyOptions,
+) iter.Seq2[DailyMetric, error] {
+	return pages(ctx, opts.Page, func(page int) ([]DailyMetric, int, error) {
+		opts.Page = page
+		items, meta, _, err := c.Platform.ListMetricsDaily(ctx, orgID, projectID, opts)
+		return items, meta.TotalPages, err
+	})
+}
+
+// Prompts iterates over the prompts of a type, like "routines"; an empty
+// routeSegment lists every type.
+func (c *Client) Prompts(
+	ctx context.Context,
+	projectID, routeSegment string,
+	opts PromptListOptions,
+) iter.Seq2[PromptInfo, error] {
+	first := opts.Page
+	return func(yield func(PromptInfo, error) bool) {
+		// seen counts the prompts of this range over the iterator.
+		seen := 0
+		prompts := pages(ctx, first, func(page int) ([]PromptInfo, int, error) {
+			opts.Page = page
+			list, err := c.Platform.ListPrompts(ctx, projectID, routeSegment, opts)
+			if err != nil {
+				return nil, 0, err
+			}
+			// The prompts API reports a total count rather than pages.
+			seen += len(list.Prompts)
+			totalPages := page
+			if seen < list.TotalCount {
+				totalPages++
+			}
+			return list.Prompts, totalPages, nil
+		})
+		prompts(yield)
+	}
+}
+
+// Datasets iterates over the datasets matching opts.
+func (c *Client) Datasets(
+	ctx context.Context,
+	orgID, projectID string,
+	opts DatasetListOptions,
+) iter.Seq2[DatasetInfo, error] {
+	return pages(ctx, opts.Page, func(page int) ([]DatasetInfo, int, error) {
+		opts.Page = page
+		items, meta, _, err := c.Platform.ListDatasets(ctx, orgID, projectID, opts)
+		return items, meta.TotalPages, err
+	})
+}
+
+// DatasetItems iterates over the dataset items matching opts.
+func (c *Client) DatasetItems(
+	ctx context.Context,
+	orgID, projectID string,
+	opts DatasetItemListOptions,
+) iter.Seq2[DatasetItemInfo, error] {
+	return pages(ctx, opts.Page, func(page int) ([]DatasetItemInfo, int, error) {
+		opts.Page = page
+		items, meta, _, err := c.Platform.ListDatasetItems(ctx, orgID, projectID, opts)
+		return items, meta.TotalPages, err
+	})
+}
+
+// DatasetRuns iterates over the runs of a dataset.
+func (c *Client) DatasetRuns(
+	ctx context.Context,
+	orgID, projectID, datasetName string,
+	opts DatasetRunListOptions,
+) iter.Seq2[DatasetRunInfo, error] {
+	return pages(ctx, opts.Page, func(page int) ([]DatasetRunInfo, int, error) {
+		opts.Page = page
+		items, meta, _, err := c.Platform.ListDatasetRuns(
+			ctx, orgID, projectID, datasetName, opts,
+		)
+		return items, meta.TotalPages, err
+	})
+}
+
+// DatasetRunItems iterates over the dataset run items matching opts.
+func (c *Client) DatasetRunItems(
+	ctx context.Context,
+	orgID, projectID string,
+	opts DatasetRunItemListOptions,
+) iter.Seq2[DatasetRunItemInfo, error] {
+	return pages(ctx, opts.Page, func(page int) ([]DatasetRunItemInfo, int, error) {
+		opts.Page = page
+		items, meta, _, err := c.Platform.ListDatasetRunItems(ctx, orgID, projectID, opts)
+		return items, meta.TotalPages, err
+	})
+}
+
+// AnnotationQueues iterates over the annotation queues matching opts.
+func (c *Client) AnnotationQueues(
+	ctx context.Context,
+	orgID, projectID string,
+	opts AnnotationQueueListOptions,
+) iter.Seq2[AnnotationQueueInfo, error] {
+	return pages(ctx, opts.Page, func(page int) ([]AnnotationQueueInfo, int, error) {
+		opts.Page = page
+		items, meta, _, err := c.Platform.ListAnnotationQueues(ctx, orgID, projectID, opts)
+		return items, meta.TotalPages, err
+	})
+}
+
+// QueueItems iterates over the items of an annotation queue.
+func (c *Client) QueueItems(
+	ctx context.Context,
+	orgID, projectID, queueID string,
+	opts QueueItemListOptions,
+) iter.Seq2[QueueItemInfo, error] {
+	return pages(ctx, opts.Page, func(page int) ([]QueueItemInfo, int, error) {
+		opts.Page = page
+		items, meta, _, err := c.Platform.ListQueueItems(ctx, orgID, projectID, queueID, opts)
+		return items, meta.TotalPages, err
+	})
+}
+
+// Comments iterates over the comments matching opts.
+func (c *Client) Comments(
+	ctx context.Context,
+	orgID, projectID string,
+	opts CommentListOptions,
+) iter.Seq2[CommentInfo, error] {
+	return pages(ctx, opts.Page, func(page int) ([]CommentInfo, int, error) {
+		opts.Page = page
+		items, meta, _, err := c.Platform.ListComments(ctx, orgID, projectID, opts)
+		return items, meta.TotalPages, err
+	})
+}
+
+// ScoreConfigs iterates over the score configs matching opts.
+func (c *Client) ScoreConfigs(
+	ctx context.Context,
+	orgID, projectID string,
+	opts ScoreConfigListOptions,
+) iter.Seq2[ScoreConfigInfo, error] {
+	return pages(ctx, opts.Page, func(page int) ([]ScoreConfigInfo, int, error) {
+		opts.Page = page
+		items, meta, _, err := c.Platform.ListScoreConfigs(ctx, orgID, projectID, opts)
+		return items, meta.TotalPages, err
+	})
+}
+
+// Chunks iterates over the chunks of a collection matching opts.
+func (c *Client) Chunks(
+	ctx context.Context,
+	orgID, projectID, database, collection string,
+	opts ListChunksOpts,
+) iter.Seq2[Chunk, error] {
+	return cursors(ctx, opts.Cursor, func(cursor string) ([]Chunk, string, error) {
+		opts.Cursor = cursor
+		list, err := c.Deployment.ListChunks(ctx, orgID, projectID, database, collection, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Chunks, nextCursor(list.HasMore, list.NextCursor), nil
+	})
+}
+
+// Documents iterates over the documents of a collection matching filter,
+// limit at a time.
+func (c *Client) Documents(
+	ctx context.Context,
+	orgID, projectID, database, collection string,
+	limit int,
+	filter string,
+) iter.Seq2[DocumentSummary, error] {
+	return cursors(ctx, "", func(cursor string) ([]DocumentSummary, string, error) {
+		list, err := c.Deployment.ListDocuments(
+			ctx, orgID, projectID, database, collection, limit, cursor, filter,
+		)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Documents, nextCursor(list.HasMore, list.NextCursor), nil
+	})
+}
+
+// pages yields the items of the pages list returns, from page first (or 1)
+// until the last of the total it reports, or an empty page.
+func pages[T any](
+	ctx context.Context,
+	first int,
+	list func(page int) (items []T, totalPages int, err error),
+) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		for page := max(first, 1); ; page++ {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			items, totalPages, err := list(page)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(items) == 0 || totalPages <= page {
+				return
+			}
+		}
+	}
+}
+
+// cursors yields the items of the pages list returns, from cursor first
+// until one has no next cursor.
+func cursors[T any](
+	ctx context.Context,
+	first string,
+	list func(cursor string) (items []T, next string, err error),
+) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		for cursor := first; ; {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			items, next, err := list(cursor)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next == "" || next == cursor {
+				return
+			}
+			cursor = next
+		}
+	}
+}
+
+// nextCursor returns the cursor of the page after one of the collections
+// API's, or "" after the last.
+func nextCursor(hasMore bool, next *string) string {
+	if !hasMore || next == nil {
+		return ""
+	}
+	return *next
+}
@@ -0,0 +1,148 @@
+package iai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func newIterTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := New(context.Background(), Config{
+		Hostname:           srv.URL,
+		DeploymentHostname: srv.URL,
+		Credentials:        &Credentials{Token: "fake-token"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return client
+}
+
+func TestClientTraces(t *testing.T) {
+	var pagesServed []string
+	client := newIterTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		const path = "/api/platform/v1/organizations/org-1/projects/proj-1/traces"
+		if r.URL.Path != path {
+			t.Errorf("path = %s, want %s", r.URL.Path, path)
+		}
+		page := r.URL.Query().Get("page")
+		pagesServed = append(pagesServed, page)
+		_, _ = fmt.Fprintf(w, `{"success":true,"data":{"traces":[{"id":"t-%s-a"},{"id":"t-%s-b"}],`+
+			`"meta":{"page":%s,"total_pages":3}}}`, page, page, page)
+	})
+
+	var ids []string
+	for trace, err := range client.Traces(
+		context.Background(), "org-1", "proj-1", TraceListOptions{Page: 2},
+	) {
+		if err != nil {
+			t.Fatalf("Traces() error = %v", err)
+		}
+		ids = append(ids, trace.ID)
+	}
+
+	wantIDs := []string{"t-2-a", "t-2-b", "t-3-a", "t-3-b"}
+	if !reflect.DeepEqual(ids, wantIDs) {
+		t.Errorf("ids = %v, want %v", ids, wantIDs)
+	}
+	if want := []string{"2", "3"}; !reflect.DeepEqual(pagesServed, want) {
+		t.Errorf("pages served = %v, want %v", pagesServed, want)
+	}
+}
+
+func TestClientTracesStopsOnBreak(t *testing.T) {
+	requests := 0
+	client := newIterTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = io.WriteString(w, `{"success":true,"data":{"traces":[{"id":"a"},{"id":"b"}],`+
+			`"meta":{"page":1,"total_pages":5}}}`)
+	})
+
+	for range client.Traces(context.Background(), "org-1", "proj-1", TraceListOptions{}) {
+		break
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+}
+
+func TestClientChunks(t *testing.T) {
+	bodies := map[string]string{
+		"":     `{"chunks":[{"id":"c-1"},{"id":"c-2"}],"nextCursor":"next","hasMore":true}`,
+		"next": `{"chunks":[{"id":"c-3"}],"nextCursor":"ignored","hasMore":false}`,
+	}
+	client := newIterTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Query().Get("cursor")]
+		if !ok {
+			t.Errorf("unexpected cursor %q", r.URL.Query().Get("cursor"))
+		}
+		_, _ = io.WriteString(w, body)
+	})
+
+	var ids []string
+	for chunk, err := range client.Chunks(
+		context.Background(), "org-1", "proj-1", "db", "docs", ListChunksOpts{Limit: 2},
+	) {
+		if err != nil {
+			t.Fatalf("Chunks() error = %v", err)
+		}
+		ids = append(ids, chunk.ID)
+	}
+
+	if want := []string{"c-1", "c-2", "c-3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+}
+
+func TestClientIteratorError(t *testing.T) {
+	client := newIterTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"message":"project not found"}`)
+	})
+
+	var errs []error
+	for _, err := range client.Sessions(
+		context.Background(), "org-1", "proj-1", SessionListOptions{},
+	) {
+		errs = append(errs, err)
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("got %d results, want 1 error", len(errs))
+	}
+	var apiErr *APIError
+	if !errors.As(errs[0], &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("error = %v, want an APIError with status 404", errs[0])
+	}
+}
+
+func TestClientPromptsRangesAgain(t *testing.T) {
+	client := newIterTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		_, _ = fmt.Fprintf(w, `{"data":{"prompts":[{"name":"p-%s-a"},{"name":"p-%s-b"}],`+
+			`"totalCount":4}}`, page, page)
+	})
+	prompts := client.Prompts(context.Background(), "proj-1", "routines", PromptListOptions{})
+
+	for i := range 2 {
+		var names []string
+		for prompt, err := range prompts {
+			if err != nil {
+				t.Fatalf("Prompts() error = %v", err)
+			}
+			names = append(names, prompt.Name)
+		}
+		want := []string{"p-1-a", "p-1-b", "p-2-a", "p-2-b"}
+		if !reflect.DeepEqual(names, want) {
+			t.Errorf("range %d: names = %v, want %v", i+1, names, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package iai
+
+import "github.com/Interactive-AI-Labs/interactive-cli/internal/clients/platform"
+
+// Types of the platform API: the requests APIClient sends and the
+// resources it returns.
+type (
+	// Organizations, projects, traces, observations, sessions, scores, metrics, and prompts.
+	APIClient                 = platform.APIClient
+	Organization              = platform.Organization
+	Project                   = platform.Project
+	TraceInfo                 = platform.TraceInfo
+	TraceDetail               = platform.TraceDetail
+	TraceMeta                 = platform.TraceMeta
+	TraceListOptions          = platform.TraceListOptions
+	ObservationInfo           = platform.ObservationInfo
+	ObservationDetail         = platform.ObservationDetail
+	CursorMeta                = platform.CursorMeta
+	PageMeta                  = platform.PageMeta
+	StandaloneObservationInfo = platform.StandaloneObservationInfo
+	ObservationSearchOptions  = platform.ObservationSearchOptions
+	SessionInfo               = platform.SessionInfo
+	SessionTraceSummary       = platform.SessionTraceSummary
+	SessionDetail             = platform.SessionDetail
+	SessionListOptions        = platform.SessionListOptions
+	ScoreInfo                 = platform.ScoreInfo
+	ScoreListOptions          = platform.ScoreListOptions
+	ScoreCreateBody           = platform.ScoreCreateBody
+	ScoreCreateResult         = platform.ScoreCreateResult
+	DailyMetric               = platform.DailyMetric
+	ModelUsage                = platform.ModelUsage
+	MetricsDailyOptions       = platform.MetricsDailyOptions
+	DeleteMessageResponse     = platform.DeleteMessageResponse
+	BulkTraceDeleteBody       = platform.BulkTraceDeleteBody
+	PromptInfo                = platform.PromptInfo
+	PromptDetail              = platform.PromptDetail
+	CreatePromptBody          = platform.CreatePromptBody
+	PromptListResponse        = platform.PromptListResponse
+	PromptListOptions         = platform.PromptListOptions
+	SchemaResponse            = platform.SchemaResponse
+	CompatibilityEntry        = platform.CompatibilityEntry
+
+	// Datasets, annotation queues, comments, and score configs.
+	DatasetInfo                = platform.DatasetInfo
+	DatasetListOptions         = platform.DatasetListOptions
+	DatasetCreateBody          = platform.DatasetCreateBody
+	DatasetItemInfo            = platform.DatasetItemInfo
+	DatasetItemListOptions     = platform.DatasetItemListOptions
+	DatasetItemCreateBody      = platform.DatasetItemCreateBody
+	DatasetRunInfo             = platform.DatasetRunInfo
+	DatasetRunListOptions      = platform.DatasetRunListOptions
+	DatasetRunItemInfo         = platform.DatasetRunItemInfo
+	DatasetRunItemListOptions  = platform.DatasetRunItemListOptions
+	DatasetRunItemCreateBody   = platform.DatasetRunItemCreateBody
+	AnnotationQueueInfo        = platform.AnnotationQueueInfo
+	AnnotationQueueListOptions = platform.AnnotationQueueListOptions
+	AnnotationQueueCreateBody  = platform.AnnotationQueueCreateBody
+	QueueItemInfo              = platform.QueueItemInfo
+	QueueItemListOptions       = platform.QueueItemListOptions
+	QueueItemCreateBody        = platform.QueueItemCreateBody
+	QueueItemUpdateBody        = platform.QueueItemUpdateBody
+	CommentInfo                = platform.CommentInfo
+	CommentListOptions         = platform.CommentListOptions
+	CommentCreateBody          = platform.CommentCreateBody
+	ScoreConfigInfo            = platform.ScoreConfigInfo
+	ScoreConfigListOptions     = platform.ScoreConfigListOptions
+	ScoreConfigCreateBody      = platform.ScoreConfigCreateBody
+	ScoreConfigUpdateBody      = platform.ScoreConfigUpdateBody
+
+	// API keys.
+	ProjectAPIKey              = platform.ProjectAPIKey
+	CreateProjectAPIKeyBody    = platform.CreateProjectAPIKeyBody
+	UpdateProjectAPIKeyBody    = platform.UpdateProjectAPIKeyBody
+	RouterAPIKey               = platform.RouterAPIKey
+	RouterAPIKeyListResponse   = platform.RouterAPIKeyListResponse
+	CreateRouterAPIKeyBody     = platform.CreateRouterAPIKeyBody
+	CreateRouterAPIKeyResponse = platform.CreateRouterAPIKeyResponse
+	UpdateRouterAPIKeyBody     = platform.UpdateRouterAPIKeyBody
+	APIKeySuccessResponse      = platform.APIKeySuccessResponse
+
+	// MCP catalog.
+	McpCatalogEntry    = platform.McpCatalogEntry
+	McpCatalogListData = platform.McpCatalogListData
+
+	// Router models.
+	RouterModel            = platform.RouterModel
+	RouterModelListOptions = platform.RouterModelListOptions
+)